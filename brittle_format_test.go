@@ -593,3 +593,122 @@ func DividerFull(b float64) func(a float64) (value float64, err error) {
     }
 }
 */
+
+func TestStruct_CustomAggregateFunction(t *testing.T) {
+    fetSize := &morph.FieldExpressionType{
+        Name:    "EncodedSize",
+        Targets: 1,
+        Type:    morph.FieldExpressionTypeAggregate,
+        Default: "unsafe.Sizeof($this)",
+        Comment: "$ returns the encoded size of $self in bytes.",
+        FieldComment: "size of $this",
+        Collect: "$acc + $value",
+    }
+    fetFlags := &morph.FieldExpressionType{
+        Name:    "Flags",
+        Targets: 2,
+        Type:    morph.FieldExpressionTypeAggregate,
+        Default: "skip",
+        Comment: "$ returns a bitmask of the fields that differ between $a and $b.",
+        FieldComment: "does $a.$ differ from $b.$?",
+        Collect: "$acc | $value",
+        Initial: "0",
+    }
+
+    apple := morph.Struct{
+        Name: "Apple",
+        Fields: []morph.Field{
+            {
+                Name: "Variety",
+                Type: "string",
+                Custom: []morph.FieldExpression{
+                    {Type: fetSize,  Pattern: "4 + len($this)"},
+                    {Type: fetFlags, Pattern: "boolBit($a.$ != $b.$, 0)"},
+                },
+            },
+            {
+                Name: "Weight",
+                Type: "int64",
+                Custom: []morph.FieldExpression{
+                    {Type: fetFlags, Pattern: "boolBit($a.$ != $b.$, 1)"},
+                },
+            },
+            {
+                Name: "Picked",
+                Type: "time.Time",
+            },
+        },
+    }
+
+    tests := []struct{
+        do func() (morph.Function, error)
+        expected string
+    }{
+        {
+            func() (morph.Function, error) {
+                return apple.CustomUnaryFunction(fetSize.Name, "($self.$type.$untitle $self.$type) $()() int")
+            },
+            internal.Must(internal.FormatSource(`
+// EncodedSize returns the encoded size of apple in bytes.
+func (apple Apple) EncodedSize() int {
+    var _acc int
+
+    // size of apple.Variety
+    _agg0 := int(4 + len(apple.Variety))
+    _acc = _acc + _agg0
+
+    // size of apple.Weight
+    _agg1 := int(unsafe.Sizeof(apple.Weight))
+    _acc = _acc + _agg1
+
+    // size of apple.Picked
+    _agg2 := int(unsafe.Sizeof(apple.Picked))
+    _acc = _acc + _agg2
+
+    return _acc
+}
+`)),
+        },
+        {
+            func() (morph.Function, error) {
+                return apple.CustomBinaryFunction(fetFlags.Name, "Diff(x Apple, y Apple) uint8", apple)
+            },
+            internal.Must(internal.FormatSource(`
+// Diff returns a bitmask of the fields that differ between x and y.
+func Diff(x Apple, y Apple) uint8 {
+    var _acc uint8 = 0
+
+    // does x.Variety differ from y.Variety?
+    _agg0 := uint8(boolBit(x.Variety != y.Variety, 0))
+    _acc = _acc | _agg0
+
+    // does x.Weight differ from y.Weight?
+    _agg1 := uint8(boolBit(x.Weight != y.Weight, 1))
+    _acc = _acc | _agg1
+
+    // does x.Picked differ from y.Picked?
+    //skipped
+
+    return _acc
+}
+`)),
+        },
+    }
+
+    for i, tt := range tests {
+        f, err := tt.do()
+        if err != nil {
+            t.Errorf("test %d: unexpected error: %v", i, err)
+            continue
+        }
+        if f.String() != tt.expected {
+            t.Logf("expected: %s", tt.expected)
+            t.Logf("got: %s", f.String())
+            t.Errorf("test %d: unexpected output", i)
+        }
+    }
+
+    if _, err := apple.CustomUnaryFunction(fetSize.Name, "EncodedSize(apple Apple)"); err == nil {
+        t.Errorf("expected error for a signature without a result")
+    }
+}
@@ -0,0 +1,203 @@
+package morph
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/tawesoft/morph/internal"
+)
+
+// FallibleFieldMapper is like a [FieldMapper], except it may fail by
+// returning a non-nil error, for example when it meets a field of a type it
+// does not support.
+//
+// A FieldMapper can be converted into a FallibleFieldMapper with
+// [FieldMapper.Fallible], and the reverse with [FallibleFieldMapper.Must].
+type FallibleFieldMapper func(input Field, emit func(output Field)) error
+
+// FallibleStructMapper is like a [StructMapper], except it may fail by
+// returning a non-nil error.
+//
+// A StructMapper can be converted into a FallibleStructMapper with
+// [StructMapper.Fallible], and the reverse with [FallibleStructMapper.Must].
+type FallibleStructMapper func(in Struct) (Struct, error)
+
+// MapperError is the error returned when a [FallibleFieldMapper] or
+// [FallibleStructMapper] fails. It records the name of the input struct, the
+// name of the input field (for a field mapper), and the name of the mapper
+// that failed, if known.
+type MapperError struct {
+    Struct string
+    Field  string
+    Mapper string
+    Reason error
+}
+
+func (e MapperError) Error() string {
+    var parts []string
+    if e.Struct != "" {
+        parts = append(parts, fmt.Sprintf("struct %q", e.Struct))
+    }
+    if e.Field != "" {
+        parts = append(parts, fmt.Sprintf("field %q", e.Field))
+    }
+    if e.Mapper != "" {
+        parts = append(parts, fmt.Sprintf("mapper %q", e.Mapper))
+    }
+    return fmt.Sprintf("error mapping %s: %v", strings.Join(parts, ", "), e.Reason)
+}
+
+func (e MapperError) Unwrap() error {
+    return e.Reason
+}
+
+// NewMapperError returns err as a [MapperError] with the given struct, field,
+// and mapper names.
+//
+// If err is already a MapperError, then any empty names are filled in
+// instead, so that the innermost mapper to fail is the one reported.
+func NewMapperError(err error, structName string, fieldName string, mapperName string) MapperError {
+    me, ok := err.(MapperError)
+    if !ok {
+        me = MapperError{Reason: err}
+    }
+    if me.Struct == "" { me.Struct = structName }
+    if me.Field  == "" { me.Field  = fieldName }
+    if me.Mapper == "" { me.Mapper = mapperName }
+    return me
+}
+
+// Fallible returns a [FallibleFieldMapper] that applies the FieldMapper,
+// recovering any panic as an error.
+func (mapper FieldMapper) Fallible() FallibleFieldMapper {
+    if mapper == nil { return nil }
+    name := internal.FuncName(mapper)
+    return func(input Field, emit func(output Field)) (err error) {
+        defer func() {
+            if r := recover(); r != nil {
+                err = NewMapperError(fmt.Errorf("panic: %v", r), "", input.Name, name)
+            }
+        }()
+        mapper(input, emit)
+        return nil
+    }
+}
+
+// Must returns a [FieldMapper] that applies the FallibleFieldMapper, and
+// panics if it returns an error.
+func (mapper FallibleFieldMapper) Must() FieldMapper {
+    if mapper == nil { return nil }
+    return func(input Field, emit func(output Field)) {
+        internal.Assert(mapper(input, emit))
+    }
+}
+
+// Fallible returns a [FallibleStructMapper] that applies the StructMapper,
+// recovering any panic as an error.
+func (mapper StructMapper) Fallible() FallibleStructMapper {
+    if mapper == nil { return nil }
+    name := internal.FuncName(mapper)
+    return func(in Struct) (out Struct, err error) {
+        defer func() {
+            if r := recover(); r != nil {
+                err = NewMapperError(fmt.Errorf("panic: %v", r), in.Name, "", name)
+            }
+        }()
+        return mapper(in), nil
+    }
+}
+
+// Must returns a [StructMapper] that applies the FallibleStructMapper, and
+// panics if it returns an error.
+func (mapper FallibleStructMapper) Must() StructMapper {
+    if mapper == nil { return nil }
+    return func(in Struct) Struct {
+        return internal.Must(mapper(in))
+    }
+}
+
+// StructMapper returns a new FallibleStructMapper that applies the given
+// FallibleFieldMapper to every field on the input struct, stopping at the
+// first error, which is returned as a [MapperError].
+//
+// If the FallibleFieldMapper is reversible, then so is the returned
+// FallibleStructMapper.
+func (mapper FallibleFieldMapper) StructMapper() FallibleStructMapper {
+    if mapper == nil { return nil }
+    name := internal.FuncName(mapper)
+    return func(in Struct) (Struct, error) {
+        out, err := mapFields(in, name, mapper)
+        if err != nil {
+            return Struct{}, NewMapperError(err, in.Name, "", name)
+        }
+        return out, nil
+    }
+}
+
+// TryMap applies each given [FallibleStructMapper] (in order of the arguments
+// provided) to a struct and returns the result, stopping at the first error,
+// which is returned as a [MapperError].
+//
+// Lineage is recorded in the same way as [Struct.Map].
+func (s Struct) TryMap(mappers ... FallibleStructMapper) (Struct, error) {
+    ss := s
+    for _, t := range mappers {
+        if t == nil { continue }
+        name := internal.FuncName(t)
+        out, err := t(ss.Copy())
+        if err != nil {
+            return Struct{}, NewMapperError(err, ss.Name, "", name)
+        }
+        out.Derive(ss, name)
+        ss = out
+    }
+    return ss, nil
+}
+
+// TryMapFields applies each given [FallibleFieldMapper] (in order of the
+// arguments provided) to a struct and returns the result, stopping at the
+// first error, which is returned as a [MapperError].
+func (s Struct) TryMapFields(mappers ... FallibleFieldMapper) (Struct, error) {
+    return s.TryMap(internal.Map(FallibleFieldMapper.StructMapper, mappers)...)
+}
+
+// mapFields implements [FieldMapper.StructMapper] and
+// [FallibleFieldMapper.StructMapper]. The name of the mapper is recorded in
+// the lineage of each changed field and of the struct.
+func mapFields(in Struct, name string, mapper FallibleFieldMapper) (Struct, error) {
+    var results []Field
+    out := in.Copy()
+
+    emit := collector(&results)
+    for _, input := range out.Fields {
+        input := input
+        emit2 := func(output Field) {
+            output = output.Copy()
+            output.Rewrite(input)
+            output.Derive(input, name)
+            emit(output)
+        }
+        if err := mapper(input, emit2); err != nil {
+            return Struct{}, NewMapperError(err, "", input.Name, "")
+        }
+    }
+
+    out.Fields = results
+    out.Derive(in, name)
+    oldReverse := out.Reverse
+    out.Reverse = func(in2 Struct) Struct {
+        out2, _ := mapFields(in2, name + " (reversed)", func (input2 Field, emit2 func(output Field)) error {
+            if input2.Reverse != nil {
+                input2.Reverse(input2.Copy(), emit2)
+            } else {
+                emit2(input2.Copy())
+            }
+            return nil
+        })
+        if oldReverse != nil {
+            out2 = oldReverse(out2.Copy())
+        }
+        return out2
+    }
+    return out, nil
+}
@@ -0,0 +1,127 @@
+package morph_test
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/fieldmappers"
+    "github.com/tawesoft/morph/structmappers"
+)
+
+var errUnsupported = errors.New("unsupported type")
+
+// timeToInt64 is a FallibleFieldMapper that fails on any field with a type
+// other than time.Time or int64.
+func timeToInt64(input morph.Field, emit func(output morph.Field)) error {
+    switch input.Type {
+    case "time.Time":
+        input.Type = "int64"
+        input.Reverse = func(input2 morph.Field, emit2 func(output morph.Field)) {
+            input2.Type = "time.Time"
+            emit2(input2)
+        }
+    case "int64":
+    default:
+        return errUnsupported
+    }
+    emit(input)
+    return nil
+}
+
+func TestStruct_TryMapFields(t *testing.T) {
+    apple := morph.Struct{
+        Name: "Apple",
+        Fields: []morph.Field{
+            {Name: "Picked", Type: "time.Time"},
+            {Name: "Weight", Type: "int64"},
+        },
+    }
+
+    out, err := apple.TryMapFields(timeToInt64)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if got := out.Fields[0].Type; got != "int64" {
+        t.Errorf("got type %q, expected int64", got)
+    }
+    if got := out.Map(structmappers.Reverse).Fields[0].Type; got != "time.Time" {
+        t.Errorf("got reversed type %q, expected time.Time", got)
+    }
+
+    bad := apple.Copy()
+    bad.Fields = append(bad.Fields, morph.Field{Name: "Price", Type: "float64"})
+
+    _, err = bad.TryMapFields(fieldmappers.TryCompose(
+        morph.FieldMapper(fieldmappers.StripTags).Fallible(),
+        timeToInt64,
+    ))
+    var me morph.MapperError
+    if !errors.As(err, &me) {
+        t.Fatalf("expected MapperError, got %v", err)
+    }
+    if !errors.Is(err, errUnsupported) {
+        t.Errorf("expected error to wrap the mapper's error, got %v", err)
+    }
+    if (me.Struct != "Apple") || (me.Field != "Price") || !strings.HasSuffix(me.Mapper, "timeToInt64") {
+        t.Errorf("unexpected error fields %+v", me)
+    }
+}
+
+func TestStruct_TryMap(t *testing.T) {
+    apple := morph.Struct{Name: "Apple"}
+
+    fail := func(in morph.Struct) (morph.Struct, error) {
+        return morph.Struct{}, errUnsupported
+    }
+    panics := morph.StructMapper(func(in morph.Struct) morph.Struct {
+        panic("oops")
+    })
+
+    out, err := apple.TryMap(structmappers.TryCompose(
+        structmappers.Rename("$Orange").Fallible(),
+        nil,
+    ))
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if out.Name != "AppleOrange" {
+        t.Errorf("got name %q, expected AppleOrange", out.Name)
+    }
+
+    _, err = apple.TryMap(fail)
+    if !errors.Is(err, errUnsupported) {
+        t.Errorf("expected error, got %v", err)
+    }
+
+    _, err = apple.TryMap(panics.Fallible())
+    var me morph.MapperError
+    if !errors.As(err, &me) || (me.Struct != "Apple") {
+        t.Errorf("expected MapperError from a panic, got %v", err)
+    }
+
+    func() {
+        defer func() {
+            if r := recover(); r == nil {
+                t.Errorf("expected Must to panic")
+            }
+        }()
+        apple.Map(morph.FallibleStructMapper(fail).Must())
+    }()
+}
+
+func TestMapperError_Error(t *testing.T) {
+    err := morph.NewMapperError(fmt.Errorf("bad"), "Apple", "Picked", "fieldmappers.TimeToInt64")
+    expected := `error mapping struct "Apple", field "Picked", mapper "fieldmappers.TimeToInt64": bad`
+    if err.Error() != expected {
+        t.Errorf("got %q, expected %q", err.Error(), expected)
+    }
+
+    // inner names are preserved
+    err = morph.NewMapperError(err, "Orange", "Weight", "other")
+    if err.Error() != expected {
+        t.Errorf("got %q, expected %q", err.Error(), expected)
+    }
+}
@@ -11,10 +11,14 @@ import (
 
     "github.com/tawesoft/morph"
     "github.com/tawesoft/morph/fieldmappers/fieldops"
+    "github.com/tawesoft/morph/internal"
 )
 
 // Compose returns a new [morph.FieldMapper] that applies each of the given
 // non-nil mappers, from left to right. Nil mappers are skipped.
+//
+// Each mapper, rather than Compose itself, is recorded in the lineage of the
+// fields that it changes (see [morph.Field.Origin]).
 func Compose(mappers ... morph.FieldMapper) morph.FieldMapper {
     return func(input morph.Field, emit func(output morph.Field)) {
         outputs := []morph.Field{input}
@@ -23,12 +27,15 @@ func Compose(mappers ... morph.FieldMapper) morph.FieldMapper {
         }
         for _, mapper := range mappers {
             if mapper == nil { continue }
+            name := internal.FuncName(mapper)
             fields := outputs
             outputs = nil
             for _, in := range fields {
+                in := in
                 emit2 := func(output morph.Field) {
                     output = output.Copy()
                     output.Rewrite(input)
+                    output.Derive(in, name)
                     catch(output)
                 }
                 mapper(in, emit2)
@@ -40,6 +47,41 @@ func Compose(mappers ... morph.FieldMapper) morph.FieldMapper {
     }
 }
 
+// TryCompose returns a new [morph.FallibleFieldMapper] that applies each of
+// the given non-nil mappers, from left to right, in the same way as
+// [Compose]. Nil mappers are skipped. It stops at, and returns, the first
+// error.
+func TryCompose(mappers ... morph.FallibleFieldMapper) morph.FallibleFieldMapper {
+    return func(input morph.Field, emit func(output morph.Field)) error {
+        outputs := []morph.Field{input}
+        catch := func(out morph.Field) {
+            outputs = append(outputs, out)
+        }
+        for _, mapper := range mappers {
+            if mapper == nil { continue }
+            name := internal.FuncName(mapper)
+            fields := outputs
+            outputs = nil
+            for _, in := range fields {
+                in := in
+                emit2 := func(output morph.Field) {
+                    output = output.Copy()
+                    output.Rewrite(input)
+                    output.Derive(in, name)
+                    catch(output)
+                }
+                if err := mapper(in, emit2); err != nil {
+                    return morph.NewMapperError(err, "", in.Name, name)
+                }
+            }
+        }
+        for _, output := range outputs {
+            emit(output)
+        }
+        return nil
+    }
+}
+
 // All is a [morph.FieldMapper] that emits every input unchanged.
 func All(input morph.Field, emit func(output morph.Field)) {
     emit(input)
@@ -80,6 +80,10 @@ func (fet FieldExpressionType) rewriteString2(
                 } else if target == leftArgument.Name {
                     return target + "." + field.Name, true
                 } else if target == rightArgument.Name {
+                    // the matching field may have been renamed by a mapper
+                    if f, ok := rightStruct.matchingField(field); ok {
+                        return target + "." + f.Name, true
+                    }
                     return target + "." + field.Name, true
                 }
             } else if kw == "type" {
@@ -259,6 +263,104 @@ func (fet FieldExpressionType) rewriteString1(
     return tr.Replace(sig)
 }
 
+// Pattern returns the pattern of a field's expression of this type, or the
+// Default pattern if the field does not set one. An empty Default is
+// returned as "skip".
+func (fet *FieldExpressionType) Pattern(f Field) string {
+    pattern := fet.defaultAccessor()(f)
+    if pattern == "" { return "skip" }
+    return pattern
+}
+
+// FormatField performs the special '$'-token replacement, described by
+// [FieldExpression], on a pattern for a single field, f, for a generator that
+// does not produce a function over whole struct values.
+//
+// Instead, values gives an expression for the field's value on each target,
+// in order: "$a" and "$b", or "$dest" and "$src", for a two-target
+// FieldExpressionType, or "$self" for a single-target FieldExpressionType.
+// Each target token followed by ".$" (e.g. "$a.$"), and "$this", is replaced
+// by the matching value, which may be followed by ".$type" for the field's
+// type. The standalone token "$" is replaced by the Name of the
+// FieldExpressionType.
+//
+// Any other token, such as "$a" alone or a named field like "$a.Foo", refers
+// to a struct value, and is an error.
+func (fet *FieldExpressionType) FormatField(pattern string, f Field, values ... string) (string, error) {
+    var tokens []string
+    if fet.Targets == 1 {
+        tokens = []string{"self"}
+    } else if fet.Type == FieldExpressionTypeValue {
+        tokens = []string{"dest", "src"}
+    } else {
+        tokens = []string{"a", "b"}
+    }
+    if len(values) != len(tokens) {
+        return "", fmt.Errorf(
+            "FieldExpressionType %q has %d target(s), but got %d value(s)",
+            fet.Name, len(tokens), len(values),
+        )
+    }
+
+    // a target token that must be followed by ".$"
+    const unresolved = "\x00"
+    tr := internal.TokenReplacer{
+        Single: func() (string, bool) {
+            return fet.Name, true
+        },
+        ByName: func(name string) (string, bool) {
+            if (name == "this") && (fet.Targets == 1) {
+                return values[0], true
+            }
+            for _, token := range tokens {
+                if name == token { return unresolved + name, true }
+            }
+            return "", false
+        },
+        Modifier: func(kw string, target string) (string, bool) {
+            if strings.HasPrefix(target, unresolved) {
+                for i, token := range tokens {
+                    if (kw == "") && (target == unresolved + token) {
+                        return values[i], true
+                    }
+                }
+                return "", false
+            }
+            if kw == "type" {
+                for _, value := range values {
+                    if target == value { return f.Type, true }
+                }
+                return "", false
+            } else if kw == "title" {
+                if len(target) > 0 {
+                    // TODO unicode
+                    return strings.ToUpper(string(target[0])) + target[1:], true
+                }
+                return "", true
+            } else if kw == "untitle" {
+                if len(target) > 0 {
+                    // TODO unicode
+                    return strings.ToLower(string(target[0])) + target[1:], true
+                }
+                return "", true
+            }
+            return "", false
+        },
+    }
+    tr.SetDefaults()
+    out, err := tr.Replace(pattern)
+    if err != nil {
+        return "", fmt.Errorf("error formatting %s pattern for field %q: %w", fet.Name, f.Name, err)
+    }
+    if strings.Contains(out, unresolved) {
+        return "", fmt.Errorf(
+            "error formatting %s pattern %q for field %q: only the current field of a target, e.g. %q, is available",
+            fet.Name, pattern, f.Name, "$" + tokens[0] + ".$",
+        )
+    }
+    return out, nil
+}
+
 // Signature returns the Go type signature of a struct as a string, including
 // any generic type constraints, omitting the "type" and "struct" keywords.
 //
@@ -281,6 +383,16 @@ func (s Struct) Signature() string {
     return sb.String()
 }
 
+// Type returns the Go type of a struct as a string, including any generic
+// type parameters, but not their constraints.
+//
+// For example, returns a result like "Orange" or "Orange[X, Y]".
+func (s Struct) Type() string {
+    if len(s.TypeParams) == 0 { return s.Name }
+    names := internal.Map(func(f Field) string { return f.Name }, s.TypeParams)
+    return s.Name + "[" + strings.Join(names, ", ") + "]"
+}
+
 // String returns a Go source code representation of the given struct.
 //
 // For example, returns a result like:
@@ -338,43 +450,38 @@ func (s Struct) String() string {
     return string(out)
 }
 
-func (s Struct) matchFieldExpressionType(targets int, operation string) *FieldExpressionType {
-    // find matching FieldExpressionType
-    var fet *FieldExpressionType
-    for _, f := range s.Fields {
-        fe := f.GetCustomExpression(operation)
-        if fe == nil { continue }
-        fet = fe.Type
-        break
-    }
-    if fet == nil {
-        return nil
-    }
-    if fet.Targets != targets {
-        return nil
-    }
-    return fet
-}
-
+// CustomUnaryFunction generates a function with the given signature that
+// performs the named operation on a single struct value, by applying a
+// [FieldExpression] to each field.
+//
+// The [FieldExpressionType] for the operation is taken from any custom
+// field expression with that name on the struct's fields or, failing that,
+// from the global registry, [DefaultFieldExpressionTypes]. In the latter case,
+// the FieldExpressionType's Default pattern is applied to every field.
+//
+// It is an error, of type [FieldExpressionTypeConflictError], if these
+// disagree about which FieldExpressionType has that name.
+//
+// To use a different registry, see [FieldExpressionTypeRegistry.UnaryFunction].
 func (s Struct) CustomUnaryFunction(operation string, signature string) (Function, error) {
-    fet := s.matchFieldExpressionType(1, operation)
-    if fet == nil {
-        return Function{}, fmt.Errorf("no matching unary FieldExpressionType for operation %q", operation)
-    }
-    return fet.formatStructUnaryFunction(operation, signature, s)
+    return DefaultFieldExpressionTypes.UnaryFunction(s, operation, signature)
 }
 
-// CustomBinaryFunction TODO docs
+// CustomBinaryFunction generates a function with the given signature that
+// performs the named operation on two struct values, by applying a
+// [FieldExpression] to each field.
+//
+// The [FieldExpressionType] for the operation is found in the same way as
+// [Struct.CustomUnaryFunction].
 //
 // The struct specified as the method receiver is treated as argument "$a" or
 // "$dest" for $-token replacement. The argument specified as "other" is
 // treated as argument "$b" or "$src" for $-token replacement.
+//
+// To use a different registry, see
+// [FieldExpressionTypeRegistry.BinaryFunction].
 func (s Struct) CustomBinaryFunction(operation string, signature string, other Struct) (Function, error) {
-    fet := s.matchFieldExpressionType(2, operation)
-    if fet == nil {
-        return Function{}, fmt.Errorf("no matching binary FieldExpressionType for operation %q", operation)
-    }
-    return fet.formatStructBinaryFunction(operation, signature, s, other)
+    return DefaultFieldExpressionTypes.BinaryFunction(s, operation, signature, other)
 }
 
 // formatStructUnaryFunction generates Go source code for a function with the given
@@ -481,6 +588,9 @@ func (fet *FieldExpressionType) formatStructUnaryFunction(
         body = fet.formatStructBooleanFunctionBody(fields)
     } else if fet.Type == FieldExpressionTypeValue {
         body = fet.formatStructValueFunctionBody(arg, destIsReturnValue, fields)
+    } else if fet.Type == FieldExpressionTypeAggregate {
+        body, err = fet.formatStructAggregateFunctionBody(fs, fields)
+        if err != nil { return esc(err) }
     }
 
     fs.Comment, err = fet.rewriteString1(fet.Comment, fs.Name, self, arg, Field{})
@@ -605,6 +715,9 @@ func (fet *FieldExpressionType) formatStructBinaryFunction(
         body = fet.formatStructBooleanFunctionBody(fields)
     } else if fet.Type == FieldExpressionTypeValue {
         body = fet.formatStructValueFunctionBody(arg1, destIsReturnValue, fields)
+    } else if fet.Type == FieldExpressionTypeAggregate {
+        body, err = fet.formatStructAggregateFunctionBody(fs, fields)
+        if err != nil { return esc(err) }
     }
 
     fs.Comment, err = fet.rewriteString2(fet.Comment, fs.Name, aOrDestToken, aOrDest, arg1, Field{}, bOrSrcToken, bOrSrc, arg2)
@@ -662,6 +775,82 @@ func (fet *FieldExpressionType) formatStructBooleanFunctionBody(
     return sb.String()
 }
 
+// formatConversion formats a Go type conversion of a value to a type,
+// parenthesising the type where required e.g. "(*Foo)(x)".
+func formatConversion(Type string, value string) string {
+    if strings.HasPrefix(Type, "*") || strings.HasPrefix(Type, "<-") || strings.HasPrefix(Type, "func") {
+        return fmt.Sprintf("(%s)(%s)", Type, value)
+    }
+    return fmt.Sprintf("%s(%s)", Type, value)
+}
+
+// formatStructAggregateFunctionBody generates a function body that folds the
+// value of each field's expression, converted to the result type, into a
+// single result using the FieldExpressionType's Collect expression, starting
+// with its Initial value.
+//
+// The type of the result is the type of the single return value in the
+// function signature.
+func (fet *FieldExpressionType) formatStructAggregateFunctionBody(
+    fs FunctionSignature,
+    fields []Field,
+) (string, error) {
+    var sb bytes.Buffer
+
+    result, ok := fs.singleReturn()
+    if !ok {
+        return "", fmt.Errorf("expected exactly one return value in signature: %q", fs.String())
+    }
+    if fet.Collect == "" {
+        return "", fmt.Errorf("missing Collect expression on aggregate FieldExpressionType %q", fet.Name)
+    }
+
+    if fet.Initial == "" {
+        sb.WriteString(fmt.Sprintf("\tvar _acc %s\n\n", result.Type))
+    } else {
+        sb.WriteString(fmt.Sprintf("\tvar _acc %s = %s\n\n", result.Type, fet.Initial))
+    }
+
+    feAccessor := fet.defaultAccessor()
+
+    for i, f := range fields {
+        if i > 0 { sb.WriteString("\n") }
+
+        sb.WriteString(formatComment("\t", f.Comment))
+
+        pattern := feAccessor(f)
+        if pattern == "skip" {
+            sb.WriteString("\t//skipped\n")
+            continue
+        }
+
+        value := fmt.Sprintf("_agg%d", i)
+        tr := internal.TokenReplacer{
+            ByName: func(name string) (string, bool) {
+                if name == "acc" {
+                    return "_acc", true
+                } else if name == "value" {
+                    return value, true
+                }
+                return "", false
+            },
+        }
+        tr.SetDefaults()
+        collect, err := tr.Replace(fet.Collect)
+        if err != nil {
+            return "", fmt.Errorf("cannot rewrite field expression type collect pattern %q: %w", fet.Collect, err)
+        }
+
+        sb.WriteString(fmt.Sprintf("\t%s := %s\n", value, formatConversion(result.Type, pattern)))
+        sb.WriteString(fmt.Sprintf("\t_acc = %s\n", collect))
+    }
+
+    if len(fields) > 0 { sb.WriteString("\n") }
+    sb.WriteString("\treturn _acc")
+
+    return sb.String(), nil
+}
+
 func (fet *FieldExpressionType) formatStructValueFunctionBody(
     dest Argument,
     destIsReturnValue bool,
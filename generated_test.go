@@ -1,3 +1,83 @@
 package morph_test
 
-// TODO
+import (
+    "fmt"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/internal"
+)
+
+func TestGenerated_Aggregate(t *testing.T) {
+    fetNonZeroCount := &morph.FieldExpressionType{
+        Name:    "NonZeroCount",
+        Targets: 1,
+        Type:    morph.FieldExpressionTypeAggregate,
+        Default: "boolToInt($this != (func() (_zero $this.$type) { return })())",
+        Comment: "$ returns the number of fields on $self that are not zero.",
+        FieldComment: "is $this not zero?",
+        Collect: "$acc + $value",
+    }
+    fetHash := &morph.FieldExpressionType{
+        Name:    "Hash",
+        Targets: 1,
+        Type:    morph.FieldExpressionTypeAggregate,
+        Default: "skip",
+        Comment: "$ returns a hash of $self.",
+        Collect: "($acc * 31) ^ $value",
+        Initial: "17",
+    }
+
+    point := internal.Must(morph.ParseStruct("test.go", `
+package example
+
+type Point struct {
+    X, Y int
+    Label string
+}
+`, "Point"))
+    point.Fields[0].SetCustomExpression(morph.FieldExpression{Type: fetHash, Pattern: "$this"})
+    point.Fields[1].SetCustomExpression(morph.FieldExpression{Type: fetHash, Pattern: "$this"})
+
+    registry := morph.NewFieldExpressionTypeRegistry(morph.DefaultFieldExpressionTypes)
+    internal.Assert(registry.Register(fetNonZeroCount, fetHash))
+
+    nonZeroCount := internal.Must(registry.UnaryFunction(point, fetNonZeroCount.Name, "(p Point) $()() int"))
+    hash := internal.Must(registry.UnaryFunction(point, fetHash.Name, "(p Point) $()() uint64"))
+
+    source := fmt.Sprintf(`
+package main
+
+import "fmt"
+
+type Point struct {
+    X, Y int
+    Label string
+}
+
+func boolToInt(x bool) int {
+    if x { return 1 }
+    return 0
+}
+
+%s
+
+%s
+
+func main() {
+    fmt.Println(Point{}.NonZeroCount())
+    fmt.Println(Point{X: 1, Label: "a"}.NonZeroCount())
+    fmt.Println(Point{X: 1, Y: 2, Label: "b"}.NonZeroCount())
+    fmt.Println(Point{X: 1, Y: 2}.Hash() == Point{X: 1, Y: 2, Label: "c"}.Hash())
+    fmt.Println(Point{X: 1, Y: 2}.Hash() == Point{X: 2, Y: 1}.Hash())
+}
+`, nonZeroCount, hash)
+
+    internal.TestCompileAndRun(t, source, func(stdout string) error {
+        expected := "0\n2\n3\ntrue\nfalse\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
@@ -0,0 +1,469 @@
+// Package binarycodec generates methods that encode and decode structs in a
+// compact binary format, without using reflection at runtime.
+//
+// For a struct Foo, [Functions] generates:
+//
+//     func (foo Foo) AppendBinary(b []byte) ([]byte, error)
+//     func (foo Foo) MarshalBinary() ([]byte, error)
+//     func (foo *Foo) UnmarshalBinary(data []byte) error
+//
+// The generated methods call unexported helper functions, returned by
+// [Helpers], which must be generated once in the same package.
+//
+// Fields are encoded in order, with no padding or field identifiers, so the
+// encoding of a struct changes if its fields change. Each field is encoded
+// as follows:
+//
+//   - bool: one byte, 0 or 1.
+//   - int8, uint8, byte: one byte.
+//   - other integers: fixed-width little or big endian (int and uint as 64
+//     bits), or as a varint (with zigzag encoding for signed integers).
+//   - float32, float64: the IEEE 754 bits, fixed-width little or big endian.
+//   - string, []byte: a uvarint length, followed by the bytes.
+//   - slices: a uvarint length, followed by each element.
+//   - pointers: one byte, 0 for nil or 1 followed by the value.
+//   - any other type, such as a nested struct with generated methods, or
+//     time.Time: a uvarint length, followed by the result of its
+//     MarshalBinary method, and decoded with its UnmarshalBinary method.
+//
+// UnmarshalBinary checks bounds and returns a descriptive error, naming the
+// field and offset, on truncated or invalid input.
+package binarycodec
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+)
+
+// Encoding is a FieldExpressionType that overrides how a field, or the
+// numeric elements of a slice or pointer field, is encoded, with one of the
+// patterns:
+//
+//   - "little" for fixed-width little endian;
+//   - "big" for fixed-width big endian;
+//   - "varint" for a varint;
+//   - "skip" to omit the field from the encoding.
+//
+// The empty pattern, or "default", uses the [Options].
+//
+// For example:
+//
+//     field.SetCustomExpression(morph.FieldExpression{
+//         Type:    binarycodec.Encoding,
+//         Pattern: "varint",
+//     })
+var Encoding = &morph.FieldExpressionType{
+    Name:    "BinaryEncoding",
+    Targets: 1,
+    Type:    morph.FieldExpressionTypeVoid,
+    Default: "default",
+}
+
+// Options configure the generated methods.
+type Options struct {
+    // BigEndian, if true, encodes fixed-width numbers in big endian byte
+    // order instead of little endian.
+    BigEndian bool
+
+    // Varint, if true, encodes integers wider than 8 bits as varints instead
+    // of fixed-width numbers. Floats are always fixed-width.
+    Varint bool
+
+    // Underlying maps the name of a named type, such as "Celsius", to its
+    // underlying type, such as "float32", so that fields of that type can be
+    // encoded and decoded without requiring the type to implement
+    // encoding.BinaryMarshaler and encoding.BinaryUnmarshaler.
+    Underlying map[string]string
+}
+
+// Imports returns the sorted import paths required by the given generated
+// functions, for example from [Functions] and [Helpers].
+func Imports(functions ... morph.Function) []string {
+    return source.Imports(functions...)
+}
+
+// Functions generates the methods, described in the package documentation,
+// for the given struct.
+//
+// Maps, arrays, interfaces, channels, and functions are not supported.
+// It is an error to use the "varint" [Encoding] on a floating point field.
+func Functions(s morph.Struct, options Options) ([]morph.Function, error) {
+    esc := func(err error) ([]morph.Function, error) {
+        return nil, fmt.Errorf(
+            "error generating binary methods for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    g := &generator{
+        s:        s,
+        options:  options,
+        receiver: source.Receiver(s.Name, "b", "data"),
+    }
+
+    for _, f := range s.Fields {
+        mode, err := fieldMode(f, options)
+        if err != nil { return esc(err) }
+        if mode == modeSkip { continue }
+        g.fields = append(g.fields, field{field: f, mode: mode})
+    }
+
+    appendBinary, err := g.appendBinary()
+    if err != nil { return esc(err) }
+    unmarshalBinary, err := g.unmarshalBinary()
+    if err != nil { return esc(err) }
+
+    functions := []morph.Function{appendBinary, g.marshalBinary(), unmarshalBinary}
+    for i := range functions {
+        functions[i].Body = strings.TrimSpace(functions[i].Body)
+    }
+    return functions, nil
+}
+
+type mode int
+
+const (
+    modeLittle mode = iota
+    modeBig
+    modeVarint
+    modeSkip
+
+    // varint for integers, and fixed-width little or big endian for floats,
+    // from Options.Varint
+    modeVarintLittle
+    modeVarintBig
+)
+
+// varint returns true if integers are encoded as varints in a mode.
+func (m mode) varint() bool {
+    return (m == modeVarint) || (m == modeVarintLittle) || (m == modeVarintBig)
+}
+
+// fieldMode returns the encoding mode of a field.
+func fieldMode(f morph.Field, options Options) (mode, error) {
+    pattern := Encoding.Default
+    if fe := f.GetCustomExpression(Encoding.Name); fe != nil {
+        if fe.Type != Encoding {
+            return 0, morph.FieldExpressionTypeConflictError{Name: Encoding.Name}
+        }
+        if fe.Pattern != "" { pattern = fe.Pattern }
+    }
+
+    switch pattern {
+    case "default":
+        if options.Varint && options.BigEndian { return modeVarintBig, nil }
+        if options.Varint { return modeVarintLittle, nil }
+        if options.BigEndian { return modeBig, nil }
+        return modeLittle, nil
+    case "little": return modeLittle, nil
+    case "big":    return modeBig, nil
+    case "varint": return modeVarint, nil
+    case "skip":   return modeSkip, nil
+    default:
+        return 0, fmt.Errorf("unsupported encoding %q for field %q", pattern, f.Name)
+    }
+}
+
+type field struct {
+    field morph.Field
+    mode  mode
+}
+
+type generator struct {
+    s        morph.Struct
+    options  Options
+    receiver string
+    fields   []field
+    n        int // counter for unique local variable names
+}
+
+// next returns a unique suffix for local variable names.
+func (g *generator) next() int {
+    g.n++
+    return g.n
+}
+
+type kind int
+
+const (
+    kindOther kind = iota // implements encoding.BinaryMarshaler and encoding.BinaryUnmarshaler
+    kindBool
+    kindByte
+    kindInt
+    kindUint
+    kindFloat
+    kindString
+    kindBytes
+    kindPointer
+    kindSlice
+)
+
+// underlying returns the underlying type of a type, from
+// [Options].Underlying, or the type itself.
+func (g *generator) underlying(Type string) string {
+    if underlying, ok := g.options.Underlying[Type]; ok { return underlying }
+    return Type
+}
+
+// kindOf returns the kind of a type, and its bit size for numeric types, or
+// its element type for pointers and slices.
+func (g *generator) kindOf(Type string) (k kind, bits int, elem string, err error) {
+    Type = g.underlying(Type)
+    switch Type {
+    case "bool":            return kindBool, 8, "", nil
+    case "int8":            return kindByte, 8, "", nil
+    case "uint8", "byte":   return kindByte, 8, "", nil
+    case "int16":           return kindInt, 16, "", nil
+    case "int32", "rune":   return kindInt, 32, "", nil
+    case "int64", "int":    return kindInt, 64, "", nil
+    case "uint16":          return kindUint, 16, "", nil
+    case "uint32":          return kindUint, 32, "", nil
+    case "uint64", "uint", "uintptr": return kindUint, 64, "", nil
+    case "float32":         return kindFloat, 32, "", nil
+    case "float64":         return kindFloat, 64, "", nil
+    case "string":          return kindString, 0, "", nil
+    case "[]byte", "[]uint8": return kindBytes, 0, "", nil
+    }
+
+    switch {
+    case strings.HasPrefix(Type, "*"):
+        return kindPointer, 0, Type[1:], nil
+    case strings.HasPrefix(Type, "[]"):
+        return kindSlice, 0, Type[2:], nil
+    case strings.HasPrefix(Type, "["),
+        strings.HasPrefix(Type, "map["),
+        strings.HasPrefix(Type, "chan "),
+        strings.HasPrefix(Type, "<-chan "),
+        strings.HasPrefix(Type, "func("),
+        strings.HasPrefix(Type, "interface{"),
+        strings.HasPrefix(Type, "struct{"),
+        Type == "any", Type == "error":
+        return 0, 0, "", fmt.Errorf("unsupported type %q", Type)
+    }
+    return kindOther, 0, "", nil
+}
+
+// order returns the encoding/binary byte order for a mode.
+func order(m mode) string {
+    if (m == modeBig) || (m == modeVarintBig) { return "binary.BigEndian" }
+    return "binary.LittleEndian"
+}
+
+func (g *generator) appendBinary() (morph.Function, error) {
+    var sb strings.Builder
+    for _, f := range g.fields {
+        fmt.Fprintf(&sb, "// %s\n", f.field.Name)
+        if err := g.encode(&sb, g.receiver + "." + f.field.Name, f.field.Type, f.mode); err != nil {
+            return morph.Function{}, fmt.Errorf("field %q: %w", f.field.Name, err)
+        }
+        sb.WriteString("\n")
+    }
+    sb.WriteString("return b, nil")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("AppendBinary appends the binary encoding of a [%s] value to b, "+
+                "and returns the extended buffer.", g.s.Name),
+            Name:      "AppendBinary",
+            Arguments: []morph.Argument{{Name: "b", Type: "[]byte"}},
+            Returns:   []morph.Argument{{Type: "[]byte"}, {Type: "error"}},
+            Receiver:  morph.Argument{Name: g.receiver, Type: g.s.Type()},
+        },
+        Body: sb.String(),
+    }, nil
+}
+
+func (g *generator) marshalBinary() morph.Function {
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment:  fmt.Sprintf("MarshalBinary returns the binary encoding of a [%s] value.", g.s.Name),
+            Name:     "MarshalBinary",
+            Returns:  []morph.Argument{{Type: "[]byte"}, {Type: "error"}},
+            Receiver: morph.Argument{Name: g.receiver, Type: g.s.Type()},
+        },
+        Body: fmt.Sprintf("return %s.AppendBinary(nil)", g.receiver),
+    }
+}
+
+// encode writes code that appends the binary encoding of x, of the given
+// type, to b.
+func (g *generator) encode(sb *strings.Builder, x string, Type string, m mode) error {
+    k, bits, elem, err := g.kindOf(Type)
+    if err != nil { return err }
+
+    switch k {
+    case kindBool:
+        fmt.Fprintf(sb, "if %s {\nb = append(b, 1)\n} else {\nb = append(b, 0)\n}\n", x)
+    case kindByte:
+        fmt.Fprintf(sb, "b = append(b, byte(%s))\n", x)
+    case kindInt, kindUint:
+        if m.varint() {
+            if k == kindInt {
+                fmt.Fprintf(sb, "b = binary.AppendVarint(b, int64(%s))\n", x)
+            } else {
+                fmt.Fprintf(sb, "b = binary.AppendUvarint(b, uint64(%s))\n", x)
+            }
+        } else {
+            fmt.Fprintf(sb, "b = %s.AppendUint%d(b, uint%d(%s))\n", order(m), bits, bits, x)
+        }
+    case kindFloat:
+        if m == modeVarint { return fmt.Errorf("varint encoding of %s is not supported", Type) }
+        fmt.Fprintf(sb, "b = %s.AppendUint%d(b, math.Float%dbits(float%d(%s)))\n", order(m), bits, bits, bits, x)
+    case kindString, kindBytes:
+        fmt.Fprintf(sb, "b = binary.AppendUvarint(b, uint64(len(%s)))\n", x)
+        fmt.Fprintf(sb, "b = append(b, %s...)\n", x)
+    case kindPointer:
+        fmt.Fprintf(sb, "if %s == nil {\nb = append(b, 0)\n} else {\nb = append(b, 1)\n", x)
+        if err := g.encode(sb, "(*" + x + ")", elem, m); err != nil { return err }
+        sb.WriteString("}\n")
+    case kindSlice:
+        n := g.next()
+        fmt.Fprintf(sb, "b = binary.AppendUvarint(b, uint64(len(%s)))\n", x)
+        fmt.Fprintf(sb, "for _, _x%d := range %s {\n", n, x)
+        if err := g.encode(sb, fmt.Sprintf("_x%d", n), elem, m); err != nil { return err }
+        sb.WriteString("}\n")
+    default:
+        n := g.next()
+        fmt.Fprintf(sb, "if _v%d, _err := %s.MarshalBinary(); _err != nil {\n", n, x)
+        sb.WriteString("return nil, _err\n")
+        fmt.Fprintf(sb, "} else {\nb = binary.AppendUvarint(b, uint64(len(_v%d)))\n", n)
+        fmt.Fprintf(sb, "b = append(b, _v%d...)\n}\n", n)
+    }
+    return nil
+}
+
+func (g *generator) unmarshalBinary() (morph.Function, error) {
+    var sb strings.Builder
+    sb.WriteString("_i := 0\n\n")
+    for _, f := range g.fields {
+        fmt.Fprintf(&sb, "// %s\n", f.field.Name)
+        what := g.s.Name + "." + f.field.Name
+        if err := g.decode(&sb, g.receiver + "." + f.field.Name, f.field.Type, f.mode, what); err != nil {
+            return morph.Function{}, fmt.Errorf("field %q: %w", f.field.Name, err)
+        }
+        sb.WriteString("\n")
+    }
+    sb.WriteString("if _i != len(data) {\n")
+    fmt.Fprintf(&sb, "return fmt.Errorf(\"binary: %%d unexpected bytes after decoding %s at offset %%d\", len(data) - _i, _i)\n", g.s.Name)
+    sb.WriteString("}\n")
+    sb.WriteString("return nil")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment:   fmt.Sprintf("UnmarshalBinary decodes a [%s] value from its binary encoding in data.", g.s.Name),
+            Name:      "UnmarshalBinary",
+            Arguments: []morph.Argument{{Name: "data", Type: "[]byte"}},
+            Returns:   []morph.Argument{{Type: "error"}},
+            Receiver:  morph.Argument{Name: g.receiver, Type: "*" + g.s.Type()},
+        },
+        Body: sb.String(),
+    }, nil
+}
+
+// decode writes code that decodes a value of the given type from data at
+// index _i into the addressable target, advancing _i, and returning any
+// error. The what argument describes the target in error messages.
+func (g *generator) decode(sb *strings.Builder, target string, Type string, m mode, what string) error {
+    k, bits, elem, err := g.kindOf(Type)
+    if err != nil { return err }
+    base := g.underlying(Type)
+    n := g.next()
+
+    // bytes writes code that reads count bytes into _vN.
+    bytes := func(count string) {
+        fmt.Fprintf(sb, "_v%d, _next%d, _err := binaryBytes(data, _i, %s, %q)\n", n, n, count, what)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+    }
+
+    sb.WriteString("{\n")
+    switch k {
+    case kindBool:
+        bytes("1")
+        fmt.Fprintf(sb, "if _v%d[0] > 1 {\nreturn fmt.Errorf(\"binary: invalid bool decoding %%s at offset %%d\", %q, _i)\n}\n", n, what)
+        fmt.Fprintf(sb, "%s = %s(_v%d[0] == 1)\n", target, Type, n)
+    case kindByte:
+        bytes("1")
+        fmt.Fprintf(sb, "%s = %s(_v%d[0])\n", target, Type, n)
+    case kindInt, kindUint:
+        if m.varint() {
+            if k == kindInt {
+                fmt.Fprintf(sb, "_v%d, _next%d, _err := binaryVarint(data, _i, %q)\n", n, n, what)
+                sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+                if (bits < 64) || (base == "int") {
+                    fmt.Fprintf(sb, "if int64(%s(_v%d)) != _v%d {\nreturn binaryRangeError(%q, %q, _i)\n}\n",
+                        Type, n, n, what, Type)
+                }
+            } else {
+                fmt.Fprintf(sb, "_v%d, _next%d, _err := binaryUvarint(data, _i, %q)\n", n, n, what)
+                sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+                if (bits < 64) || (base == "uint") || (base == "uintptr") {
+                    fmt.Fprintf(sb, "if uint64(%s(_v%d)) != _v%d {\nreturn binaryRangeError(%q, %q, _i)\n}\n",
+                        Type, n, n, what, Type)
+                }
+            }
+            fmt.Fprintf(sb, "%s = %s(_v%d)\n", target, Type, n)
+        } else {
+            bytes(fmt.Sprintf("%d", bits / 8))
+            wide := fmt.Sprintf("uint%d", bits)
+            if k == kindInt { wide = fmt.Sprintf("int%d", bits) }
+            fmt.Fprintf(sb, "_x%d := %s(%s.Uint%d(_v%d))\n", n, wide, order(m), bits, n)
+            if (base == "int") || (base == "uint") || (base == "uintptr") {
+                // may be narrower than 64 bits on some platforms
+                fmt.Fprintf(sb, "if %s(%s(_x%d)) != _x%d {\nreturn binaryRangeError(%q, %q, _i)\n}\n",
+                    wide, Type, n, n, what, Type)
+            }
+            fmt.Fprintf(sb, "%s = %s(_x%d)\n", target, Type, n)
+        }
+    case kindFloat:
+        if m == modeVarint { return fmt.Errorf("varint encoding of %s is not supported", Type) }
+        bytes(fmt.Sprintf("%d", bits / 8))
+        fmt.Fprintf(sb, "%s = %s(math.Float%dfrombits(%s.Uint%d(_v%d)))\n", target, Type, bits, order(m), bits, n)
+    case kindString:
+        fmt.Fprintf(sb, "_n%d, _l%d, _err := binaryLength(data, _i, %q)\n", n, n, what)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+        fmt.Fprintf(sb, "_i = _l%d\n", n)
+        bytes(fmt.Sprintf("_n%d", n))
+        fmt.Fprintf(sb, "%s = %s(_v%d)\n", target, Type, n)
+    case kindBytes:
+        fmt.Fprintf(sb, "_n%d, _l%d, _err := binaryLength(data, _i, %q)\n", n, n, what)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+        fmt.Fprintf(sb, "_i = _l%d\n", n)
+        bytes(fmt.Sprintf("_n%d", n))
+        fmt.Fprintf(sb, "%s = %s(append([]byte(nil), _v%d...))\n", target, Type, n)
+    case kindPointer:
+        bytes("1")
+        fmt.Fprintf(sb, "switch _v%d[0] {\n", n)
+        fmt.Fprintf(sb, "case 0:\n%s = nil\n_i = _next%d\n", target, n)
+        fmt.Fprintf(sb, "case 1:\n_i = _next%d\n", n)
+        fmt.Fprintf(sb, "if %s == nil {\n%s = new(%s)\n}\n", target, target, elem)
+        if err := g.decode(sb, "(*" + target + ")", elem, m, what); err != nil { return err }
+        fmt.Fprintf(sb, "default:\nreturn fmt.Errorf(\"binary: invalid pointer flag decoding %%s at offset %%d\", %q, _i)\n", what)
+        sb.WriteString("}\n")
+        sb.WriteString("}\n")
+        return nil
+    case kindSlice:
+        fmt.Fprintf(sb, "_n%d, _next%d, _err := binaryLength(data, _i, %q)\n", n, n, what)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+        fmt.Fprintf(sb, "_i = _next%d\n", n)
+        fmt.Fprintf(sb, "_s%d := make(%s, _n%d)\n", n, Type, n)
+        fmt.Fprintf(sb, "for _j%d := range _s%d {\n", n, n)
+        if err := g.decode(sb, fmt.Sprintf("_s%d[_j%d]", n, n), elem, m, what); err != nil { return err }
+        sb.WriteString("}\n")
+        fmt.Fprintf(sb, "%s = _s%d\n", target, n)
+        sb.WriteString("}\n")
+        return nil
+    default:
+        fmt.Fprintf(sb, "_n%d, _l%d, _err := binaryLength(data, _i, %q)\n", n, n, what)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+        fmt.Fprintf(sb, "_i = _l%d\n", n)
+        bytes(fmt.Sprintf("_n%d", n))
+        fmt.Fprintf(sb, "if _err := %s.UnmarshalBinary(_v%d); _err != nil {\n", target, n)
+        fmt.Fprintf(sb, "return fmt.Errorf(\"binary: error decoding %%s at offset %%d: %%w\", %q, _i, _err)\n}\n", what)
+    }
+    fmt.Fprintf(sb, "_i = _next%d\n", n)
+    sb.WriteString("}\n")
+    return nil
+}
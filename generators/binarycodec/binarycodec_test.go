@@ -0,0 +1,237 @@
+package binarycodec_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/binarycodec"
+    "github.com/tawesoft/morph/internal"
+)
+
+const source = `
+package example
+
+type Celsius float32
+
+type Point struct {
+    X, Y int32
+}
+
+type Record struct {
+    Ok      bool
+    Small   int8
+    Byte    uint8
+    Count   int
+    Id      uint64
+    Delta   int16
+    Ratio   float64
+    Scale   float32
+    Name    string
+    Data    []byte
+    Note    *string
+    Missing *int32
+    Values  []int64
+    Origin  Point
+    Path    []*Point
+    When    time.Time
+    Cache   string
+    Temp    Celsius
+}
+`
+
+func functions(options binarycodec.Options) []morph.Function {
+    point := internal.Must(morph.ParseStruct("test.go", source, "Point"))
+    record := internal.Must(morph.ParseStruct("test.go", source, "Record"))
+
+    for i, f := range record.Fields {
+        switch f.Name {
+        case "Id":
+            record.Fields[i].SetCustomExpression(morph.FieldExpression{
+                Type:    binarycodec.Encoding,
+                Pattern: "big",
+            })
+        case "Values":
+            record.Fields[i].SetCustomExpression(morph.FieldExpression{
+                Type:    binarycodec.Encoding,
+                Pattern: "varint",
+            })
+        case "Cache":
+            record.Fields[i].SetCustomExpression(morph.FieldExpression{
+                Type:    binarycodec.Encoding,
+                Pattern: "skip",
+            })
+        }
+    }
+
+    options.Underlying = map[string]string{"Celsius": "float32"}
+    var fs []morph.Function
+    fs = append(fs, internal.Must(binarycodec.Functions(point, options))...)
+    fs = append(fs, internal.Must(binarycodec.Functions(record, options))...)
+    fs = append(fs, binarycodec.Helpers()...)
+    return fs
+}
+
+func program(functions []morph.Function, main string) string {
+    point := internal.Must(morph.ParseStruct("test.go", source, "Point"))
+    record := internal.Must(morph.ParseStruct("test.go", source, "Record"))
+
+    imports := append(binarycodec.Imports(functions...), "fmt", "reflect", "time")
+    main = "type Celsius float32\n\nvar _ = reflect.DeepEqual\n\n" + main
+    return internal.TestProgram(imports, []fmt.Stringer{point, record}, functions, main)
+}
+
+func TestFunctions(t *testing.T) {
+    main := `
+func main() {
+    note := "note"
+    r := Record{
+        Ok:     true,
+        Small:  -2,
+        Byte:   200,
+        Count:  -1234567,
+        Id:     0x0102030405060708,
+        Delta:  -300,
+        Ratio:  3.25,
+        Scale:  -0.5,
+        Name:   "héllo",
+        Data:   []byte{1, 2, 3},
+        Note:   &note,
+        Values: []int64{-1, 0, 1, 1 << 40},
+        Origin: Point{X: 1, Y: -1},
+        Path:   []*Point{{X: 2, Y: 3}, nil},
+        When:   time.Date(2000, 1, 2, 3, 4, 5, 6, time.UTC),
+        Cache:  "cache",
+        Temp:   -40.5,
+    }
+
+    data, err := r.MarshalBinary()
+    if err != nil { fmt.Println(err); return }
+    fmt.Printf("%x\n", data[:4])
+
+    var got Record
+    if err := got.UnmarshalBinary(data); err != nil { fmt.Println(err); return }
+    r.Cache = ""
+    fmt.Println(reflect.DeepEqual(r, got))
+
+    var empty Record
+    data, _ = Record{}.MarshalBinary()
+    if err := empty.UnmarshalBinary(data); err != nil { fmt.Println(err); return }
+
+    for _, n := range []int{0, 5, 20} {
+        fmt.Println(got.UnmarshalBinary(data[:n]))
+    }
+    fmt.Println(got.UnmarshalBinary(append(data, 0)))
+
+    data, _ = Point{X: 1, Y: 2}.MarshalBinary()
+    fmt.Printf("%x\n", data)
+}
+`
+
+    internal.TestCompileAndRun(t, program(functions(binarycodec.Options{}), main), func(stdout string) error {
+        expected := strings.Join([]string{
+            "01fec879",
+            "true",
+            "binary: unexpected end of input decoding Record.Ok at offset 0 (need 1 bytes, have 0)",
+            "binary: unexpected end of input decoding Record.Count at offset 3 (need 8 bytes, have 2)",
+            "binary: unexpected end of input decoding Record.Delta at offset 19 (need 2 bytes, have 1)",
+            "binary: 1 unexpected bytes after decoding Record at offset 68",
+            "0100000002000000",
+        }, "\n") + "\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_varint(t *testing.T) {
+    main := `
+func main() {
+    data, _ := Point{X: -1, Y: 64}.MarshalBinary()
+    fmt.Printf("%x\n", data)
+
+    var p Point
+    fmt.Println(p.UnmarshalBinary([]byte{0x01, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}))
+    fmt.Println(p.UnmarshalBinary([]byte{0x01, 0x80}))
+
+    var r Record
+    fmt.Println(r.UnmarshalBinary([]byte{0, 0, 0, 0, 0xFF}))
+}
+`
+
+    internal.TestCompileAndRun(t, program(functions(binarycodec.Options{Varint: true}), main), func(stdout string) error {
+        expected := strings.Join([]string{
+            "018001",
+            "binary: value out of range for int32 decoding Point.Y at offset 1",
+            "binary: unexpected end of input decoding Point.Y at offset 1",
+            "binary: unexpected end of input decoding Record.Id at offset 4 (need 8 bytes, have 1)",
+        }, "\n") + "\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_bigEndian(t *testing.T) {
+    main := `
+func main() {
+    data, _ := Point{X: 1, Y: 2}.MarshalBinary()
+    fmt.Printf("%x\n", data)
+}
+`
+
+    internal.TestCompileAndRun(t, program(functions(binarycodec.Options{BigEndian: true}), main), func(stdout string) error {
+        expected := "0000000100000002\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_receiverName(t *testing.T) {
+    // a receiver that would clash with an imported package
+    binary := morph.Struct{Name: "Binary", Fields: []morph.Field{{Name: "X", Type: "int32"}}}
+    functions := internal.Must(binarycodec.Functions(binary, binarycodec.Options{}))
+    functions = append(functions, binarycodec.Helpers()...)
+
+    main := `
+func main() {
+    data, err := Binary{X: 3}.MarshalBinary()
+    var b Binary
+    fmt.Println(data, err, b.UnmarshalBinary(data), b.X)
+}
+`
+    imports := append(binarycodec.Imports(functions...), "fmt")
+    program := internal.TestProgram(imports, []fmt.Stringer{binary}, functions, main)
+
+    internal.TestCompileAndRun(t, program, func(stdout string) error {
+        expected := "[3 0 0 0] <nil> <nil> 3\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_errors(t *testing.T) {
+    varint := morph.Field{Name: "X", Type: "float64"}
+    varint.SetCustomExpression(morph.FieldExpression{Type: binarycodec.Encoding, Pattern: "varint"})
+    unknown := morph.Field{Name: "X", Type: "int"}
+    unknown.SetCustomExpression(morph.FieldExpression{Type: binarycodec.Encoding, Pattern: "huge"})
+
+    for _, s := range []morph.Struct{
+        {Name: "A", Fields: []morph.Field{{Name: "X", Type: "map[string]int"}}},
+        {Name: "B", Fields: []morph.Field{{Name: "X", Type: "[4]int"}}},
+        {Name: "C", Fields: []morph.Field{varint}},
+        {Name: "D", Fields: []morph.Field{unknown}},
+        {Name: "E", Fields: []morph.Field{{Name: "X", Type: "[]any"}}},
+    } {
+        if _, err := binarycodec.Functions(s, binarycodec.Options{}); err == nil {
+            t.Errorf("expected error for struct %s", s.Name)
+        }
+    }
+}
@@ -0,0 +1,79 @@
+package binarycodec
+
+import (
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+)
+
+// Helpers returns unexported helper functions, each with a name starting
+// with "binary", that are called by the generated methods. They must be
+// generated exactly once in each package that uses the generated methods.
+//
+// Use [Imports] to find the import paths they require.
+func Helpers() []morph.Function {
+    return source.Functions(helpers)
+}
+
+const helpers = `package helpers
+
+// binaryBytes returns the n bytes at index i of data, and the index of the
+// following byte, or an error if data is too short.
+func binaryBytes(data []byte, i int, n int, what string) ([]byte, int, error) {
+    if (n < 0) || (len(data) - i < n) {
+        return nil, i, fmt.Errorf("binary: unexpected end of input decoding %s at offset %d (need %d bytes, have %d)",
+            what, i, n, len(data) - i)
+    }
+    return data[i:i+n], i + n, nil
+}
+
+// binaryUvarint decodes the unsigned varint at index i of data, and returns
+// it and the index of the following byte.
+func binaryUvarint(data []byte, i int, what string) (uint64, int, error) {
+    if i >= len(data) {
+        return 0, i, fmt.Errorf("binary: unexpected end of input decoding %s at offset %d", what, i)
+    }
+    v, n := binary.Uvarint(data[i:])
+    if n == 0 {
+        return 0, i, fmt.Errorf("binary: unexpected end of input decoding %s at offset %d", what, i)
+    }
+    if n < 0 {
+        return 0, i, fmt.Errorf("binary: varint overflows 64 bits decoding %s at offset %d", what, i)
+    }
+    return v, i + n, nil
+}
+
+// binaryVarint decodes the signed (zigzag) varint at index i of data, and
+// returns it and the index of the following byte.
+func binaryVarint(data []byte, i int, what string) (int64, int, error) {
+    if i >= len(data) {
+        return 0, i, fmt.Errorf("binary: unexpected end of input decoding %s at offset %d", what, i)
+    }
+    v, n := binary.Varint(data[i:])
+    if n == 0 {
+        return 0, i, fmt.Errorf("binary: unexpected end of input decoding %s at offset %d", what, i)
+    }
+    if n < 0 {
+        return 0, i, fmt.Errorf("binary: varint overflows 64 bits decoding %s at offset %d", what, i)
+    }
+    return v, i + n, nil
+}
+
+// binaryLength decodes the unsigned varint length prefix at index i of data,
+// and returns it and the index of the following byte, or an error if the
+// length exceeds the remaining input (as every encoded value is at least one
+// byte).
+func binaryLength(data []byte, i int, what string) (int, int, error) {
+    n, next, err := binaryUvarint(data, i, what)
+    if err != nil { return 0, i, err }
+    if n > uint64(len(data) - next) {
+        return 0, i, fmt.Errorf("binary: length %d exceeds remaining input decoding %s at offset %d", n, what, i)
+    }
+    return int(n), next, nil
+}
+
+// binaryRangeError returns an error for a decoded value that overflows its
+// type.
+func binaryRangeError(what string, Type string, offset int) error {
+    return fmt.Errorf("binary: value out of range for %s decoding %s at offset %d", Type, what, offset)
+}
+`
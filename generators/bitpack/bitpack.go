@@ -0,0 +1,306 @@
+// Package bitpack generates functions for bit-packed structs, such as those
+// created by [structmappers.Packed], where small integer and boolean fields
+// are packed into a single unsigned integer.
+//
+// For a struct Foo, and a packed struct FooPacked, with a single field Bits,
+// [Functions] generates:
+//
+//     func (fooPacked FooPacked) Bar() T          // for each field Bar of type T
+//     func (fooPacked *FooPacked) SetBar(bar T)   // for each field Bar of type T
+//     func (foo Foo) Pack() FooPacked
+//     func (fooPacked FooPacked) Unpack() Foo
+//
+// Fields are packed in order, starting at the least significant bit.
+//
+// [structmappers.Packed]: https://pkg.go.dev/github.com/tawesoft/morph/structmappers#Packed
+package bitpack
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/internal"
+)
+
+// storageBits returns the number of bits in a storage type, or zero if the
+// type is not a supported storage type.
+func storageBits(Type string) int {
+    switch Type {
+    case "uint8", "byte": return 8
+    case "uint16": return 16
+    case "uint32": return 32
+    case "uint64": return 64
+    default: return 0
+    }
+}
+
+// typeBits returns the number of bits in a builtin integer type, and if it is
+// signed, or zero if the type is not a builtin integer type.
+//
+// The size of int and uint is assumed to be 64 bits. On other platforms, the
+// generated compile-time checks still apply.
+func typeBits(Type string) (bits int, signed bool) {
+    switch Type {
+    case "int8":           return 8, true
+    case "int16":          return 16, true
+    case "int32", "rune":  return 32, true
+    case "int64", "int":   return 64, true
+    case "uint8", "byte":  return 8, false
+    case "uint16":         return 16, false
+    case "uint32":         return 32, false
+    case "uint64", "uint", "uintptr": return 64, false
+    default: return 0, false
+    }
+}
+
+// layout describes where a field is packed.
+type layout struct {
+    field  morph.Field
+    offset int
+    width  int
+    signed bool
+}
+
+func (l layout) mask() string {
+    return fmt.Sprintf("0x%x", (uint64(1) << l.width) - 1)
+}
+
+func (l layout) String() string {
+    if l.width == 1 {
+        return fmt.Sprintf("bit %d", l.offset)
+    }
+    return fmt.Sprintf("bits %d to %d", l.offset, l.offset + l.width - 1)
+}
+
+// Functions generates the functions and methods, described in the package
+// documentation, that operate on the packed struct.
+//
+// The packed struct must have a single field, Bits, with the storage type
+// uint8, uint16, uint32, or uint64.
+//
+// The widths argument gives the number of bits used to store each field on
+// the input struct. A bool field defaults to a width of one bit, and must
+// not have any other width. Every other field must be given a width.
+//
+// A field with a signed builtin integer type is stored in two's complement
+// form, and sign-extended when unpacked. A field with any other type, such as
+// a named type, must have an unsigned integer underlying type. Pack includes
+// constant conversions that fail to compile if a field's type cannot
+// represent every value of its width.
+//
+// It is an error if the total width exceeds the storage type, or if a field
+// has the name of a generated method, or of the packed struct's Bits field.
+func Functions(s morph.Struct, packed morph.Struct, widths map[string]int) ([]morph.Function, error) {
+    esc := func(err error) ([]morph.Function, error) {
+        return nil, fmt.Errorf(
+            "error generating bitpack functions for structs %q and %q: %w",
+            s.Name, packed.Name, err,
+        )
+    }
+
+    if len(s.TypeParams) > 0 {
+        return esc(fmt.Errorf("generic structs are not supported"))
+    }
+    if (len(packed.Fields) != 1) || (packed.Fields[0].Name != "Bits") {
+        return esc(fmt.Errorf("packed struct must have a single field, Bits"))
+    }
+    storage := packed.Fields[0].Type
+    size := storageBits(storage)
+    if size == 0 {
+        return esc(fmt.Errorf("unsupported storage type %q", storage))
+    }
+
+    for name := range widths {
+        if !hasField(s, name) {
+            return esc(fmt.Errorf("width given for missing field %q", name))
+        }
+    }
+
+    // names of the packed struct's field, and of generated methods, other
+    // than each field's getter
+    generated := map[string]bool{"Bits": true, "Pack": true, "Unpack": true}
+    for _, f := range s.Fields {
+        generated["Set" + f.Name] = true
+    }
+
+    var layouts []layout
+    offset := 0
+    for _, f := range s.Fields {
+        if generated[f.Name] {
+            return esc(fmt.Errorf("field %q conflicts with a generated name", f.Name))
+        }
+
+        width, ok := widths[f.Name]
+        bits, signed := typeBits(f.Type)
+        if f.Type == "bool" {
+            if !ok { width = 1 }
+            if width != 1 {
+                return esc(fmt.Errorf("bool field %q must have a width of 1", f.Name))
+            }
+        } else if !ok {
+            return esc(fmt.Errorf("missing width for field %q", f.Name))
+        }
+        if width < 1 {
+            return esc(fmt.Errorf("field %q has invalid width %d", f.Name, width))
+        }
+        if (bits > 0) && (width > bits) {
+            return esc(fmt.Errorf("field %q of type %s cannot hold %d bits", f.Name, f.Type, width))
+        }
+
+        layouts = append(layouts, layout{
+            field:  f,
+            offset: offset,
+            width:  width,
+            signed: signed,
+        })
+        offset += width
+    }
+    if offset > size {
+        return esc(fmt.Errorf("total width of %d bits overflows storage type %s", offset, storage))
+    }
+
+    g := generator{
+        s:        s,
+        packed:   packed,
+        layouts:  layouts,
+        storage:  storage,
+        size:     size,
+        receiver: internal.Untitle(packed.Name),
+        arg:      internal.Untitle(s.Name),
+    }
+    if g.arg == g.receiver { g.arg = "_" + g.arg }
+
+    var functions []morph.Function
+    for _, l := range layouts {
+        functions = append(functions, g.get(l), g.set(l))
+    }
+    functions = append(functions, g.pack(), g.unpack())
+    for i := range functions {
+        functions[i].Body = strings.TrimSpace(functions[i].Body)
+    }
+    return functions, nil
+}
+
+func hasField(s morph.Struct, name string) bool {
+    for _, f := range s.Fields {
+        if f.Name == name { return true }
+    }
+    return false
+}
+
+type generator struct {
+    s        morph.Struct
+    packed   morph.Struct
+    layouts  []layout
+    storage  string
+    size     int    // bits in storage type
+    receiver string // receiver name for methods on the packed struct
+    arg      string // argument name for the input struct
+}
+
+func (g generator) get(l layout) morph.Function {
+    var sb strings.Builder
+    f := l.field
+    bits := g.receiver + ".Bits"
+    if f.Type == "bool" {
+        fmt.Fprintf(&sb, "return (%s & (1 << %d)) != 0", bits, l.offset)
+    } else if l.signed {
+        // shift the field to the top, then arithmetic shift right to
+        // sign-extend
+        fmt.Fprintf(&sb, "return %s(int%d(%s << %d) >> %d)",
+            f.Type, g.size, bits, g.size - l.offset - l.width, g.size - l.width)
+    } else {
+        fmt.Fprintf(&sb, "return %s((%s >> %d) & %s)", f.Type, bits, l.offset, l.mask())
+    }
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment:   fmt.Sprintf("%s returns the %s field, packed in %s.", f.Name, f.Name, l),
+            Name:      f.Name,
+            Returns:   []morph.Argument{{Type: f.Type}},
+            Receiver:  morph.Argument{Name: g.receiver, Type: g.packed.Name},
+        },
+        Body: sb.String(),
+    }
+}
+
+func (g generator) set(l layout) morph.Function {
+    var sb strings.Builder
+    f := l.field
+    bits := g.receiver + ".Bits"
+    arg := "_v"
+    if f.Type == "bool" {
+        fmt.Fprintf(&sb, "if %s {\n%s |= (1 << %d)\n} else {\n%s &^= (1 << %d)\n}",
+            arg, bits, l.offset, bits, l.offset)
+    } else {
+        fmt.Fprintf(&sb, "%s = (%s &^ (%s << %d)) | ((%s(%s) & %s) << %d)",
+            bits, bits, l.mask(), l.offset, g.storage, arg, l.mask(), l.offset)
+    }
+
+    comment := fmt.Sprintf("Set%s sets the %s field, packed in %s.", f.Name, f.Name, l)
+    if f.Type != "bool" {
+        comment += " Bits of the value that do not fit are discarded."
+    }
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment:   comment,
+            Name:      "Set" + f.Name,
+            Arguments: []morph.Argument{{Name: arg, Type: f.Type}},
+            Receiver:  morph.Argument{Name: g.receiver, Type: "*" + g.packed.Name},
+        },
+        Body: sb.String(),
+    }
+}
+
+func (g generator) pack() morph.Function {
+    var sb strings.Builder
+    sb.WriteString("// compile-time checks that each field type can represent its packed width\n")
+    for _, l := range g.layouts {
+        f := l.field
+        if f.Type == "bool" { continue }
+        if l.signed {
+            fmt.Fprintf(&sb, "const _, _ = %s(-1 << %d), %s(1 << %d - 1)\n",
+                f.Type, l.width - 1, f.Type, l.width - 1)
+        } else {
+            fmt.Fprintf(&sb, "const _ = %s(%s)\n", f.Type, l.mask())
+        }
+    }
+    sb.WriteString("\n")
+
+    fmt.Fprintf(&sb, "var _out %s\n", g.packed.Name)
+    for _, l := range g.layouts {
+        fmt.Fprintf(&sb, "_out.Set%s(%s.%s)\n", l.field.Name, g.arg, l.field.Name)
+    }
+    sb.WriteString("return _out")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment:   fmt.Sprintf("Pack returns the [%s] form of a [%s] value.", g.packed.Name, g.s.Name),
+            Name:      "Pack",
+            Returns:   []morph.Argument{{Type: g.packed.Name}},
+            Receiver:  morph.Argument{Name: g.arg, Type: g.s.Name},
+        },
+        Body: sb.String(),
+    }
+}
+
+func (g generator) unpack() morph.Function {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "return %s{\n", g.s.Name)
+    for _, l := range g.layouts {
+        fmt.Fprintf(&sb, "%s: %s.%s(),\n", l.field.Name, g.receiver, l.field.Name)
+    }
+    sb.WriteString("}")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment:   fmt.Sprintf("Unpack returns the [%s] value stored in a [%s].", g.s.Name, g.packed.Name),
+            Name:      "Unpack",
+            Returns:   []morph.Argument{{Type: g.s.Name}},
+            Receiver:  morph.Argument{Name: g.receiver, Type: g.packed.Name},
+        },
+        Body: sb.String(),
+    }
+}
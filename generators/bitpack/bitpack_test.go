@@ -0,0 +1,108 @@
+package bitpack_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/bitpack"
+    "github.com/tawesoft/morph/internal"
+    "github.com/tawesoft/morph/structmappers"
+)
+
+func TestFunctions(t *testing.T) {
+    source := `
+package example
+
+type Mode uint8
+
+type Pixel struct {
+    Visible bool
+    Type    Mode
+    Depth   int8
+    Red     uint8
+    Offset  int
+}
+`
+    pixel := internal.Must(morph.ParseStruct("test.go", source, "Pixel"))
+    packed := pixel.Map(structmappers.Packed("$Packed", "uint32"))
+
+    widths := map[string]int{
+        "Type":   2,
+        "Depth":  4,
+        "Red":    8,
+        "Offset": 17,
+    }
+    functions, err := bitpack.Functions(pixel, packed, widths)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    var sb strings.Builder
+    sb.WriteString("package main\n\nimport \"fmt\"\n\ntype Mode uint8\n\n")
+    sb.WriteString(pixel.String() + "\n\n")
+    sb.WriteString(packed.String() + "\n\n")
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(`
+func main() {
+    for _, p := range []Pixel{
+        {Visible: true, Type: 3, Depth: -8, Red: 255, Offset: -65536},
+        {Visible: false, Type: 1, Depth: 7, Red: 16, Offset: 65535},
+        {},
+    } {
+        packed := p.Pack()
+        fmt.Println(packed.Unpack() == p, packed.Depth(), packed.Offset())
+    }
+
+    var p PixelPacked
+    p.SetType(Mode(0xFE)) // excess bits discarded
+    p.SetRed(188)
+    p.SetVisible(true)
+    p.SetVisible(false)
+    fmt.Printf("%+v 0x%x\n", p.Unpack(), p.Bits)
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        expected := "true -8 -65536\n" +
+            "true 7 65535\n" +
+            "true 0 0\n" +
+            "{Visible:false Type:2 Depth:0 Red:188 Offset:0} 0x5e04\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+
+    // errors
+    for _, tt := range []struct {
+        storage string
+        widths  map[string]int
+    }{
+        {"uint32", map[string]int{"Type": 2, "Depth": 4, "Red": 8, "Offset": 18}}, // overflow
+        {"uint16", widths},                                                       // overflow
+        {"uint",   widths},                                                       // storage type
+        {"uint32", map[string]int{"Type": 2, "Depth": 4, "Red": 8}},              // missing
+        {"uint32", map[string]int{"Type": 2, "Depth": 4, "Red": 9, "Offset": 1}}, // too wide
+        {"uint32", map[string]int{"Visible": 2, "Type": 2, "Depth": 4, "Red": 8, "Offset": 1}},
+        {"uint32", map[string]int{"Type": 0, "Depth": 4, "Red": 8, "Offset": 1}},
+        {"uint32", map[string]int{"Missing": 1, "Type": 2, "Depth": 4, "Red": 8, "Offset": 1}},
+    } {
+        packed := pixel.Map(structmappers.Packed("$Packed", tt.storage))
+        if _, err := bitpack.Functions(pixel, packed, tt.widths); err == nil {
+            t.Errorf("expected error for storage %s, widths %v", tt.storage, tt.widths)
+        }
+    }
+
+    // field names that conflict with generated names
+    for _, name := range []string{"Bits", "Pack", "Unpack", "SetX"} {
+        s := morph.Struct{Name: "S", Fields: []morph.Field{{Name: "X", Type: "bool"}, {Name: name, Type: "bool"}}}
+        packed := s.Map(structmappers.Packed("$Packed", "uint8"))
+        if _, err := bitpack.Functions(s, packed, nil); err == nil {
+            t.Errorf("expected error for field %s", name)
+        }
+    }
+}
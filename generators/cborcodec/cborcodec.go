@@ -0,0 +1,554 @@
+// Package cborcodec generates methods that encode and decode structs as CBOR
+// (RFC 8949), without using reflection at runtime or any dependencies
+// outside the standard library.
+//
+// For a struct Foo, [Functions] generates:
+//
+//     func (foo Foo) AppendCBOR(b []byte) ([]byte, error)
+//     func (foo Foo) MarshalCBOR() ([]byte, error)
+//     func (foo *Foo) UnmarshalCBOR(data []byte) error
+//
+// The generated methods call unexported helper functions, returned by
+// [Helpers], which must be generated once in the same package.
+//
+// A struct is encoded either as a map, keyed by field name or by an integer
+// key given in a field's tag, or, with [Options].Array, as an array of
+// field values in order.
+package cborcodec
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "unicode"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+    "github.com/tawesoft/morph/tag"
+)
+
+// Options configure the generated methods.
+type Options struct {
+    // Array, if true, encodes a struct as an array of its field values, in
+    // order, instead of as a map.
+    Array bool
+
+    // Underlying maps the name of a named type, such as "Celsius", to its
+    // underlying type, such as "float64", so that fields of that type can be
+    // encoded and decoded without requiring the type to implement
+    // MarshalCBOR and UnmarshalCBOR methods.
+    Underlying map[string]string
+}
+
+// Imports returns the sorted import paths required by the given generated
+// functions, for example from [Functions] and [Helpers].
+func Imports(functions ... morph.Function) []string {
+    return source.Imports(functions...)
+}
+
+// Functions generates the methods, described in the package documentation,
+// for the given struct.
+//
+// Each exported field is encoded, in order, using the key and options in the
+// field's "cbor" tag, if any, in the same form as the "json" tag e.g.
+// `cbor:"name,omitempty"`. Keys are otherwise the field name. The
+// "keyasint" option, e.g. `cbor:"1,keyasint"`, uses an integer key instead.
+// A field with the tag `cbor:"-"` is skipped. In a map, a field with the
+// "omitempty" option is left out if it is false, zero, nil, empty, or a zero
+// time.Time. Keys are ignored, and every field is present, in an array.
+//
+// The following field types are supported, and may be nested:
+//
+//   - string, bool, and each builtin integer and floating point type;
+//   - []byte, as a byte string;
+//   - time.Time, as an epoch-based date/time (tag 1) with an integer
+//     number of seconds, or a float if there is a fractional second;
+//   - pointers, slices, and maps with string or integer keys, of a
+//     supported type, where nil is encoded as null;
+//   - any other type that has MarshalCBOR and UnmarshalCBOR methods,
+//     including other structs with generated methods.
+//
+// Embedded fields, arrays, interfaces, channels, and functions are not
+// supported. Map keys are encoded in sorted order.
+//
+// UnmarshalCBOR accepts any integer or float encoding for numbers, and
+// standard date/time strings (tag 0) for time.Time. It skips map keys that
+// do not match any field, and extra array elements, and leaves fields that
+// are missing from the input unchanged. A null value leaves a field
+// unchanged, except that a null pointer, slice, or map field is set to nil.
+// Indefinite-length items are not supported.
+func Functions(s morph.Struct, options Options) ([]morph.Function, error) {
+    esc := func(err error) ([]morph.Function, error) {
+        return nil, fmt.Errorf(
+            "error generating CBOR methods for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    g := &generator{
+        s:        s,
+        options:  options,
+        receiver: source.Receiver(s.Name, "b", "data"),
+    }
+
+    seen := make(map[string]string)
+    for _, f := range s.Fields {
+        m, ok, err := g.member(f)
+        if err != nil { return esc(err) }
+        if !ok { continue }
+        if !options.Array {
+            id := m.key
+            if m.intKey { id = "#" + id }
+            if other, exists := seen[id]; exists {
+                return esc(fmt.Errorf("fields %q and %q have the same key %q", other, f.Name, m.key))
+            }
+            seen[id] = f.Name
+        }
+        g.members = append(g.members, m)
+    }
+
+    appendCBOR, err := g.appendCBOR()
+    if err != nil { return esc(err) }
+    unmarshalCBOR, err := g.unmarshalCBOR()
+    if err != nil { return esc(err) }
+
+    functions := []morph.Function{appendCBOR, g.marshalCBOR(), unmarshalCBOR}
+    for i := range functions {
+        functions[i].Body = strings.TrimSpace(functions[i].Body)
+    }
+    return functions, nil
+}
+
+// member is a field encoded as a CBOR map entry or array element.
+type member struct {
+    field     morph.Field
+    key       string
+    intKey    bool
+    omitEmpty bool
+}
+
+type generator struct {
+    s        morph.Struct
+    options  Options
+    receiver string
+    members  []member
+    n        int // counter for unique local variable names
+}
+
+// next returns a unique suffix for local variable names.
+func (g *generator) next() int {
+    g.n++
+    return g.n
+}
+
+// member returns the member for a field, or false if the field is skipped.
+func (g *generator) member(f morph.Field) (member, bool, error) {
+    if (f.Name == "") || (f.Name == f.Type) || strings.Contains(f.Name, ".") {
+        return member{}, false, fmt.Errorf("embedded field %q is not supported", f.Type)
+    }
+    if r := []rune(f.Name)[0]; !unicode.IsUpper(r) { return member{}, false, nil }
+
+    m := member{field: f, key: f.Name}
+    value, ok := tag.Lookup(f.Tag, "cbor")
+    if !ok { return m, true, nil }
+    if value == "-" { return member{}, false, nil }
+
+    name, opts, _ := strings.Cut(value, ",")
+    if name != "" { m.key = name }
+    for opts != "" {
+        var opt string
+        opt, opts, _ = strings.Cut(opts, ",")
+        switch opt {
+        case "omitempty":
+            m.omitEmpty = true
+        case "keyasint":
+            m.intKey = true
+        default:
+            return member{}, false, fmt.Errorf("unsupported option %q on field %q", opt, f.Name)
+        }
+    }
+    if m.intKey {
+        n, err := strconv.ParseInt(m.key, 10, 64)
+        if err != nil {
+            return member{}, false, fmt.Errorf("invalid integer key %q on field %q", m.key, f.Name)
+        }
+        // canonical decimal form, so that e.g. "010" and "10" are the same key
+        m.key = strconv.FormatInt(n, 10)
+    }
+    return m, true, nil
+}
+
+type kind int
+
+const (
+    kindOther kind = iota // has MarshalCBOR and UnmarshalCBOR methods
+    kindString
+    kindBool
+    kindInt
+    kindUint
+    kindFloat
+    kindBytes
+    kindTime
+    kindPointer
+    kindSlice
+    kindMap
+)
+
+// kind returns the kind of a type, and its bit size for numeric types, or
+// its element type for pointers, slices and maps, and its key type for maps.
+func (g *generator) kind(Type string) (k kind, bits int, elem string, key string, err error) {
+    if underlying, ok := g.options.Underlying[Type]; ok { Type = underlying }
+    switch Type {
+    case "string":  return kindString, 0, "", "", nil
+    case "bool":    return kindBool, 0, "", "", nil
+    case "int":     return kindInt, 0, "", "", nil
+    case "int8":    return kindInt, 8, "", "", nil
+    case "int16":   return kindInt, 16, "", "", nil
+    case "int32", "rune": return kindInt, 32, "", "", nil
+    case "int64":   return kindInt, 64, "", "", nil
+    case "uint", "uintptr": return kindUint, 0, "", "", nil
+    case "uint8", "byte": return kindUint, 8, "", "", nil
+    case "uint16":  return kindUint, 16, "", "", nil
+    case "uint32":  return kindUint, 32, "", "", nil
+    case "uint64":  return kindUint, 64, "", "", nil
+    case "float32": return kindFloat, 32, "", "", nil
+    case "float64": return kindFloat, 64, "", "", nil
+    case "[]byte", "[]uint8": return kindBytes, 0, "", "", nil
+    case "time.Time": return kindTime, 0, "", "", nil
+    }
+
+    switch {
+    case strings.HasPrefix(Type, "*"):
+        return kindPointer, 0, Type[1:], "", nil
+    case strings.HasPrefix(Type, "[]"):
+        return kindSlice, 0, Type[2:], "", nil
+    case strings.HasPrefix(Type, "map["):
+        key, elem, ok := strings.Cut(Type[4:], "]")
+        if !ok { break }
+        kk, _, _, _, err := g.kind(key)
+        if err != nil { return 0, 0, "", "", err }
+        if (kk != kindString) && (kk != kindInt) && (kk != kindUint) { break }
+        return kindMap, 0, elem, key, nil
+    case strings.HasPrefix(Type, "["),
+        strings.HasPrefix(Type, "chan "),
+        strings.HasPrefix(Type, "<-chan "),
+        strings.HasPrefix(Type, "func("),
+        strings.HasPrefix(Type, "interface{"),
+        strings.HasPrefix(Type, "struct{"),
+        Type == "any", Type == "error":
+        return 0, 0, "", "", fmt.Errorf("unsupported type %q", Type)
+    default:
+        return kindOther, 0, "", "", nil
+    }
+    return 0, 0, "", "", fmt.Errorf("unsupported type %q", Type)
+}
+
+func (g *generator) appendCBOR() (morph.Function, error) {
+    var sb strings.Builder
+    if g.options.Array {
+        fmt.Fprintf(&sb, "b = cborAppendHead(b, 4, %d)\n", len(g.members))
+    } else {
+        fmt.Fprintf(&sb, "_n := uint64(%d)\n", len(g.members))
+        for i, m := range g.members {
+            if !m.omitEmpty { continue }
+            cond, err := g.nonEmpty(g.receiver + "." + m.field.Name, m.field.Type)
+            if err != nil { return morph.Function{}, fmt.Errorf("field %q: %w", m.field.Name, err) }
+            if cond == "" {
+                g.members[i].omitEmpty = false
+                continue
+            }
+            fmt.Fprintf(&sb, "if !(%s) {\n_n--\n}\n", cond)
+        }
+        sb.WriteString("b = cborAppendHead(b, 5, _n)\n")
+    }
+
+    for _, m := range g.members {
+        x := g.receiver + "." + m.field.Name
+        fmt.Fprintf(&sb, "\n// %s\n", m.field.Name)
+        omit := m.omitEmpty && !g.options.Array
+        if omit {
+            cond, _ := g.nonEmpty(x, m.field.Type)
+            fmt.Fprintf(&sb, "if %s {\n", cond)
+        }
+        if !g.options.Array {
+            if m.intKey {
+                fmt.Fprintf(&sb, "b = cborAppendInt(b, %s)\n", m.key)
+            } else {
+                fmt.Fprintf(&sb, "b = cborAppendString(b, %s)\n", strconv.Quote(m.key))
+            }
+        }
+        if err := g.encode(&sb, x, m.field.Type); err != nil {
+            return morph.Function{}, fmt.Errorf("field %q: %w", m.field.Name, err)
+        }
+        if omit { sb.WriteString("}\n") }
+    }
+    sb.WriteString("\nreturn b, nil\n")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("AppendCBOR appends the CBOR encoding of a [%s] value to b, "+
+                "and returns the extended buffer.", g.s.Name),
+            Name:      "AppendCBOR",
+            Arguments: []morph.Argument{{Name: "b", Type: "[]byte"}},
+            Returns:   []morph.Argument{{Type: "[]byte"}, {Type: "error"}},
+            Receiver:  morph.Argument{Name: g.receiver, Type: g.s.Type()},
+        },
+        Body: sb.String(),
+    }, nil
+}
+
+func (g *generator) marshalCBOR() morph.Function {
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment:  fmt.Sprintf("MarshalCBOR returns the CBOR encoding of a [%s] value.", g.s.Name),
+            Name:     "MarshalCBOR",
+            Returns:  []morph.Argument{{Type: "[]byte"}, {Type: "error"}},
+            Receiver: morph.Argument{Name: g.receiver, Type: g.s.Type()},
+        },
+        Body: fmt.Sprintf("return %s.AppendCBOR(nil)", g.receiver),
+    }
+}
+
+// nonEmpty returns a condition that is true if x is not empty, as described
+// for the "omitempty" option, or the empty string if x is never empty.
+func (g *generator) nonEmpty(x string, Type string) (string, error) {
+    k, _, _, _, err := g.kind(Type)
+    if err != nil { return "", err }
+    switch k {
+    case kindString:
+        return x + ` != ""`, nil
+    case kindBool:
+        return "bool(" + x + ")", nil
+    case kindInt, kindUint, kindFloat:
+        return x + " != 0", nil
+    case kindBytes, kindSlice, kindMap:
+        return "len(" + x + ") != 0", nil
+    case kindTime:
+        return "!" + x + ".IsZero()", nil
+    case kindPointer:
+        return x + " != nil", nil
+    default:
+        return "", nil
+    }
+}
+
+// encode writes code that appends the CBOR encoding of x, of the given type,
+// to b.
+func (g *generator) encode(sb *strings.Builder, x string, Type string) error {
+    k, bits, elem, key, err := g.kind(Type)
+    if err != nil { return err }
+
+    switch k {
+    case kindString:
+        fmt.Fprintf(sb, "b = cborAppendString(b, string(%s))\n", x)
+    case kindBool:
+        fmt.Fprintf(sb, "if %s {\nb = append(b, 0xf5)\n} else {\nb = append(b, 0xf4)\n}\n", x)
+    case kindInt:
+        fmt.Fprintf(sb, "b = cborAppendInt(b, int64(%s))\n", x)
+    case kindUint:
+        fmt.Fprintf(sb, "b = cborAppendHead(b, 0, uint64(%s))\n", x)
+    case kindFloat:
+        fmt.Fprintf(sb, "b = cborAppendFloat(b, float64(%s), %d)\n", x, bits)
+    case kindTime:
+        fmt.Fprintf(sb, "b = cborAppendTime(b, %s)\n", x)
+    case kindBytes:
+        fmt.Fprintf(sb, "if %s == nil {\nb = append(b, 0xf6)\n} else {\n", x)
+        fmt.Fprintf(sb, "b = cborAppendHead(b, 2, uint64(len(%s)))\n", x)
+        fmt.Fprintf(sb, "b = append(b, %s...)\n}\n", x)
+    case kindPointer:
+        fmt.Fprintf(sb, "if %s == nil {\nb = append(b, 0xf6)\n} else {\n", x)
+        if err := g.encode(sb, "(*" + x + ")", elem); err != nil { return err }
+        sb.WriteString("}\n")
+    case kindSlice:
+        n := g.next()
+        fmt.Fprintf(sb, "if %s == nil {\nb = append(b, 0xf6)\n} else {\n", x)
+        fmt.Fprintf(sb, "b = cborAppendHead(b, 4, uint64(len(%s)))\n", x)
+        fmt.Fprintf(sb, "for _, _x%d := range %s {\n", n, x)
+        if err := g.encode(sb, fmt.Sprintf("_x%d", n), elem); err != nil { return err }
+        sb.WriteString("}\n")
+        sb.WriteString("}\n")
+    case kindMap:
+        n := g.next()
+        fmt.Fprintf(sb, "if %s == nil {\nb = append(b, 0xf6)\n} else {\n", x)
+        fmt.Fprintf(sb, "_keys%d := make([]%s, 0, len(%s))\n", n, key, x)
+        fmt.Fprintf(sb, "for _k%d := range %s {\n_keys%d = append(_keys%d, _k%d)\n}\n", n, x, n, n, n)
+        fmt.Fprintf(sb, "sort.Slice(_keys%d, func(_a, _b int) bool {\nreturn _keys%d[_a] < _keys%d[_b]\n})\n", n, n, n)
+        fmt.Fprintf(sb, "b = cborAppendHead(b, 5, uint64(len(%s)))\n", x)
+        fmt.Fprintf(sb, "for _, _k%d := range _keys%d {\n", n, n)
+        if err := g.encode(sb, fmt.Sprintf("_k%d", n), key); err != nil { return err }
+        if err := g.encode(sb, fmt.Sprintf("%s[_k%d]", x, n), elem); err != nil { return err }
+        sb.WriteString("}\n")
+        sb.WriteString("}\n")
+    default:
+        n := g.next()
+        fmt.Fprintf(sb, "if _v%d, _err := %s.MarshalCBOR(); _err != nil {\n", n, x)
+        sb.WriteString("return nil, _err\n")
+        fmt.Fprintf(sb, "} else {\nb = append(b, _v%d...)\n}\n", n)
+    }
+    return nil
+}
+
+func (g *generator) unmarshalCBOR() (morph.Function, error) {
+    var sb strings.Builder
+    name := strconv.Quote(g.s.Name)
+    sb.WriteString("if (len(data) == 1) && cborNull(data, 0) {\nreturn nil\n}\n\n")
+
+    major := 5
+    if g.options.Array { major = 4 }
+    fmt.Fprintf(&sb, "_n, _i, _err := cborLength(data, 0, %s, %d)\n", name, major)
+    sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+    sb.WriteString("for _k := 0; _k < _n; _k++ {\n")
+
+    // cases writes a switch case for each member for which id returns a
+    // non-empty case expression.
+    cases := func(value string, id func(m member) string) error {
+        fmt.Fprintf(&sb, "switch %s {\n", value)
+        for _, m := range g.members {
+            c := id(m)
+            if c == "" { continue }
+            fmt.Fprintf(&sb, "case %s: // %s\n", c, m.field.Name)
+            what := g.s.Name + "." + m.field.Name
+            if err := g.decode(&sb, g.receiver + "." + m.field.Name, m.field.Type, what); err != nil {
+                return fmt.Errorf("field %q: %w", m.field.Name, err)
+            }
+        }
+        sb.WriteString("default:\n")
+        fmt.Fprintf(&sb, "if _i, _err = cborSkip(data, _i, %s); _err != nil {\nreturn _err\n}\n", name)
+        sb.WriteString("}\n")
+        return nil
+    }
+
+    if g.options.Array {
+        i := -1
+        err := cases("_k", func(m member) string {
+            i++
+            return strconv.Itoa(i)
+        })
+        if err != nil { return morph.Function{}, err }
+    } else {
+        fmt.Fprintf(&sb, "_key, _ikey, _isint, _next, _err := cborKey(data, _i, %s)\n", name)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+        sb.WriteString("_i = _next\n")
+        sb.WriteString("if _isint {\n")
+        err := cases("_ikey", func(m member) string {
+            if !m.intKey { return "" }
+            return m.key
+        })
+        if err != nil { return morph.Function{}, err }
+        sb.WriteString("} else {\n")
+        err = cases("_key", func(m member) string {
+            if m.intKey { return "" }
+            return strconv.Quote(m.key)
+        })
+        if err != nil { return morph.Function{}, err }
+        sb.WriteString("}\n")
+    }
+    sb.WriteString("}\n\n")
+
+    sb.WriteString("if _i != len(data) {\n")
+    fmt.Fprintf(&sb, "return fmt.Errorf(\"cbor: %%d unexpected bytes after decoding %s at offset %%d\", len(data) - _i, _i)\n", g.s.Name)
+    sb.WriteString("}\n")
+    sb.WriteString("return nil\n")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment:   fmt.Sprintf("UnmarshalCBOR decodes a [%s] value from its CBOR encoding in data.", g.s.Name),
+            Name:      "UnmarshalCBOR",
+            Arguments: []morph.Argument{{Name: "data", Type: "[]byte"}},
+            Returns:   []morph.Argument{{Type: "error"}},
+            Receiver:  morph.Argument{Name: g.receiver, Type: "*" + g.s.Type()},
+        },
+        Body: sb.String(),
+    }, nil
+}
+
+// decode writes code that decodes the CBOR data item at index _i of data
+// into the addressable target of the given type, advancing _i, and returning
+// any error. The what argument describes the target in error messages.
+func (g *generator) decode(sb *strings.Builder, target string, Type string, what string) error {
+    k, bits, elem, key, err := g.kind(Type)
+    if err != nil { return err }
+    n := g.next()
+    q := strconv.Quote(what)
+
+    // call writes code that calls a helper, returning a value into _vN.
+    call := func(helper string) {
+        fmt.Fprintf(sb, "_v%d, _next%d, _err := %s(data, _i, %s)\n", n, n, helper, q)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+    }
+
+    sb.WriteString("if cborNull(data, _i) {\n")
+    switch k {
+    case kindBytes, kindPointer, kindSlice, kindMap:
+        fmt.Fprintf(sb, "%s = nil\n", target)
+    }
+    sb.WriteString("_i++\n} else {\n")
+
+    switch k {
+    case kindString:
+        call("cborString")
+        fmt.Fprintf(sb, "%s = %s(_v%d)\n", target, Type, n)
+    case kindBool:
+        call("cborBool")
+        fmt.Fprintf(sb, "%s = %s(_v%d)\n", target, Type, n)
+    case kindInt:
+        call("cborInt")
+        if bits < 64 {
+            fmt.Fprintf(sb, "if int64(%s(_v%d)) != _v%d {\nreturn cborRangeError(%s, %q, _i)\n}\n", Type, n, n, q, Type)
+        }
+        fmt.Fprintf(sb, "%s = %s(_v%d)\n", target, Type, n)
+    case kindUint:
+        call("cborUint")
+        if bits < 64 {
+            fmt.Fprintf(sb, "if uint64(%s(_v%d)) != _v%d {\nreturn cborRangeError(%s, %q, _i)\n}\n", Type, n, n, q, Type)
+        }
+        fmt.Fprintf(sb, "%s = %s(_v%d)\n", target, Type, n)
+    case kindFloat:
+        call("cborFloat")
+        fmt.Fprintf(sb, "%s = %s(_v%d)\n", target, Type, n)
+    case kindBytes:
+        call("cborBytes")
+        fmt.Fprintf(sb, "%s = %s(_v%d)\n", target, Type, n)
+    case kindTime:
+        call("cborTime")
+        fmt.Fprintf(sb, "%s = _v%d\n", target, n)
+    case kindPointer:
+        fmt.Fprintf(sb, "if %s == nil {\n%s = new(%s)\n}\n", target, target, elem)
+        if err := g.decode(sb, "(*" + target + ")", elem, what); err != nil { return err }
+        sb.WriteString("}\n")
+        return nil
+    case kindSlice:
+        fmt.Fprintf(sb, "_n%d, _next%d, _err := cborLength(data, _i, %s, 4)\n", n, n, q)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+        fmt.Fprintf(sb, "_i = _next%d\n", n)
+        fmt.Fprintf(sb, "_s%d := make(%s, _n%d)\n", n, Type, n)
+        fmt.Fprintf(sb, "for _j%d := range _s%d {\n", n, n)
+        if err := g.decode(sb, fmt.Sprintf("_s%d[_j%d]", n, n), elem, what); err != nil { return err }
+        sb.WriteString("}\n")
+        fmt.Fprintf(sb, "%s = _s%d\n", target, n)
+        sb.WriteString("}\n")
+        return nil
+    case kindMap:
+        fmt.Fprintf(sb, "_n%d, _next%d, _err := cborLength(data, _i, %s, 5)\n", n, n, q)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+        fmt.Fprintf(sb, "_i = _next%d\n", n)
+        fmt.Fprintf(sb, "_m%d := make(%s, _n%d)\n", n, Type, n)
+        fmt.Fprintf(sb, "for _j%d := 0; _j%d < _n%d; _j%d++ {\n", n, n, n, n)
+        fmt.Fprintf(sb, "var _k%d %s\n", n, key)
+        if err := g.decode(sb, fmt.Sprintf("_k%d", n), key, what); err != nil { return err }
+        fmt.Fprintf(sb, "var _x%d %s\n", n, elem)
+        if err := g.decode(sb, fmt.Sprintf("_x%d", n), elem, what); err != nil { return err }
+        fmt.Fprintf(sb, "_m%d[_k%d] = _x%d\n", n, n, n)
+        sb.WriteString("}\n")
+        fmt.Fprintf(sb, "%s = _m%d\n", target, n)
+        sb.WriteString("}\n")
+        return nil
+    default:
+        fmt.Fprintf(sb, "_next%d, _err := cborSkip(data, _i, %s)\n", n, q)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+        fmt.Fprintf(sb, "if _err := %s.UnmarshalCBOR(data[_i:_next%d]); _err != nil {\nreturn _err\n}\n", target, n)
+    }
+    fmt.Fprintf(sb, "_i = _next%d\n", n)
+    sb.WriteString("}\n")
+    return nil
+}
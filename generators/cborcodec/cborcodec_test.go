@@ -0,0 +1,224 @@
+package cborcodec_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/cborcodec"
+    "github.com/tawesoft/morph/internal"
+)
+
+const source = `
+package example
+
+type Celsius float32
+
+type Point struct {
+    X, Y int32
+}
+
+type Reading struct {
+    Id       uint16               ` + "`cbor:\"1,keyasint\"`" + `
+    Sensor   string               ` + "`cbor:\"sensor\"`" + `
+    Temp     Celsius              ` + "`cbor:\"2,keyasint\"`" + `
+    Ok       bool
+    Offset   int64                ` + "`cbor:\",omitempty\"`" + `
+    Ratio    float64
+    Raw      []byte
+    When     time.Time
+    Where    *Point               ` + "`cbor:\"where,omitempty\"`" + `
+    Path     []Point
+    Labels   map[string]int8
+    Counts   map[int]string
+    Note     *string
+    Secret   string               ` + "`cbor:\"-\"`" + `
+    private  int
+}
+`
+
+func program(options cborcodec.Options, main string) string {
+    point := internal.Must(morph.ParseStruct("test.go", source, "Point"))
+    reading := internal.Must(morph.ParseStruct("test.go", source, "Reading"))
+
+    options.Underlying = map[string]string{"Celsius": "float32"}
+    var functions []morph.Function
+    functions = append(functions, internal.Must(cborcodec.Functions(point, options))...)
+    functions = append(functions, internal.Must(cborcodec.Functions(reading, options))...)
+    functions = append(functions, cborcodec.Helpers()...)
+
+    imports := append(cborcodec.Imports(functions...), "fmt", "reflect", "time")
+    main = "type Celsius float32\n\nvar _ = reflect.DeepEqual\n\n" + main
+    return internal.TestProgram(imports, []fmt.Stringer{point, reading}, functions, main)
+}
+
+func TestFunctions(t *testing.T) {
+    main := `
+func main() {
+    data, _ := Point{X: 1, Y: -1}.MarshalCBOR()
+    fmt.Printf("%x\n", data)
+
+    note := "note"
+    r := Reading{
+        Id:     500,
+        Sensor: "t1",
+        Temp:   -40.5,
+        Ok:     true,
+        Ratio:  0.25,
+        Raw:    []byte{1, 2},
+        When:   time.Date(2013, 3, 21, 20, 4, 0, 500000000, time.UTC),
+        Where:  &Point{X: 24, Y: -25},
+        Path:   []Point{{X: 1, Y: 2}},
+        Labels: map[string]int8{"b": -1, "a": 1},
+        Counts: map[int]string{3: "c", -1: "z"},
+        Note:   &note,
+        Secret: "secret",
+    }
+    data, err := r.MarshalCBOR()
+    if err != nil { fmt.Println(err); return }
+
+    var got Reading
+    if err := got.UnmarshalCBOR(data); err != nil { fmt.Println(err); return }
+    r.Secret = ""
+    fmt.Println(reflect.DeepEqual(r, got))
+
+    data, _ = Reading{When: time.Unix(1363896240, 0).UTC()}.MarshalCBOR()
+    fmt.Printf("%x\n", data)
+
+    for _, input := range []string{
+        // integer key, unknown key, half float, time string (tag 0)
+        "a5" + "0105" + "626f6bf5" + "02f9c500" + "645768656e" +
+            "c074323031332d30332d32315432303a30343a30305a" + "624f6bf5",
+        "a1",
+        "a1011a00010000",
+        "a1010500",
+        "a1616b9f00ff",
+        "a16673656e736f72",
+        "a16673656e736f7201",
+        "a1657768657265f6",
+        "f6",
+    } {
+        var data []byte
+        fmt.Sscanf(input, "%x", &data)
+        got := Reading{Sensor: "start", Where: &Point{}}
+        err := got.UnmarshalCBOR(data)
+        fmt.Println(got.Id, got.Sensor, got.Temp, got.Ok, got.When.Format(time.RFC3339), got.Where == nil, err)
+    }
+}
+`
+
+    internal.TestCompileAndRun(t, program(cborcodec.Options{}, main), func(stdout string) error {
+        expected := strings.Join([]string{
+            "a2615801615920",
+            "true",
+            "ab01006673656e736f7260" + "02fa00000000" + "624f6bf4" + "65526174696ffb0000000000000000" +
+                "635261" + "77f6" + "645768656ec11a514b67b0" + "6450617468f6" + "664c6162656c73f6" +
+                "66436f756e7473f6" + "644e6f7465f6",
+            "5 start -5 true 2013-03-21T20:04:00Z false <nil>",
+            "0 start 0 false 0001-01-01T00:00:00Z false cbor: unexpected end of input decoding Reading at offset 0",
+            "0 start 0 false 0001-01-01T00:00:00Z false cbor: value out of range for uint16 decoding Reading.Id at offset 2",
+            "5 start 0 false 0001-01-01T00:00:00Z false cbor: 1 unexpected bytes after decoding Reading at offset 3",
+            "0 start 0 false 0001-01-01T00:00:00Z false cbor: unsupported indefinite length or reserved value decoding Reading at offset 3",
+            "0 start 0 false 0001-01-01T00:00:00Z false cbor: unexpected end of input decoding Reading.Sensor at offset 8",
+            "0 start 0 false 0001-01-01T00:00:00Z false cbor: unexpected unsigned integer decoding Reading.Sensor at offset 8",
+            "0 start 0 false 0001-01-01T00:00:00Z true <nil>",
+            "0 start 0 false 0001-01-01T00:00:00Z false <nil>",
+        }, "\n") + "\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_array(t *testing.T) {
+    main := `
+func main() {
+    data, _ := Point{X: 1, Y: -1}.MarshalCBOR()
+    fmt.Printf("%x\n", data)
+
+    var p Point
+    fmt.Println(p.UnmarshalCBOR([]byte{0x83, 0x02, 0x03, 0x04}), p)
+    fmt.Println(p.UnmarshalCBOR([]byte{0x81, 0x05}), p)
+    fmt.Println(p.UnmarshalCBOR([]byte{0xa0}), p)
+
+    r := Reading{Id: 1, Where: &Point{X: 2}, Counts: map[int]string{}}
+    data, _ = r.MarshalCBOR()
+    var got Reading
+    fmt.Println(got.UnmarshalCBOR(data), reflect.DeepEqual(r, got))
+}
+`
+
+    internal.TestCompileAndRun(t, program(cborcodec.Options{Array: true}, main), func(stdout string) error {
+        expected := strings.Join([]string{
+            "820120",
+            "<nil> {2 3}",
+            "<nil> {5 3}",
+            "cbor: unexpected map decoding Point at offset 0 {5 3}",
+            "<nil> true",
+        }, "\n") + "\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_errors(t *testing.T) {
+    for _, s := range []morph.Struct{
+        {Name: "A", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `cbor:"x,string"`}}},
+        {Name: "B", Fields: []morph.Field{{Name: "X", Type: "[4]int"}}},
+        {Name: "C", Fields: []morph.Field{{Name: "X", Type: "map[float64]string"}}},
+        {Name: "D", Fields: []morph.Field{{Name: "X", Type: "int"}, {Name: "Y", Type: "int", Tag: `cbor:"X"`}}},
+        {Name: "E", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `cbor:"one,keyasint"`}}},
+        {Name: "F", Fields: []morph.Field{
+            {Name: "X", Type: "int", Tag: `cbor:"1,keyasint"`},
+            {Name: "Y", Type: "int", Tag: `cbor:"1,keyasint"`},
+        }},
+        {Name: "G", Fields: []morph.Field{{Name: "Point", Type: "Point"}}},
+        {Name: "H", Fields: []morph.Field{
+            {Name: "X", Type: "int", Tag: `cbor:"1,keyasint"`},
+            {Name: "Y", Type: "int", Tag: `cbor:"01,keyasint"`},
+        }},
+    } {
+        if _, err := cborcodec.Functions(s, cborcodec.Options{}); err == nil {
+            t.Errorf("expected error for struct %s", s.Name)
+        }
+    }
+}
+
+func TestFunctions_intKey(t *testing.T) {
+    s := morph.Struct{Name: "Key", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `cbor:"010,keyasint"`}}}
+    functions := internal.Must(cborcodec.Functions(s, cborcodec.Options{}))
+    functions = append(functions, cborcodec.Helpers()...)
+    imports := append(cborcodec.Imports(functions...), "fmt", "strings")
+
+    main := `
+func main() {
+    data, _ := Key{X: 1}.MarshalCBOR()
+    fmt.Printf("%x\n", data)
+
+    for _, depth := range []int{3, 600} {
+        var data []byte
+        // an unknown key whose value is nested arrays
+        fmt.Sscanf("a2" + "0a02" + "0b" + strings.Repeat("81", depth) + "00", "%x", &data)
+        var got Key
+        err := got.UnmarshalCBOR(data)
+        fmt.Println(got.X, err)
+    }
+}
+`
+
+    internal.TestCompileAndRun(t, internal.TestProgram(imports, []fmt.Stringer{s}, functions, main), func(stdout string) error {
+        expected := strings.Join([]string{
+            "a10a01",
+            "2 <nil>",
+            "2 cbor: data items nested too deeply decoding Key at offset 516",
+        }, "\n") + "\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
@@ -0,0 +1,281 @@
+package cborcodec
+
+import (
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+)
+
+// Helpers returns unexported helper functions, each with a name starting
+// with "cbor", that are called by the generated methods. They must be
+// generated exactly once in each package that uses the generated methods.
+//
+// Use [Imports] to find the import paths they require.
+func Helpers() []morph.Function {
+    return source.Functions(helpers)
+}
+
+const helpers = `package helpers
+
+// cborAppendHead appends the head of a CBOR data item with the given major
+// type and argument, in its shortest form.
+func cborAppendHead(b []byte, major byte, n uint64) []byte {
+    major <<= 5
+    switch {
+    case n < 24:              return append(b, major | byte(n))
+    case n <= math.MaxUint8:  return append(b, major | 24, byte(n))
+    case n <= math.MaxUint16: return binary.BigEndian.AppendUint16(append(b, major | 25), uint16(n))
+    case n <= math.MaxUint32: return binary.BigEndian.AppendUint32(append(b, major | 26), uint32(n))
+    default:                  return binary.BigEndian.AppendUint64(append(b, major | 27), n)
+    }
+}
+
+// cborAppendInt appends n as a CBOR unsigned or negative integer.
+func cborAppendInt(b []byte, n int64) []byte {
+    if n < 0 { return cborAppendHead(b, 1, uint64(-(n + 1))) }
+    return cborAppendHead(b, 0, uint64(n))
+}
+
+// cborAppendFloat appends f as a CBOR floating point number with the given
+// bit size (32 or 64).
+func cborAppendFloat(b []byte, f float64, bits int) []byte {
+    if bits == 32 {
+        return binary.BigEndian.AppendUint32(append(b, 0xfa), math.Float32bits(float32(f)))
+    }
+    return binary.BigEndian.AppendUint64(append(b, 0xfb), math.Float64bits(f))
+}
+
+// cborAppendString appends s as a CBOR text string.
+func cborAppendString(b []byte, s string) []byte {
+    b = cborAppendHead(b, 3, uint64(len(s)))
+    return append(b, s...)
+}
+
+// cborAppendTime appends t as a CBOR epoch-based date/time (tag 1): an
+// integer number of seconds or, if t has a fractional second, a float.
+func cborAppendTime(b []byte, t time.Time) []byte {
+    b = append(b, 0xc1)
+    if t.Nanosecond() == 0 { return cborAppendInt(b, t.Unix()) }
+    return cborAppendFloat(b, float64(t.Unix()) + (float64(t.Nanosecond()) / 1e9), 64)
+}
+
+// cborEOF returns an error for input that ends at index i in the middle of
+// a data item.
+func cborEOF(what string, i int) error {
+    return fmt.Errorf("cbor: unexpected end of input decoding %s at offset %d", what, i)
+}
+
+// cborTypeError returns an error for a data item, at index i of data, of a
+// type that cannot be decoded into the target.
+func cborTypeError(data []byte, i int, what string) error {
+    kinds := []string{
+        "unsigned integer", "negative integer", "byte string", "text string",
+        "array", "map", "tag", "simple value",
+    }
+    return fmt.Errorf("cbor: unexpected %s decoding %s at offset %d", kinds[data[i] >> 5], what, i)
+}
+
+// cborRangeError returns an error for a decoded value that overflows its
+// type.
+func cborRangeError(what string, Type string, i int) error {
+    return fmt.Errorf("cbor: value out of range for %s decoding %s at offset %d", Type, what, i)
+}
+
+// cborHead decodes the head of the CBOR data item at index i of data, and
+// returns its major type, its argument, and the index of the following byte.
+// Indefinite lengths are not supported.
+func cborHead(data []byte, i int, what string) (byte, uint64, int, error) {
+    if i >= len(data) { return 0, 0, i, cborEOF(what, i) }
+    major, info := data[i] >> 5, data[i] & 0x1f
+    switch {
+    case info < 24:
+        return major, uint64(info), i + 1, nil
+    case info <= 27:
+        n := 1 << (info - 24)
+        if len(data) - i - 1 < n { return 0, 0, i, cborEOF(what, i) }
+        var arg uint64
+        for _, c := range data[i+1:i+1+n] { arg = (arg << 8) | uint64(c) }
+        return major, arg, i + 1 + n, nil
+    default:
+        return 0, 0, i, fmt.Errorf("cbor: unsupported indefinite length or reserved value decoding %s at offset %d", what, i)
+    }
+}
+
+// cborNull returns true if the data item at index i of data is null or
+// undefined.
+func cborNull(data []byte, i int) bool {
+    return (i < len(data)) && ((data[i] == 0xf6) || (data[i] == 0xf7))
+}
+
+// cborSkip returns the index of the byte following the data item, and any
+// nested data items, at index i of data. It returns an error for data items
+// nested more than 512 levels deep.
+func cborSkip(data []byte, i int, what string) (int, error) {
+    return cborSkipDepth(data, i, what, 0)
+}
+
+// cborSkipDepth implements cborSkip for a data item nested depth levels
+// deep.
+func cborSkipDepth(data []byte, i int, what string, depth int) (int, error) {
+    if depth >= 512 {
+        return i, fmt.Errorf("cbor: data items nested too deeply decoding %s at offset %d", what, i)
+    }
+    major, arg, next, err := cborHead(data, i, what)
+    if err != nil { return i, err }
+    switch major {
+    case 2, 3:
+        if arg > uint64(len(data) - next) { return i, cborEOF(what, i) }
+        return next + int(arg), nil
+    case 4, 5:
+        if arg > uint64(len(data) - next) { return i, cborEOF(what, i) }
+        if major == 5 { arg *= 2 }
+        for ; arg > 0; arg-- {
+            next, err = cborSkipDepth(data, next, what, depth + 1)
+            if err != nil { return i, err }
+        }
+        return next, nil
+    case 6:
+        return cborSkipDepth(data, next, what, depth + 1)
+    default:
+        return next, nil
+    }
+}
+
+// cborLength decodes the head of the array (major type 4) or map (major
+// type 5) at index i of data, and returns its number of elements or pairs
+// and the index of the following byte.
+func cborLength(data []byte, i int, what string, major byte) (int, int, error) {
+    m, n, next, err := cborHead(data, i, what)
+    if err != nil { return 0, i, err }
+    if m != major { return 0, i, cborTypeError(data, i, what) }
+    // every data item is at least one byte
+    if (n > uint64(len(data) - next)) || ((major == 5) && (n * 2 > uint64(len(data) - next))) {
+        return 0, i, cborEOF(what, i)
+    }
+    return int(n), next, nil
+}
+
+// cborUint decodes the unsigned integer at index i of data.
+func cborUint(data []byte, i int, what string) (uint64, int, error) {
+    major, arg, next, err := cborHead(data, i, what)
+    if err != nil { return 0, i, err }
+    if major != 0 { return 0, i, cborTypeError(data, i, what) }
+    return arg, next, nil
+}
+
+// cborInt decodes the unsigned or negative integer at index i of data.
+func cborInt(data []byte, i int, what string) (int64, int, error) {
+    major, arg, next, err := cborHead(data, i, what)
+    if err != nil { return 0, i, err }
+    if (major != 0) && (major != 1) { return 0, i, cborTypeError(data, i, what) }
+    if arg > math.MaxInt64 { return 0, i, cborRangeError(what, "int64", i) }
+    if major == 1 { return -1 - int64(arg), next, nil }
+    return int64(arg), next, nil
+}
+
+// cborHalf converts a IEEE 754 half-precision float to a float64.
+func cborHalf(h uint16) float64 {
+    exp, frac := int(h >> 10) & 0x1f, float64(h & 0x3ff)
+    var f float64
+    switch exp {
+    case 0:  f = math.Ldexp(frac, -24)
+    case 31:
+        f = math.Inf(1)
+        if frac != 0 { f = math.NaN() }
+    default: f = math.Ldexp(frac + 1024, exp - 25)
+    }
+    if h & 0x8000 != 0 { f = -f }
+    return f
+}
+
+// cborFloat decodes the half, single, or double-precision float, or
+// integer, at index i of data.
+func cborFloat(data []byte, i int, what string) (float64, int, error) {
+    major, arg, next, err := cborHead(data, i, what)
+    if err != nil { return 0, i, err }
+    switch {
+    case major == 0:      return float64(arg), next, nil
+    case major == 1:      return -1 - float64(arg), next, nil
+    case data[i] == 0xf9: return cborHalf(uint16(arg)), next, nil
+    case data[i] == 0xfa: return float64(math.Float32frombits(uint32(arg))), next, nil
+    case data[i] == 0xfb: return math.Float64frombits(arg), next, nil
+    }
+    return 0, i, cborTypeError(data, i, what)
+}
+
+// cborBool decodes the boolean at index i of data.
+func cborBool(data []byte, i int, what string) (bool, int, error) {
+    if i >= len(data) { return false, i, cborEOF(what, i) }
+    switch data[i] {
+    case 0xf4: return false, i + 1, nil
+    case 0xf5: return true, i + 1, nil
+    }
+    return false, i, cborTypeError(data, i, what)
+}
+
+// cborRaw decodes the byte string (major type 2) or text string (major type
+// 3) at index i of data, and returns its contents without copying.
+func cborRaw(data []byte, i int, what string, major byte) ([]byte, int, error) {
+    m, n, next, err := cborHead(data, i, what)
+    if err != nil { return nil, i, err }
+    if m != major { return nil, i, cborTypeError(data, i, what) }
+    if n > uint64(len(data) - next) { return nil, i, cborEOF(what, i) }
+    return data[next:next+int(n)], next + int(n), nil
+}
+
+// cborString decodes the text string at index i of data.
+func cborString(data []byte, i int, what string) (string, int, error) {
+    s, next, err := cborRaw(data, i, what, 3)
+    if err != nil { return "", i, err }
+    if !utf8.Valid(s) {
+        return "", i, fmt.Errorf("cbor: invalid UTF-8 text string decoding %s at offset %d", what, i)
+    }
+    return string(s), next, nil
+}
+
+// cborBytes decodes a copy of the byte string at index i of data.
+func cborBytes(data []byte, i int, what string) ([]byte, int, error) {
+    s, next, err := cborRaw(data, i, what, 2)
+    if err != nil { return nil, i, err }
+    return append([]byte{}, s...), next, nil
+}
+
+// cborKey decodes the text string or integer map key at index i of data,
+// and returns it as a string or an integer, and true if it is an integer.
+func cborKey(data []byte, i int, what string) (string, int64, bool, int, error) {
+    if (i < len(data)) && (data[i] >> 5 == 3) {
+        s, next, err := cborString(data, i, what)
+        return s, 0, false, next, err
+    }
+    n, next, err := cborInt(data, i, what)
+    return "", n, true, next, err
+}
+
+// cborTime decodes the standard date/time string (tag 0) or epoch-based
+// date/time (tag 1) at index i of data, in UTC.
+func cborTime(data []byte, i int, what string) (time.Time, int, error) {
+    major, tag, next, err := cborHead(data, i, what)
+    if err != nil { return time.Time{}, i, err }
+    switch {
+    case (major == 6) && (tag == 0):
+        s, next, err := cborString(data, next, what)
+        if err != nil { return time.Time{}, i, err }
+        t, err := time.Parse(time.RFC3339Nano, s)
+        if err != nil { return time.Time{}, i, fmt.Errorf("cbor: error decoding %s at offset %d: %w", what, i, err) }
+        return t.UTC(), next, nil
+    case (major == 6) && (tag == 1):
+        if (next < len(data)) && (data[next] >> 5 <= 1) {
+            n, next, err := cborInt(data, next, what)
+            if err != nil { return time.Time{}, i, err }
+            return time.Unix(n, 0).UTC(), next, nil
+        }
+        f, next, err := cborFloat(data, next, what)
+        if err != nil { return time.Time{}, i, err }
+        if math.IsNaN(f) || math.IsInf(f, 0) || (math.Abs(f) > math.MaxInt64) {
+            return time.Time{}, i, cborRangeError(what, "time.Time", i)
+        }
+        sec := math.Floor(f)
+        return time.Unix(int64(sec), int64(math.Round((f - sec) * 1e9))).UTC(), next, nil
+    }
+    return time.Time{}, i, cborTypeError(data, i, what)
+}
+`
@@ -0,0 +1,262 @@
+// Package columns generates functions for column-orientated ("struct of
+// arrays") structs, such as those created by [structmappers.Columns].
+//
+// For a row struct Foo, and a columns struct FooColumns, with a slice field for
+// each field on Foo, [Functions] generates:
+//
+//     func (fooColumns *FooColumns) Append(foo Foo)
+//     func (fooColumns FooColumns) At(i int) Foo
+//     func (fooColumns FooColumns) Set(i int, foo Foo)
+//     func (fooColumns FooColumns) Len() int
+//     func (fooColumns FooColumns) Swap(i int, j int)
+//     func (fooColumns FooColumns) LessBar(i int, j int) bool // for each field Bar
+//     func (fooColumns FooColumns) Slice() []Foo
+//     func NewFooColumns(foos []Foo) FooColumns
+//
+// [structmappers.Columns]: https://pkg.go.dev/github.com/tawesoft/morph/structmappers#Columns
+package columns
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/internal"
+)
+
+// Functions generates the functions and methods, described in the package
+// documentation, that operate on the columns struct.
+//
+// Every field on the row struct must have a matching field, with the same
+// name and a slice type, on the columns struct.
+//
+// A LessBar method is generated for each field, Bar, using the field's
+// Orderer expression (see [morph.Struct.Orderer]), where "$a.$" and "$b.$"
+// are replaced with the values at index i and j, respectively, as described
+// by [morph.FieldExpressionType.FormatField]. No method is generated for a
+// field with the Orderer "skip".
+func Functions(row morph.Struct, columns morph.Struct) ([]morph.Function, error) {
+    esc := func(err error) ([]morph.Function, error) {
+        return nil, fmt.Errorf(
+            "error generating columns functions for structs %q and %q: %w",
+            row.Name, columns.Name, err,
+        )
+    }
+
+    for _, f := range row.Fields {
+        c, ok := columnField(columns, f.Name)
+        if !ok {
+            return esc(fmt.Errorf("missing column for field %q", f.Name))
+        }
+        if c.Type != "[]"+f.Type {
+            return esc(fmt.Errorf("column %q has type %q (expected %q)", f.Name, c.Type, "[]"+f.Type))
+        }
+    }
+
+    g := generator{
+        row:      row,
+        columns:  columns,
+        receiver: internal.Untitle(columns.Name),
+        arg:      internal.Untitle(row.Name),
+    }
+    if g.arg == g.receiver { g.arg = "_" + g.arg }
+
+    functions := []morph.Function{
+        g.append(),
+        g.at(),
+        g.set(),
+        g.len(),
+        g.swap(),
+    }
+    for _, f := range row.Fields {
+        fn, ok, err := g.less(f)
+        if err != nil { return esc(err) }
+        if !ok { continue }
+        functions = append(functions, fn)
+    }
+    functions = append(functions, g.slice(), g.new())
+    for i := range functions {
+        functions[i].Body = strings.TrimSpace(functions[i].Body)
+    }
+    return functions, nil
+}
+
+type generator struct {
+    row      morph.Struct
+    columns  morph.Struct
+    receiver string // receiver name for methods on columns
+    arg      string // argument name for row values
+}
+
+// method returns a method signature on the columns struct.
+func (g generator) method(comment string, name string, pointer bool, args []morph.Argument, returns []morph.Argument) morph.FunctionSignature {
+    Type := g.columns.Type()
+    if pointer { Type = "*" + Type }
+    return morph.FunctionSignature{
+        Comment:   comment,
+        Name:      name,
+        Arguments: args,
+        Returns:   returns,
+        Receiver:  morph.Argument{Name: g.receiver, Type: Type},
+    }
+}
+
+func (g generator) append() morph.Function {
+    var sb strings.Builder
+    for _, f := range g.row.Fields {
+        fmt.Fprintf(&sb, "%s.%s = append(%s.%s, %s.%s)\n",
+            g.receiver, f.Name, g.receiver, f.Name, g.arg, f.Name)
+    }
+    return morph.Function{
+        Signature: g.method(
+            fmt.Sprintf("Append appends a [%s] value to the end of each column.", g.row.Name),
+            "Append", true,
+            []morph.Argument{{Name: g.arg, Type: g.row.Type()}},
+            nil,
+        ),
+        Body: sb.String(),
+    }
+}
+
+func (g generator) at() morph.Function {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "return %s{\n", g.row.Type())
+    for _, f := range g.row.Fields {
+        fmt.Fprintf(&sb, "%s: %s.%s[i],\n", f.Name, g.receiver, f.Name)
+    }
+    sb.WriteString("}")
+    return morph.Function{
+        Signature: g.method(
+            fmt.Sprintf("At returns the [%s] value at index i.", g.row.Name),
+            "At", false,
+            []morph.Argument{{Name: "i", Type: "int"}},
+            []morph.Argument{{Type: g.row.Type()}},
+        ),
+        Body: sb.String(),
+    }
+}
+
+func (g generator) set() morph.Function {
+    var sb strings.Builder
+    for _, f := range g.row.Fields {
+        fmt.Fprintf(&sb, "%s.%s[i] = %s.%s\n", g.receiver, f.Name, g.arg, f.Name)
+    }
+    return morph.Function{
+        Signature: g.method(
+            fmt.Sprintf("Set sets the [%s] value at index i.", g.row.Name),
+            "Set", false,
+            []morph.Argument{{Name: "i", Type: "int"}, {Name: g.arg, Type: g.row.Type()}},
+            nil,
+        ),
+        Body: sb.String(),
+    }
+}
+
+func (g generator) len() morph.Function {
+    body := "return 0"
+    if f, ok := internal.First(g.row.Fields); ok {
+        body = fmt.Sprintf("return len(%s.%s)", g.receiver, f.Name)
+    }
+    return morph.Function{
+        Signature: g.method(
+            "Len returns the number of values in each column.",
+            "Len", false,
+            nil,
+            []morph.Argument{{Type: "int"}},
+        ),
+        Body: body,
+    }
+}
+
+func (g generator) swap() morph.Function {
+    var sb strings.Builder
+    for _, f := range g.row.Fields {
+        c := g.receiver + "." + f.Name
+        fmt.Fprintf(&sb, "%s[i], %s[j] = %s[j], %s[i]\n", c, c, c, c)
+    }
+    return morph.Function{
+        Signature: g.method(
+            "Swap swaps the values at index i and j in each column.",
+            "Swap", false,
+            []morph.Argument{{Name: "i", Type: "int"}, {Name: "j", Type: "int"}},
+            nil,
+        ),
+        Body: sb.String(),
+    }
+}
+
+// less returns a LessBar method for a field Bar, or false if the field's
+// Orderer is "skip".
+func (g generator) less(f morph.Field) (morph.Function, bool, error) {
+    fet := morph.LookupFieldExpressionType("Orderer")
+    if fet == nil {
+        return morph.Function{}, false, fmt.Errorf("no Orderer FieldExpressionType is registered")
+    }
+    pattern := fet.Pattern(f)
+    if pattern == "skip" { return morph.Function{}, false, nil }
+
+    c := g.receiver + "." + f.Name
+    expr, err := fet.FormatField(pattern, f, c+"[i]", c+"[j]")
+    if err != nil { return morph.Function{}, false, err }
+
+    return morph.Function{
+        Signature: g.method(
+            fmt.Sprintf("Less%s returns true if the %s value at index i orders before the value at index j.", f.Name, f.Name),
+            "Less"+f.Name, false,
+            []morph.Argument{{Name: "i", Type: "int"}, {Name: "j", Type: "int"}},
+            []morph.Argument{{Type: "bool"}},
+        ),
+        Body: "return " + expr,
+    }, true, nil
+}
+
+func (g generator) slice() morph.Function {
+    body := fmt.Sprintf(`_n := %s.Len()
+_out := make([]%s, _n)
+for _i := 0; _i < _n; _i++ {
+    _out[_i] = %s.At(_i)
+}
+return _out`, g.receiver, g.row.Type(), g.receiver)
+    return morph.Function{
+        Signature: g.method(
+            fmt.Sprintf("Slice returns the columns as a new slice of [%s] values.", g.row.Name),
+            "Slice", false,
+            nil,
+            []morph.Argument{{Type: "[]" + g.row.Type()}},
+        ),
+        Body: body,
+    }
+}
+
+func (g generator) new() morph.Function {
+    xs := g.arg + "s"
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "_out := %s{\n", g.columns.Type())
+    for _, f := range g.row.Fields {
+        fmt.Fprintf(&sb, "%s: make([]%s, 0, len(%s)),\n", f.Name, f.Type, xs)
+    }
+    fmt.Fprintf(&sb, "}\nfor _, _x := range %s {\n_out.Append(_x)\n}\nreturn _out", xs)
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf(
+                "New%s returns a new [%s] containing each [%s] value in %s.",
+                g.columns.Name, g.columns.Name, g.row.Name, xs,
+            ),
+            Name:      "New" + g.columns.Name,
+            Type:      internal.Map(func(f morph.Field) morph.Argument {
+                return morph.Argument{Name: f.Name, Type: f.Type}
+            }, g.columns.TypeParams),
+            Arguments: []morph.Argument{{Name: xs, Type: "[]" + g.row.Type()}},
+            Returns:   []morph.Argument{{Type: g.columns.Type()}},
+        },
+        Body: sb.String(),
+    }
+}
+
+func columnField(s morph.Struct, name string) (morph.Field, bool) {
+    for _, f := range s.Fields {
+        if f.Name == name { return f, true }
+    }
+    return morph.Field{}, false
+}
@@ -0,0 +1,100 @@
+package columns_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/columns"
+    "github.com/tawesoft/morph/internal"
+    "github.com/tawesoft/morph/structmappers"
+)
+
+func TestFunctions(t *testing.T) {
+    source := `
+package example
+
+type Point[T any] struct {
+    X     int
+    Y     int
+    Label string
+    Extra T
+}
+`
+    point := internal.Must(morph.ParseStruct("test.go", source, "Point"))
+    point.Fields[2].Orderer = "$a.$.$type($a.$) > $b.$"
+    point.Fields[3].Orderer = "skip"
+
+    pointColumns := point.Map(structmappers.Columns("$Columns"))
+    if pointColumns.Name != "PointColumns" {
+        t.Fatalf("unexpected name %q", pointColumns.Name)
+    }
+    if got := pointColumns.Fields[0].Type; got != "[]int" {
+        t.Fatalf("unexpected field type %q", got)
+    }
+
+    reversed := pointColumns.Map(structmappers.Reverse)
+    if reversed.String() != point.String() {
+        t.Errorf("reverse mapping failed: got %s", reversed)
+    }
+
+    functions, err := columns.Functions(point, pointColumns)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    var sb strings.Builder
+    sb.WriteString(`package main
+
+import (
+    "fmt"
+    "sort"
+)
+
+`)
+    sb.WriteString(point.String() + "\n\n")
+    sb.WriteString(pointColumns.String() + "\n\n")
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(`
+type byY struct { PointColumns[bool] }
+func (c byY) Less(i, j int) bool { return c.LessY(i, j) }
+
+func main() {
+    cs := NewPointColumns([]Point[bool]{
+        {X: 1, Y: 3, Label: "a"},
+        {X: 2, Y: 1, Label: "b", Extra: true},
+    })
+    cs.Append(Point[bool]{X: 3, Y: 2, Label: "c"})
+    cs.Set(0, Point[bool]{X: 0, Y: 4, Label: "z"})
+    fmt.Println(cs.Len(), cs.At(0), cs.LessLabel(1, 0))
+
+    sort.Sort(byY{cs})
+    fmt.Println(cs.X, cs.Y, cs.Label, cs.Extra)
+    fmt.Println(cs.Slice())
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        expected := "3 {0 4 z false} false\n" +
+            "[2 3 0] [1 2 4] [b c z] [true false false]\n" +
+            "[{2 1 b true} {3 2 c false} {0 4 z false}]\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+
+    // an Orderer that refers to another field
+    point.Fields[0].Orderer = "$a.Y < $b.Y"
+    if _, err := columns.Functions(point, pointColumns); err == nil {
+        t.Errorf("expected error for an Orderer pattern that refers to another field")
+    }
+
+    // mismatched structs
+    if _, err := columns.Functions(point, point); err == nil {
+        t.Errorf("expected error for a columns struct without slice fields")
+    }
+}
@@ -0,0 +1,411 @@
+// Package csvcodec generates functions that convert structs to and from CSV
+// records, and read and write whole CSV files with a header row, without
+// using reflection at runtime.
+//
+// For a struct Foo, [Functions] generates:
+//
+//     func FooCSVHeader() []string
+//     func (foo Foo) MarshalCSVRecord() []string
+//     func (foo *Foo) UnmarshalCSVRecord(record []string) error
+//     func ReadFooCSV(r io.Reader) ([]Foo, error)
+//     func WriteFooCSV(w io.Writer, xs []Foo) error
+//
+// and an unexported method, unmarshalCSVRecord, used by the others.
+//
+// The generated functions call unexported helper functions, returned by
+// [Helpers], which must be generated once in the same package.
+package csvcodec
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+    "unicode"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+    "github.com/tawesoft/morph/tag"
+)
+
+// Options configure the generated functions.
+type Options struct {
+    // Names, if not nil, maps a field name to a column name for each field
+    // without a column name in its "csv" tag. Otherwise, the column name is
+    // the field name.
+    Names func(field string) string
+
+    // TimeLayout is the layout used to format and parse time.Time fields.
+    // If empty, [time.RFC3339] is used.
+    TimeLayout string
+
+    // Underlying maps the name of a named type, such as "Celsius", to its
+    // underlying type, such as "float64", so that fields of that type can be
+    // converted.
+    Underlying map[string]string
+}
+
+// Imports returns the sorted import paths required by the given generated
+// functions, for example from [Functions] and [Helpers].
+func Imports(functions ... morph.Function) []string {
+    return source.Imports(functions...)
+}
+
+// Functions generates the functions, described in the package
+// documentation, for the given struct.
+//
+// Each exported field is a column, in order, named by the field's "csv"
+// tag e.g. `csv:"name"`, if any, or by [Options].Names. A field with the tag
+// `csv:"-"` is skipped.
+//
+// The following field types are supported:
+//
+//   - string, bool, and each builtin integer and floating point type;
+//   - time.Time, using [Options].TimeLayout;
+//   - pointers to any of these, where nil is an empty cell.
+//
+// An empty cell is parsed as the zero value, or nil for a pointer. Parse
+// errors name the column. Parse errors from ReadFooCSV also name the line,
+// but those from UnmarshalCSVRecord do not, as the line of a single record
+// is not known.
+//
+// The generated ReadFooCSV function maps columns to fields using the
+// header row, so that the columns may be in any order. It ignores columns
+// that do not match a field, and returns an error if a column is missing.
+// UnmarshalCSVRecord expects the columns in the same order as
+// FooCSVHeader.
+func Functions(s morph.Struct, options Options) ([]morph.Function, error) {
+    esc := func(err error) ([]morph.Function, error) {
+        return nil, fmt.Errorf(
+            "error generating CSV functions for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    if len(s.TypeParams) > 0 {
+        return esc(fmt.Errorf("generic structs are not supported"))
+    }
+    if options.TimeLayout == "" { options.TimeLayout = time.RFC3339 }
+
+    g := &generator{
+        s:        s,
+        options:  options,
+        receiver: source.Receiver(s.Name, "record", "columns", "line"),
+    }
+
+    seen := make(map[string]string)
+    for _, f := range s.Fields {
+        c, ok, err := g.column(f)
+        if err != nil { return esc(err) }
+        if !ok { continue }
+        if other, exists := seen[c.name]; exists {
+            return esc(fmt.Errorf("fields %q and %q have the same column name %q", other, f.Name, c.name))
+        }
+        seen[c.name] = f.Name
+        g.columns = append(g.columns, c)
+    }
+
+    marshal, err := g.marshalCSVRecord()
+    if err != nil { return esc(err) }
+    unmarshal, err := g.unmarshalCSVRecordCore()
+    if err != nil { return esc(err) }
+
+    functions := []morph.Function{
+        g.header(),
+        marshal,
+        g.unmarshalCSVRecord(),
+        unmarshal,
+        g.read(),
+        g.write(),
+    }
+    for i := range functions {
+        functions[i].Body = strings.TrimSpace(functions[i].Body)
+    }
+    return functions, nil
+}
+
+// column is a field converted to a CSV column.
+type column struct {
+    field morph.Field
+    name  string
+}
+
+type generator struct {
+    s        morph.Struct
+    options  Options
+    receiver string
+    columns  []column
+}
+
+// column returns the column for a field, or false if the field is skipped.
+func (g *generator) column(f morph.Field) (column, bool, error) {
+    if (f.Name == "") || (f.Name == f.Type) || strings.Contains(f.Name, ".") {
+        return column{}, false, fmt.Errorf("embedded field %q is not supported", f.Type)
+    }
+    if r := []rune(f.Name)[0]; !unicode.IsUpper(r) { return column{}, false, nil }
+
+    c := column{field: f, name: f.Name}
+    if g.options.Names != nil { c.name = g.options.Names(f.Name) }
+    if value, ok := tag.Lookup(f.Tag, "csv"); ok {
+        if value == "-" { return column{}, false, nil }
+        name, _, _ := strings.Cut(value, ",")
+        if name != "" { c.name = name }
+    }
+    return c, true, nil
+}
+
+type kind int
+
+const (
+    kindString kind = iota
+    kindBool
+    kindInt
+    kindUint
+    kindFloat
+    kindTime
+    kindPointer
+)
+
+// kind returns the kind of a type, and its bit size for numeric types, or
+// its element type for pointers.
+func (g *generator) kind(Type string) (k kind, bits int, elem string, err error) {
+    if underlying, ok := g.options.Underlying[Type]; ok { Type = underlying }
+    switch Type {
+    case "string":  return kindString, 0, "", nil
+    case "bool":    return kindBool, 0, "", nil
+    case "int":     return kindInt, 0, "", nil
+    case "int8":    return kindInt, 8, "", nil
+    case "int16":   return kindInt, 16, "", nil
+    case "int32", "rune": return kindInt, 32, "", nil
+    case "int64":   return kindInt, 64, "", nil
+    case "uint", "uintptr": return kindUint, 0, "", nil
+    case "uint8", "byte": return kindUint, 8, "", nil
+    case "uint16":  return kindUint, 16, "", nil
+    case "uint32":  return kindUint, 32, "", nil
+    case "uint64":  return kindUint, 64, "", nil
+    case "float32": return kindFloat, 32, "", nil
+    case "float64": return kindFloat, 64, "", nil
+    case "time.Time": return kindTime, 0, "", nil
+    }
+    if strings.HasPrefix(Type, "*") {
+        if ek, _, _, err := g.kind(Type[1:]); (err == nil) && (ek != kindPointer) {
+            return kindPointer, 0, Type[1:], nil
+        }
+    }
+    return 0, 0, "", fmt.Errorf("unsupported type %q", Type)
+}
+
+func (g *generator) headerName() string {
+    return g.s.Name + "CSVHeader"
+}
+
+func (g *generator) header() morph.Function {
+    names := make([]string, 0, len(g.columns))
+    for _, c := range g.columns {
+        names = append(names, strconv.Quote(c.name))
+    }
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("%s returns the CSV header row for [%s] records.",
+                g.headerName(), g.s.Name),
+            Name:    g.headerName(),
+            Returns: []morph.Argument{{Type: "[]string"}},
+        },
+        Body: fmt.Sprintf("return []string{%s}", strings.Join(names, ", ")),
+    }
+}
+
+func (g *generator) marshalCSVRecord() (morph.Function, error) {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "_out := make([]string, %d)\n", len(g.columns))
+    for i, c := range g.columns {
+        fmt.Fprintf(&sb, "\n// %s\n", c.field.Name)
+        if err := g.format(&sb, fmt.Sprintf("_out[%d]", i), g.receiver + "." + c.field.Name, c.field.Type); err != nil {
+            return morph.Function{}, fmt.Errorf("field %q: %w", c.field.Name, err)
+        }
+    }
+    sb.WriteString("\nreturn _out\n")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("MarshalCSVRecord returns a [%s] value as a CSV record, "+
+                "with columns in the same order as [%s].", g.s.Name, g.headerName()),
+            Name:     "MarshalCSVRecord",
+            Returns:  []morph.Argument{{Type: "[]string"}},
+            Receiver: morph.Argument{Name: g.receiver, Type: g.s.Name},
+        },
+        Body: sb.String(),
+    }, nil
+}
+
+// format writes code that assigns x, of the given type, formatted as a
+// string, to dest.
+func (g *generator) format(sb *strings.Builder, dest string, x string, Type string) error {
+    k, bits, elem, err := g.kind(Type)
+    if err != nil { return err }
+
+    switch k {
+    case kindString:
+        fmt.Fprintf(sb, "%s = string(%s)\n", dest, x)
+    case kindBool:
+        fmt.Fprintf(sb, "%s = strconv.FormatBool(bool(%s))\n", dest, x)
+    case kindInt:
+        fmt.Fprintf(sb, "%s = strconv.FormatInt(int64(%s), 10)\n", dest, x)
+    case kindUint:
+        fmt.Fprintf(sb, "%s = strconv.FormatUint(uint64(%s), 10)\n", dest, x)
+    case kindFloat:
+        fmt.Fprintf(sb, "%s = strconv.FormatFloat(float64(%s), 'g', -1, %d)\n", dest, x, bits)
+    case kindTime:
+        fmt.Fprintf(sb, "%s = %s.Format(%s)\n", dest, x, strconv.Quote(g.options.TimeLayout))
+    case kindPointer:
+        fmt.Fprintf(sb, "if %s != nil {\n", x)
+        if err := g.format(sb, dest, "(*" + x + ")", elem); err != nil { return err }
+        sb.WriteString("}\n")
+    }
+    return nil
+}
+
+func (g *generator) unmarshalCSVRecord() morph.Function {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "if len(record) != %d {\n", len(g.columns))
+    fmt.Fprintf(&sb, "return fmt.Errorf(\"csv: wrong number of fields: expected %d, got %%d\", len(record))\n", len(g.columns))
+    sb.WriteString("}\n")
+    fmt.Fprintf(&sb, "return %s.unmarshalCSVRecord(record, nil, 0)\n", g.receiver)
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("UnmarshalCSVRecord parses a [%s] value from a CSV record, "+
+                "with columns in the same order as [%s].\n\n"+
+                "Parse errors name the column, but not the line, which is not known.", g.s.Name, g.headerName()),
+            Name:      "UnmarshalCSVRecord",
+            Arguments: []morph.Argument{{Name: "record", Type: "[]string"}},
+            Returns:   []morph.Argument{{Type: "error"}},
+            Receiver:  morph.Argument{Name: g.receiver, Type: "*" + g.s.Name},
+        },
+        Body: sb.String(),
+    }
+}
+
+func (g *generator) unmarshalCSVRecordCore() (morph.Function, error) {
+    var sb strings.Builder
+    for i, c := range g.columns {
+        fmt.Fprintf(&sb, "// %s\n{\n", c.field.Name)
+        fmt.Fprintf(&sb, "_column := csvColumn(columns, %d)\n", i)
+        sb.WriteString("_v := record[_column]\n")
+        if err := g.parse(&sb, g.receiver + "." + c.field.Name, c.field.Type, c.name); err != nil {
+            return morph.Function{}, fmt.Errorf("field %q: %w", c.field.Name, err)
+        }
+        sb.WriteString("}\n\n")
+    }
+    sb.WriteString("return nil\n")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("unmarshalCSVRecord parses a [%s] value from a CSV record. "+
+                "The columns argument maps each field index to a column index in the record, "+
+                "or is nil if they are the same. The line number, if not zero, is used in errors.", g.s.Name),
+            Name: "unmarshalCSVRecord",
+            Arguments: []morph.Argument{
+                {Name: "record", Type: "[]string"},
+                {Name: "columns", Type: "[]int"},
+                {Name: "line", Type: "int"},
+            },
+            Returns:  []morph.Argument{{Type: "error"}},
+            Receiver: morph.Argument{Name: g.receiver, Type: "*" + g.s.Name},
+        },
+        Body: sb.String(),
+    }, nil
+}
+
+// parse writes code that parses the string _v into the addressable target,
+// of the given type, returning any error.
+func (g *generator) parse(sb *strings.Builder, target string, Type string, name string) error {
+    k, bits, elem, err := g.kind(Type)
+    if err != nil { return err }
+
+    // call writes code that parses _v with a function returning a value and
+    // an error.
+    call := func(zero string, fn string) {
+        fmt.Fprintf(sb, "if _v == \"\" {\n%s = %s\n} else {\n", target, zero)
+        fmt.Fprintf(sb, "_x, _err := %s\n", fn)
+        fmt.Fprintf(sb, "if _err != nil {\nreturn csvParseError(line, _column, %s, _v, _err)\n}\n", strconv.Quote(name))
+        fmt.Fprintf(sb, "%s = %s(_x)\n", target, Type)
+        sb.WriteString("}\n")
+    }
+
+    switch k {
+    case kindString:
+        fmt.Fprintf(sb, "%s = %s(_v)\n", target, Type)
+    case kindBool:
+        call("false", "strconv.ParseBool(_v)")
+    case kindInt:
+        call("0", fmt.Sprintf("strconv.ParseInt(_v, 10, %d)", bits))
+    case kindUint:
+        call("0", fmt.Sprintf("strconv.ParseUint(_v, 10, %d)", bits))
+    case kindFloat:
+        call("0", fmt.Sprintf("strconv.ParseFloat(_v, %d)", bits))
+    case kindTime:
+        call("time.Time{}", fmt.Sprintf("time.Parse(%s, _v)", strconv.Quote(g.options.TimeLayout)))
+    case kindPointer:
+        fmt.Fprintf(sb, "if _v == \"\" {\n%s = nil\n} else {\n", target)
+        fmt.Fprintf(sb, "if %s == nil {\n%s = new(%s)\n}\n", target, target, elem)
+        if err := g.parse(sb, "(*" + target + ")", elem, name); err != nil { return err }
+        sb.WriteString("}\n")
+    }
+    return nil
+}
+
+func (g *generator) read() morph.Function {
+    var sb strings.Builder
+    sb.WriteString("_r := csv.NewReader(r)\n")
+    sb.WriteString("_header, _err := _r.Read()\n")
+    sb.WriteString("if _err == io.EOF {\nreturn nil, fmt.Errorf(\"csv: missing header\")\n}\n")
+    sb.WriteString("if _err != nil {\nreturn nil, _err\n}\n")
+    fmt.Fprintf(&sb, "_columns, _err := csvColumns(_header, %s())\n", g.headerName())
+    sb.WriteString("if _err != nil {\nreturn nil, _err\n}\n\n")
+    fmt.Fprintf(&sb, "var _xs []%s\n", g.s.Name)
+    sb.WriteString("for {\n")
+    sb.WriteString("_record, _err := _r.Read()\n")
+    sb.WriteString("if _err == io.EOF {\nbreak\n}\n")
+    sb.WriteString("if _err != nil {\nreturn _xs, _err\n}\n")
+    sb.WriteString("_line, _ := _r.FieldPos(0)\n")
+    fmt.Fprintf(&sb, "var _x %s\n", g.s.Name)
+    sb.WriteString("if _err := _x.unmarshalCSVRecord(_record, _columns, _line); _err != nil {\nreturn _xs, _err\n}\n")
+    sb.WriteString("_xs = append(_xs, _x)\n")
+    sb.WriteString("}\n")
+    sb.WriteString("return _xs, nil\n")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("Read%sCSV reads CSV records, after a header row, as [%s] values. "+
+                "The header row maps columns to fields by name, so the columns may be in any order.\n\n"+
+                "On error, it returns the values read so far.", g.s.Name, g.s.Name),
+            Name:      "Read" + g.s.Name + "CSV",
+            Arguments: []morph.Argument{{Name: "r", Type: "io.Reader"}},
+            Returns:   []morph.Argument{{Type: "[]" + g.s.Name}, {Type: "error"}},
+        },
+        Body: sb.String(),
+    }
+}
+
+func (g *generator) write() morph.Function {
+    var sb strings.Builder
+    sb.WriteString("_w := csv.NewWriter(w)\n")
+    fmt.Fprintf(&sb, "if _err := _w.Write(%s()); _err != nil {\nreturn _err\n}\n", g.headerName())
+    sb.WriteString("for _, _x := range xs {\n")
+    sb.WriteString("if _err := _w.Write(_x.MarshalCSVRecord()); _err != nil {\nreturn _err\n}\n")
+    sb.WriteString("}\n")
+    sb.WriteString("_w.Flush()\n")
+    sb.WriteString("return _w.Error()\n")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("Write%sCSV writes a header row, followed by each [%s] value "+
+                "as a CSV record.", g.s.Name, g.s.Name),
+            Name:      "Write" + g.s.Name + "CSV",
+            Arguments: []morph.Argument{{Name: "w", Type: "io.Writer"}, {Name: "xs", Type: "[]" + g.s.Name}},
+            Returns:   []morph.Argument{{Type: "error"}},
+        },
+        Body: sb.String(),
+    }
+}
@@ -0,0 +1,148 @@
+package csvcodec_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/csvcodec"
+    "github.com/tawesoft/morph/internal"
+)
+
+const source = `
+package example
+
+type Person struct {
+    Name    string     ` + "`csv:\"full name\"`" + `
+    Age     int8
+    Height  *float64
+    Joined  time.Time
+    Active  bool
+    Visits  uint
+    Secret  string     ` + "`csv:\"-\"`" + `
+    private int
+}
+`
+
+func TestFunctions(t *testing.T) {
+    person := internal.Must(morph.ParseStruct("test.go", source, "Person"))
+
+    functions := internal.Must(csvcodec.Functions(person, csvcodec.Options{
+        Names:      strings.ToLower,
+        TimeLayout: "2006-01-02",
+    }))
+    functions = append(functions, csvcodec.Helpers()...)
+
+    var sb strings.Builder
+    sb.WriteString("package main\n\nimport (\n")
+    for _, i := range csvcodec.Imports(functions...) {
+        if i == "fmt" || i == "os" || i == "strings" || i == "time" { continue }
+        fmt.Fprintf(&sb, "%q\n", i)
+    }
+    sb.WriteString("\"fmt\"\n\"os\"\n\"strings\"\n\"time\"\n)\n\n")
+    sb.WriteString(person.String() + "\n\n")
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(`
+func main() {
+    height := 1.75
+    people := []Person{
+        {Name: "Alice, \"Al\"", Age: 30, Height: &height, Joined: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Active: true, Visits: 7},
+        {Name: "Bob", Secret: "secret"},
+    }
+
+    fmt.Println(PersonCSVHeader())
+    if err := WritePersonCSV(os.Stdout, people); err != nil { fmt.Println(err) }
+
+    input := "\ufeffvisits,active,extra,joined,height,age,full name\n" +
+        "3,true,x,2021-05-06,1.5,-4,Carol\n" +
+        ",,,,,,\n"
+    got, err := ReadPersonCSV(strings.NewReader(input))
+    fmt.Println(len(got), err)
+    for _, p := range got {
+        fmt.Println(p.MarshalCSVRecord())
+    }
+
+    for _, input := range []string{
+        "",
+        "age,full name\n1,x\n",
+        "age,age\n",
+        "full name,age,height,joined,active,visits\nx,1,,,,\ny,300,,,,\n",
+        "full name,age,height,joined,active,visits\nx,1,tall,,,\n",
+        "full name,age,height,joined,active,visits\nx,1,,yesterday,,\n",
+        "full name,age,height,joined,active,visits\nx,1,,,,-1\n",
+        "full name,age,height,joined,active,visits\nx,1\n",
+    } {
+        got, err := ReadPersonCSV(strings.NewReader(input))
+        fmt.Println(len(got), err)
+    }
+
+    var p Person
+    fmt.Println(p.UnmarshalCSVRecord([]string{"x", "1", "", "", "maybe", ""}))
+    fmt.Println(p.UnmarshalCSVRecord([]string{"x"}))
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        expected := strings.Join([]string{
+            "[full name age height joined active visits]",
+            "full name,age,height,joined,active,visits",
+            `"Alice, ""Al""",30,1.75,2020-01-02,true,7`,
+            "Bob,0,,0001-01-01,false,0",
+            "2 <nil>",
+            "[Carol -4 1.5 2021-05-06 true 3]",
+            "[ 0  0001-01-01 false 0]",
+            "0 csv: missing header",
+            "0 csv: missing column \"height\" in header",
+            "0 csv: duplicate column \"age\" in header",
+            "1 csv: line 3, column 2 (\"age\"): cannot parse \"300\": value out of range",
+            "0 csv: line 2, column 3 (\"height\"): cannot parse \"tall\": invalid syntax",
+            "0 csv: line 2, column 4 (\"joined\"): cannot parse \"yesterday\": parsing time \"yesterday\" as \"2006-01-02\": cannot parse \"yesterday\" as \"2006\"",
+            "0 csv: line 2, column 6 (\"visits\"): cannot parse \"-1\": invalid syntax",
+            "0 record on line 2: wrong number of fields",
+            "csv: column 5 (\"active\"): cannot parse \"maybe\": invalid syntax",
+            "csv: wrong number of fields: expected 6, got 1",
+        }, "\n") + "\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_errors(t *testing.T) {
+    for _, s := range []morph.Struct{
+        {Name: "A", Fields: []morph.Field{{Name: "X", Type: "[]int"}}},
+        {Name: "B", Fields: []morph.Field{{Name: "X", Type: "int"}, {Name: "Y", Type: "int", Tag: `csv:"X"`}}},
+        {Name: "C", Fields: []morph.Field{{Name: "X", Type: "**int"}}},
+        {Name: "D", TypeParams: []morph.Field{{Name: "T", Type: "any"}}, Fields: []morph.Field{{Name: "X", Type: "int"}}},
+    } {
+        if _, err := csvcodec.Functions(s, csvcodec.Options{}); err == nil {
+            t.Errorf("expected error for struct %s", s.Name)
+        }
+    }
+}
+
+func TestFunctions_receiverName(t *testing.T) {
+    record := morph.Struct{Name: "Record", Fields: []morph.Field{{Name: "X", Type: "int"}}}
+    functions := internal.Must(csvcodec.Functions(record, csvcodec.Options{}))
+    functions = append(functions, csvcodec.Helpers()...)
+    imports := append(csvcodec.Imports(functions...), "fmt", "strings")
+
+    main := `
+func main() {
+    got, err := ReadRecordCSV(strings.NewReader("X\n3\n"))
+    fmt.Println(got[0].MarshalCSVRecord(), err)
+}
+`
+
+    internal.TestCompileAndRun(t, internal.TestProgram(imports, []fmt.Stringer{record}, functions, main), func(stdout string) error {
+        expected := "[3] <nil>\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
@@ -0,0 +1,59 @@
+package csvcodec
+
+import (
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+)
+
+// Helpers returns unexported helper functions, each with a name starting
+// with "csv", that are called by the generated functions. They must be
+// generated exactly once in each package that uses the generated functions.
+//
+// Use [Imports] to find the import paths they require.
+func Helpers() []morph.Function {
+    return source.Functions(helpers)
+}
+
+const helpers = `package helpers
+
+// csvColumn returns the index in a record of the column for the field at
+// index i, given a mapping from field index to column index, or nil if they
+// are the same.
+func csvColumn(columns []int, i int) int {
+    if columns == nil { return i }
+    return columns[i]
+}
+
+// csvColumns maps each name in names to the index of the column with that
+// name in a header row, ignoring any leading byte order mark.
+func csvColumns(header []string, names []string) ([]int, error) {
+    index := make(map[string]int, len(header))
+    for i, h := range header {
+        if i == 0 { h = strings.TrimPrefix(h, "\ufeff") }
+        if _, exists := index[h]; exists {
+            return nil, fmt.Errorf("csv: duplicate column %q in header", h)
+        }
+        index[h] = i
+    }
+
+    columns := make([]int, len(names))
+    for i, name := range names {
+        column, ok := index[name]
+        if !ok { return nil, fmt.Errorf("csv: missing column %q in header", name) }
+        columns[i] = column
+    }
+    return columns, nil
+}
+
+// csvParseError returns an error for a value that cannot be parsed, at the
+// given zero-based column index, and one-based line, or zero if the line is
+// not known.
+func csvParseError(line int, column int, name string, value string, err error) error {
+    var numErr *strconv.NumError
+    if errors.As(err, &numErr) { err = numErr.Err }
+    if line > 0 {
+        return fmt.Errorf("csv: line %d, column %d (%q): cannot parse %q: %w", line, column + 1, name, value, err)
+    }
+    return fmt.Errorf("csv: column %d (%q): cannot parse %q: %w", column + 1, name, value, err)
+}
+`
@@ -0,0 +1,270 @@
+// Package envconfig generates functions that load a configuration struct
+// from environment variables, without using reflection at runtime.
+//
+// For a struct Foo, [Functions] generates:
+//
+//     func LoadFooFromEnv(lookup func(string) (string, bool)) (Foo, error)
+//
+// where lookup is a function such as [os.LookupEnv].
+//
+// The generated functions call unexported helper functions, returned by
+// [Helpers], which must be generated once in the same package.
+package envconfig
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "unicode"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+    "github.com/tawesoft/morph/tag"
+)
+
+// Options configure the generated functions.
+type Options struct {
+    // Prefix is prepended to the name of every variable e.g. "APP_".
+    Prefix string
+
+    // Names, if not nil, maps a field name to a variable name, without any
+    // prefix, for each field without a name in its "env" tag. Otherwise, the
+    // field name is converted to upper snake case e.g. "MaxConns" to
+    // "MAX_CONNS".
+    Names func(field string) string
+
+    // Structs are other structs that may be the types of fields, which are
+    // loaded recursively.
+    Structs []morph.Struct
+
+    // Underlying maps the name of a named type, such as "Celsius", to its
+    // underlying type, such as "float64", so that fields of that type can be
+    // converted.
+    Underlying map[string]string
+}
+
+// Imports returns the sorted import paths required by the given generated
+// functions, for example from [Functions] and [Helpers].
+func Imports(functions ... morph.Function) []string {
+    return source.Imports(functions...)
+}
+
+// Functions generates the functions, described in the package
+// documentation, for the given struct.
+//
+// Each exported field is loaded from a variable, named by [Options].Prefix
+// followed by the field's "env" tag e.g. `env:"PORT"`, if any, or by
+// [Options].Names. A field with the tag `env:"-"` is skipped. A variable
+// that is unset, or empty, leaves the field unchanged, unless the field
+// has a default value in an "envDefault" tag e.g. `envDefault:"8080"`, or
+// is required with the "required" option e.g. `env:"PORT,required"` or
+// `env:",required"`.
+//
+// A field with the type of a struct in [Options].Structs is loaded
+// recursively, with the field's variable name, and an underscore, as a
+// further prefix e.g. "APP_DB_" for a field DB.
+//
+// The following field types are supported:
+//
+//   - string, bool, and each builtin integer and floating point type,
+//     parsed with [strconv];
+//   - []byte, set to the bytes of the value, like a string;
+//   - time.Duration, parsed with [time.ParseDuration];
+//   - pointers to any of these, which are only set if the variable is set;
+//   - slices of any of these, parsed from a comma-separated list.
+//
+// The generated function reports every invalid or missing variable, joined
+// into one error.
+func Functions(s morph.Struct, options Options) ([]morph.Function, error) {
+    esc := func(err error) ([]morph.Function, error) {
+        return nil, fmt.Errorf(
+            "error generating environment variable functions for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    if len(s.TypeParams) > 0 {
+        return esc(fmt.Errorf("generic structs are not supported"))
+    }
+
+    g := &generator{
+        s:       s,
+        options: options,
+        structs: make(map[string]morph.Struct),
+        seen:    make(map[string]string),
+    }
+    for _, x := range options.Structs {
+        g.structs[x.Name] = x
+    }
+
+    load, err := g.load()
+    if err != nil { return esc(err) }
+
+    functions := []morph.Function{load}
+    for i := range functions {
+        functions[i].Body = strings.TrimSpace(functions[i].Body)
+    }
+    return functions, nil
+}
+
+type generator struct {
+    s       morph.Struct
+    options Options
+    structs map[string]morph.Struct
+    seen    map[string]string // variable name => field path
+}
+
+func (g *generator) load() (morph.Function, error) {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "var _result %s\n", g.s.Name)
+    sb.WriteString("var _errs []error\n\n")
+    if err := g.fields(&sb, g.s, "_result", g.options.Prefix, nil); err != nil {
+        return morph.Function{}, err
+    }
+    sb.WriteString("return _result, errors.Join(_errs...)\n")
+
+    name := "Load" + g.s.Name + "FromEnv"
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("%s loads a [%s] value from environment variables, "+
+                "using a lookup function such as os.LookupEnv.\n\n"+
+                "It returns an error describing every variable that is invalid, "+
+                "or required but not set.", name, g.s.Name),
+            Name:      name,
+            Arguments: []morph.Argument{{Name: "lookup", Type: "func(string) (string, bool)"}},
+            Returns:   []morph.Argument{{Type: g.s.Name}, {Type: "error"}},
+        },
+        Body: sb.String(),
+    }, nil
+}
+
+// fields writes code that loads each field of a struct into the target,
+// with the given variable name prefix. Parents are the names of the structs
+// already being loaded.
+func (g *generator) fields(sb *strings.Builder, s morph.Struct, target string, prefix string, parents []string) error {
+    for _, p := range parents {
+        if p == s.Name { return fmt.Errorf("struct %q contains itself", s.Name) }
+    }
+    parents = append(parents, s.Name)
+
+    for _, f := range s.Fields {
+        if (f.Name == "") || (f.Name == f.Type) || strings.Contains(f.Name, ".") {
+            return fmt.Errorf("embedded field %q is not supported", f.Type)
+        }
+        if r := []rune(f.Name)[0]; !unicode.IsUpper(r) { continue }
+
+        name := strings.ToUpper(strings.Join(source.Words(f.Name), "_"))
+        if g.options.Names != nil { name = g.options.Names(f.Name) }
+        required := false
+        if value, ok := tag.Lookup(f.Tag, "env"); ok {
+            if value == "-" { continue }
+            tagName, opts, _ := strings.Cut(value, ",")
+            if tagName != "" { name = tagName }
+            for opts != "" {
+                var opt string
+                opt, opts, _ = strings.Cut(opts, ",")
+                switch opt {
+                case "required": required = true
+                default:
+                    return fmt.Errorf("unsupported option %q in tag of field %q", opt, f.Name)
+                }
+            }
+        }
+        fallback, _ := tag.Lookup(f.Tag, "envDefault")
+        path := target + "." + f.Name
+
+        if nested, ok := g.structs[f.Type]; ok {
+            if required || (fallback != "") {
+                return fmt.Errorf("field %q: a struct cannot be required or have a default", f.Name)
+            }
+            if err := g.fields(sb, nested, path, prefix + name + "_", parents); err != nil {
+                return fmt.Errorf("field %q: %w", f.Name, err)
+            }
+            continue
+        }
+
+        name = prefix + name
+        if other, exists := g.seen[name]; exists {
+            return fmt.Errorf("fields %q and %q have the same variable name %q", other, path, name)
+        }
+        g.seen[name] = path
+
+        quoted := strconv.Quote(name)
+        fmt.Fprintf(sb, "// %s\n", strings.TrimPrefix(path, "_result."))
+        fmt.Fprintf(sb, "if _v, _ok := envLookup(lookup, %s, %s); _ok {\n", quoted, strconv.Quote(fallback))
+        if err := g.parse(sb, path, f.Type, quoted); err != nil {
+            return fmt.Errorf("field %q: %w", f.Name, err)
+        }
+        if required {
+            fmt.Fprintf(sb, "} else {\n_errs = append(_errs, envMissingError(%s))\n", quoted)
+        }
+        sb.WriteString("}\n\n")
+    }
+    return nil
+}
+
+const (
+    kindDuration = source.KindUser + iota
+    kindPointer
+    kindSlice
+)
+
+// kind returns the kind of a type, and its bit size for numeric types, or
+// its element type for pointers and slices.
+func (g *generator) kind(Type string) (k source.Kind, bits int, elem string, err error) {
+    if underlying, ok := g.options.Underlying[Type]; ok { Type = underlying }
+    if k, bits, ok := source.Builtin(Type); ok { return k, bits, "", nil }
+    if (Type == "[]byte") || (Type == "[]uint8") { return source.KindString, 0, "", nil }
+    if Type == "time.Duration" { return kindDuration, 0, "", nil }
+    for _, x := range []struct{ prefix string; k source.Kind }{{"*", kindPointer}, {"[]", kindSlice}} {
+        if !strings.HasPrefix(Type, x.prefix) { continue }
+        elem := Type[len(x.prefix):]
+        if ek, _, _, err := g.kind(elem); (err == nil) && (ek < kindPointer) {
+            return x.k, 0, elem, nil
+        }
+    }
+    return 0, 0, "", fmt.Errorf("unsupported type %q", Type)
+}
+
+// parse writes code that parses the string _v, from the variable with the
+// given quoted name, into the addressable target, of the given type, or
+// appends an error to _errs.
+func (g *generator) parse(sb *strings.Builder, target string, Type string, name string) error {
+    k, bits, elem, err := g.kind(Type)
+    if err != nil { return err }
+
+    // call writes code that parses _v with a function returning a value and
+    // an error.
+    call := func(fn string) {
+        fmt.Fprintf(sb, "if _x, _err := %s; _err != nil {\n", fn)
+        fmt.Fprintf(sb, "_errs = append(_errs, envParseError(%s, _v, _err))\n", name)
+        fmt.Fprintf(sb, "} else {\n%s = %s(_x)\n}\n", target, Type)
+    }
+
+    switch k {
+    case source.KindString:
+        fmt.Fprintf(sb, "%s = %s(_v)\n", target, Type)
+    case source.KindBool:
+        call("strconv.ParseBool(_v)")
+    case source.KindInt:
+        call(fmt.Sprintf("strconv.ParseInt(_v, 10, %d)", bits))
+    case source.KindUint:
+        call(fmt.Sprintf("strconv.ParseUint(_v, 10, %d)", bits))
+    case source.KindFloat:
+        call(fmt.Sprintf("strconv.ParseFloat(_v, %d)", bits))
+    case kindDuration:
+        call("time.ParseDuration(_v)")
+    case kindPointer:
+        fmt.Fprintf(sb, "{\n_p := new(%s)\n", elem)
+        fmt.Fprintf(sb, "_n := len(_errs)\n")
+        if err := g.parse(sb, "(*_p)", elem, name); err != nil { return err }
+        fmt.Fprintf(sb, "if len(_errs) == _n {\n%s = _p\n}\n}\n", target)
+    case kindSlice:
+        fmt.Fprintf(sb, "_vs := envSplit(_v)\n")
+        fmt.Fprintf(sb, "%s = make(%s, len(_vs))\n", target, Type)
+        sb.WriteString("for _i, _v := range _vs {\n")
+        if err := g.parse(sb, target + "[_i]", elem, name); err != nil { return err }
+        sb.WriteString("}\n")
+    }
+    return nil
+}
@@ -0,0 +1,144 @@
+package envconfig_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/envconfig"
+    "github.com/tawesoft/morph/internal"
+)
+
+const source = `
+package example
+
+type Level int
+
+type Database struct {
+    Host     string         ` + "`env:\",required\"`" + `
+    Port     uint16         ` + "`envDefault:\"5432\"`" + `
+    Timeout  time.Duration  ` + "`envDefault:\"5s\"`" + `
+}
+
+type Config struct {
+    Name      string         ` + "`env:\"SERVICE_NAME\" envDefault:\"demo\"`" + `
+    HTTPPort  int            ` + "`env:\",required\"`" + `
+    Debug     bool
+    Ratio     float32
+    Verbosity Level
+    MaxConns  *int
+    Tags      []string
+    Weights   []float64
+    Key       []byte
+    DB        Database
+    Replica   Database       ` + "`env:\"REPLICA\"`" + `
+    Secret    string         ` + "`env:\"-\"`" + `
+    private   int
+}
+`
+
+func TestFunctions(t *testing.T) {
+    config := internal.Must(morph.ParseStruct("test.go", source, "Config"))
+    database := internal.Must(morph.ParseStruct("test.go", source, "Database"))
+
+    functions := internal.Must(envconfig.Functions(config, envconfig.Options{
+        Prefix:     "APP_",
+        Structs:    []morph.Struct{database},
+        Underlying: map[string]string{"Level": "int"},
+    }))
+    functions = append(functions, envconfig.Helpers()...)
+
+    var sb strings.Builder
+    sb.WriteString("package main\n\nimport (\n")
+    for _, i := range envconfig.Imports(functions...) {
+        if i == "fmt" || i == "time" { continue }
+        fmt.Fprintf(&sb, "%q\n", i)
+    }
+    sb.WriteString("\"fmt\"\n\"time\"\n)\n\ntype Level int\n\n")
+    sb.WriteString(database.String() + "\n\n")
+    sb.WriteString(config.String() + "\n\n")
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(`
+func lookupIn(env map[string]string) func(string) (string, bool) {
+    return func(name string) (string, bool) {
+        v, ok := env[name]
+        return v, ok
+    }
+}
+
+func main() {
+    c, err := LoadConfigFromEnv(lookupIn(map[string]string{
+        "APP_HTTP_PORT":       "8080",
+        "APP_DEBUG":           "true",
+        "APP_RATIO":           "0.5",
+        "APP_VERBOSITY":       "3",
+        "APP_MAX_CONNS":       "10",
+        "APP_TAGS":            "a, b,c",
+        "APP_WEIGHTS":         "1,2.5",
+        "APP_KEY":             "a,b",
+        "APP_DB_HOST":         "db.local",
+        "APP_DB_PORT":         "6543",
+        "APP_REPLICA_HOST":    "replica.local",
+        "APP_REPLICA_TIMEOUT": "",
+        "APP_SECRET":          "secret",
+    }))
+    fmt.Println(err)
+    fmt.Println(c.Name, c.HTTPPort, c.Debug, c.Ratio, c.Verbosity, *c.MaxConns, c.Tags, len(c.Tags), c.Weights)
+    fmt.Println(c.DB.Host, c.DB.Port, c.DB.Timeout, c.Replica.Host, c.Replica.Port, c.Replica.Timeout, c.Secret == "", string(c.Key))
+
+    c, err = LoadConfigFromEnv(lookupIn(map[string]string{
+        "APP_SERVICE_NAME": "other",
+        "APP_DEBUG":        "maybe",
+        "APP_MAX_CONNS":    "many",
+        "APP_WEIGHTS":      "1,x",
+        "APP_DB_PORT":      "70000",
+        "APP_DB_TIMEOUT":   "soon",
+        "APP_REPLICA_HOST": "replica.local",
+    }))
+    fmt.Println(err)
+    fmt.Println(c.Name, c.MaxConns == nil, c.Replica.Host)
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        expected := strings.Join([]string{
+            "<nil>",
+            "demo 8080 true 0.5 3 10 [a b c] 3 [1 2.5]",
+            "db.local 6543 5s replica.local 5432 5s true a,b",
+            "env: APP_HTTP_PORT: required but not set",
+            "env: APP_DEBUG: cannot parse \"maybe\": invalid syntax",
+            "env: APP_MAX_CONNS: cannot parse \"many\": invalid syntax",
+            "env: APP_WEIGHTS: cannot parse \"x\": invalid syntax",
+            "env: APP_DB_HOST: required but not set",
+            "env: APP_DB_PORT: cannot parse \"70000\": value out of range",
+            "env: APP_DB_TIMEOUT: cannot parse \"soon\": time: invalid duration \"soon\"",
+            "other true replica.local",
+        }, "\n") + "\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_errors(t *testing.T) {
+    inner := morph.Struct{Name: "Inner", Fields: []morph.Field{{Name: "X", Type: "int"}}}
+    loop := morph.Struct{Name: "Loop", Fields: []morph.Field{{Name: "L", Type: "Loop"}}}
+    for _, s := range []morph.Struct{
+        {Name: "A", Fields: []morph.Field{{Name: "X", Type: "map[string]int"}}},
+        {Name: "B", Fields: []morph.Field{{Name: "X", Type: "int"}, {Name: "Y", Type: "int", Tag: `env:"X"`}}},
+        {Name: "C", Fields: []morph.Field{{Name: "X", Type: "*[]int"}}},
+        {Name: "D", TypeParams: []morph.Field{{Name: "T", Type: "any"}}, Fields: []morph.Field{{Name: "X", Type: "int"}}},
+        {Name: "E", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `env:",optional"`}}},
+        {Name: "F", Fields: []morph.Field{{Name: "X", Type: "Inner", Tag: `env:",required"`}}},
+        {Name: "G", Fields: []morph.Field{{Name: "Inner_X", Type: "int", Tag: `env:"INNER_X"`}, {Name: "In", Type: "Inner", Tag: `env:"INNER"`}}},
+        loop,
+    } {
+        if _, err := envconfig.Functions(s, envconfig.Options{Structs: []morph.Struct{inner, loop}}); err == nil {
+            t.Errorf("expected error for struct %s", s.Name)
+        }
+    }
+}
@@ -0,0 +1,51 @@
+package envconfig
+
+import (
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+)
+
+// Helpers returns unexported helper functions, each with a name starting
+// with "env", that are called by the generated functions. They must be
+// generated exactly once in each package that uses the generated functions.
+//
+// Use [Imports] to find the import paths they require.
+func Helpers() []morph.Function {
+    return source.Functions(helpers)
+}
+
+const helpers = `package helpers
+
+// envLookup returns the value of an environment variable, using a lookup
+// function such as os.LookupEnv, or the fallback value, if not empty, if the
+// variable is unset or empty. It returns false if there is no value.
+func envLookup(lookup func(string) (string, bool), name string, fallback string) (string, bool) {
+    if v, ok := lookup(name); ok && (v != "") { return v, true }
+    if fallback != "" { return fallback, true }
+    return "", false
+}
+
+// envSplit splits a comma-separated list, trimming spaces around each
+// element.
+func envSplit(value string) []string {
+    xs := strings.Split(value, ",")
+    for i := range xs {
+        xs[i] = strings.TrimSpace(xs[i])
+    }
+    return xs
+}
+
+// envParseError returns an error for the value of an environment variable
+// that cannot be parsed.
+func envParseError(name string, value string, err error) error {
+    var numErr *strconv.NumError
+    if errors.As(err, &numErr) { err = numErr.Err }
+    return fmt.Errorf("env: %s: cannot parse %q: %w", name, value, err)
+}
+
+// envMissingError returns an error for a required environment variable that
+// is unset or empty.
+func envMissingError(name string) error {
+    return fmt.Errorf("env: %s: required but not set", name)
+}
+`
@@ -0,0 +1,302 @@
+// Package flagset generates functions that bind the fields of a
+// configuration struct to command-line flags in a [flag.FlagSet], without
+// using reflection at runtime.
+//
+// For a struct Foo, [Functions] generates:
+//
+//     func RegisterFooFlags(fs *flag.FlagSet, dst *Foo, prefix string)
+package flagset
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "unicode"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+    "github.com/tawesoft/morph/tag"
+)
+
+// Options configure the generated functions.
+type Options struct {
+    // Names, if not nil, maps a field name to a flag name, without any
+    // prefix, for each field without a name in its "flag" tag. Otherwise,
+    // the field name is converted to kebab case e.g. "MaxConns" to
+    // "max-conns".
+    Names func(field string) string
+
+    // Structs are other structs that may be the types of fields, whose
+    // fields are bound recursively.
+    Structs []morph.Struct
+
+    // Text lists the names of types, such as "net.IP", whose pointer types
+    // implement [encoding.TextUnmarshaler], so that fields of these types
+    // are parsed by their UnmarshalText method. time.Time is always
+    // included.
+    Text []string
+
+    // Underlying maps the name of a named type, such as "Celsius", to its
+    // underlying type, such as "float64", so that fields of that type can be
+    // converted.
+    Underlying map[string]string
+}
+
+// Imports returns the sorted import paths required by the given generated
+// functions.
+func Imports(functions ... morph.Function) []string {
+    return source.Imports(functions...)
+}
+
+// Functions generates the functions, described in the package
+// documentation, for the given struct.
+//
+// Each exported field is bound to a flag, named by the prefix argument of
+// the generated function, followed by the field's "flag" tag e.g.
+// `flag:"port"`, if any, or by [Options].Names. A field with the tag
+// `flag:"-"` is skipped. The field's comment is the flag's usage text, and
+// its value, when the function is called, is the flag's default value.
+//
+// A field with the type of a struct in [Options].Structs is bound
+// recursively, with the field's flag name, and a dot, as a further prefix
+// e.g. "db.host" for a field Host of a field DB.
+//
+// The following field types are supported:
+//
+//   - string, bool, int, int64, uint, uint64, float64, and time.Duration,
+//     bound with the matching method of [flag.FlagSet] e.g. StringVar;
+//   - each other builtin integer and floating point type, parsed with
+//     [strconv];
+//   - types listed in [Options].Text, and time.Time, parsed with their
+//     UnmarshalText method;
+//   - pointers to any of these, which are only set if the flag is given;
+//   - slices of any of these, where each use of the flag appends a value.
+//
+// Pointers to, and slices of, bool are not supported, because a boolean
+// flag must be allowed without a value e.g. "-verbose".
+func Functions(s morph.Struct, options Options) ([]morph.Function, error) {
+    esc := func(err error) ([]morph.Function, error) {
+        return nil, fmt.Errorf(
+            "error generating flag functions for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    if len(s.TypeParams) > 0 {
+        return esc(fmt.Errorf("generic structs are not supported"))
+    }
+
+    g := &generator{
+        s:       s,
+        options: options,
+        structs: make(map[string]morph.Struct),
+        text:    map[string]bool{"time.Time": true},
+        seen:    make(map[string]string),
+    }
+    for _, x := range options.Structs {
+        g.structs[x.Name] = x
+    }
+    for _, x := range options.Text {
+        g.text[x] = true
+    }
+
+    register, err := g.register()
+    if err != nil { return esc(err) }
+
+    functions := []morph.Function{register}
+    for i := range functions {
+        functions[i].Body = strings.TrimSpace(functions[i].Body)
+    }
+    return functions, nil
+}
+
+type generator struct {
+    s       morph.Struct
+    options Options
+    structs map[string]morph.Struct
+    text    map[string]bool
+    seen    map[string]string // flag name => field path
+}
+
+// usage returns a comment as usage text, on one line.
+func usage(comment string) string {
+    return strings.Join(strings.Fields(comment), " ")
+}
+
+func (g *generator) register() (morph.Function, error) {
+    var sb strings.Builder
+    if err := g.fields(&sb, g.s, "dst", "", nil); err != nil {
+        return morph.Function{}, err
+    }
+
+    name := "Register" + g.s.Name + "Flags"
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("%s defines a flag in a FlagSet for each field of a [%s] value, "+
+                "with the given prefix, which is usually empty. Each flag's default value is the "+
+                "field's current value, and parsing the flag sets the field.", name, g.s.Name),
+            Name: name,
+            Arguments: []morph.Argument{
+                {Name: "fs", Type: "*flag.FlagSet"},
+                {Name: "dst", Type: "*" + g.s.Name},
+                {Name: "prefix", Type: "string"},
+            },
+        },
+        Body: sb.String(),
+    }, nil
+}
+
+// fields writes code that binds each field of a struct, at target, to a
+// flag with the given name prefix. Parents are the names of the structs
+// already being bound.
+func (g *generator) fields(sb *strings.Builder, s morph.Struct, target string, prefix string, parents []string) error {
+    for _, p := range parents {
+        if p == s.Name { return fmt.Errorf("struct %q contains itself", s.Name) }
+    }
+    parents = append(parents, s.Name)
+
+    for _, f := range s.Fields {
+        if (f.Name == "") || (f.Name == f.Type) || strings.Contains(f.Name, ".") {
+            return fmt.Errorf("embedded field %q is not supported", f.Type)
+        }
+        if r := []rune(f.Name)[0]; !unicode.IsUpper(r) { continue }
+
+        name := strings.ToLower(strings.Join(source.Words(f.Name), "-"))
+        if g.options.Names != nil { name = g.options.Names(f.Name) }
+        if value, ok := tag.Lookup(f.Tag, "flag"); ok {
+            if value == "-" { continue }
+            if value != "" { name = value }
+        }
+        path := target + "." + f.Name
+
+        if nested, ok := g.structs[f.Type]; ok {
+            if err := g.fields(sb, nested, path, prefix + name + ".", parents); err != nil {
+                return fmt.Errorf("field %q: %w", f.Name, err)
+            }
+            continue
+        }
+
+        name = prefix + name
+        if other, exists := g.seen[name]; exists {
+            return fmt.Errorf("fields %q and %q have the same flag name %q", other, path, name)
+        }
+        g.seen[name] = path
+
+        fmt.Fprintf(sb, "// %s\n", strings.TrimPrefix(path, "dst."))
+        if err := g.bind(sb, path, f.Type, "prefix + " + strconv.Quote(name), strconv.Quote(usage(f.Comment))); err != nil {
+            return fmt.Errorf("field %q: %w", f.Name, err)
+        }
+        sb.WriteString("\n")
+    }
+    return nil
+}
+
+const (
+    kindDuration = source.KindUser + iota
+    kindText
+    kindPointer
+    kindSlice
+)
+
+// kind returns the kind of a type, its underlying type, and its bit size
+// for numeric types, or its element type for pointers and slices.
+func (g *generator) kind(Type string) (k source.Kind, underlying string, bits int, elem string, err error) {
+    if g.text[Type] { return kindText, Type, 0, "", nil }
+    underlying = Type
+    if u, ok := g.options.Underlying[Type]; ok { underlying = u }
+    if k, bits, ok := source.Builtin(underlying); ok { return k, underlying, bits, "", nil }
+    if underlying == "time.Duration" { return kindDuration, underlying, 0, "", nil }
+    for _, x := range []struct{ prefix string; k source.Kind }{{"*", kindPointer}, {"[]", kindSlice}} {
+        if !strings.HasPrefix(Type, x.prefix) { continue }
+        elem := Type[len(x.prefix):]
+        ek, _, _, _, err := g.kind(elem)
+        if ek == source.KindBool {
+            // a flag.Value for these would need an IsBoolFlag method, so
+            // that the flag can be given without a value
+            return 0, "", 0, "", fmt.Errorf("unsupported type %q: pointers to, and slices of, bool are not supported", Type)
+        }
+        if (err == nil) && (ek < kindPointer) {
+            return x.k, Type, 0, elem, nil
+        }
+    }
+    return 0, "", 0, "", fmt.Errorf("unsupported type %q", Type)
+}
+
+// bind writes code that binds the addressable target, of the given type,
+// to a flag with the given name and usage expressions.
+func (g *generator) bind(sb *strings.Builder, target string, Type string, name string, usage string) error {
+    k, underlying, _, elem, err := g.kind(Type)
+    if err != nil { return err }
+
+    // flag.FlagSet methods that bind a variable of exactly the right type
+    method := map[string]string{
+        "string":        "StringVar",
+        "bool":          "BoolVar",
+        "int":           "IntVar",
+        "int64":         "Int64Var",
+        "uint":          "UintVar",
+        "uint64":        "Uint64Var",
+        "float64":       "Float64Var",
+        "time.Duration": "DurationVar",
+    }[underlying]
+    if (method != "") && (k < kindText) {
+        if Type == underlying {
+            fmt.Fprintf(sb, "fs.%s(&%s, %s, %s, %s)\n", method, target, name, target, usage)
+        } else {
+            fmt.Fprintf(sb, "fs.%s((*%s)(&%s), %s, %s(%s), %s)\n",
+                method, underlying, target, name, underlying, target, usage)
+        }
+        return nil
+    }
+    fmt.Fprintf(sb, "fs.Func(%s, %s, func(_s string) error {\n", name, usage)
+
+    switch k {
+    case kindPointer:
+        fmt.Fprintf(sb, "var _x %s\n", elem)
+        if err := g.parse(sb, "_x", elem); err != nil { return err }
+        fmt.Fprintf(sb, "%s = &_x\n", target)
+    case kindSlice:
+        fmt.Fprintf(sb, "var _x %s\n", elem)
+        if err := g.parse(sb, "_x", elem); err != nil { return err }
+        fmt.Fprintf(sb, "%s = append(%s, _x)\n", target, target)
+    default:
+        if err := g.parse(sb, target, Type); err != nil { return err }
+    }
+    sb.WriteString("return nil\n})\n")
+    return nil
+}
+
+// parse writes code that parses the string _s into the addressable target,
+// of the given type, returning any error.
+func (g *generator) parse(sb *strings.Builder, target string, Type string) error {
+    k, _, bits, _, err := g.kind(Type)
+    if err != nil { return err }
+
+    // call writes code that parses _s with a function returning a value and
+    // an error.
+    call := func(fn string) {
+        fmt.Fprintf(sb, "_v, _err := %s\n", fn)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+        fmt.Fprintf(sb, "%s = %s(_v)\n", target, Type)
+    }
+
+    switch k {
+    case source.KindString:
+        fmt.Fprintf(sb, "%s = %s(_s)\n", target, Type)
+    case source.KindBool:
+        call("strconv.ParseBool(_s)")
+    case source.KindInt:
+        call(fmt.Sprintf("strconv.ParseInt(_s, 10, %d)", bits))
+    case source.KindUint:
+        call(fmt.Sprintf("strconv.ParseUint(_s, 10, %d)", bits))
+    case source.KindFloat:
+        call(fmt.Sprintf("strconv.ParseFloat(_s, %d)", bits))
+    case kindDuration:
+        call("time.ParseDuration(_s)")
+    case kindText:
+        fmt.Fprintf(sb, "if _err := (&%s).UnmarshalText([]byte(_s)); _err != nil {\nreturn _err\n}\n", target)
+    default:
+        return fmt.Errorf("unsupported type %q", Type)
+    }
+    return nil
+}
@@ -0,0 +1,141 @@
+package flagset_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/flagset"
+    "github.com/tawesoft/morph/internal"
+)
+
+const source = `
+package example
+
+type Level int
+
+type Database struct {
+    // Host is the database
+    // host name.
+    Host     string
+    Port     uint16
+    Timeout  time.Duration
+}
+
+type Config struct {
+    // Name of the service.
+    Name      string         ` + "`flag:\"service-name\"`" + `
+    HTTPPort  int
+    Debug     bool
+    Ratio     float32
+    Verbosity Level
+    MaxConns  *int
+    Tags      []string
+    Addr      net.IP
+    Start     time.Time
+    DB        Database
+    Secret    string         ` + "`flag:\"-\"`" + `
+    private   int
+}
+`
+
+func TestFunctions(t *testing.T) {
+    config := internal.Must(morph.ParseStruct("test.go", source, "Config"))
+    database := internal.Must(morph.ParseStruct("test.go", source, "Database"))
+
+    functions := internal.Must(flagset.Functions(config, flagset.Options{
+        Structs:    []morph.Struct{database},
+        Text:       []string{"net.IP"},
+        Underlying: map[string]string{"Level": "int"},
+    }))
+
+    var sb strings.Builder
+    sb.WriteString("package main\n\nimport (\n")
+    for _, i := range flagset.Imports(functions...) {
+        if i == "fmt" || i == "net" || i == "os" || i == "time" { continue }
+        fmt.Fprintf(&sb, "%q\n", i)
+    }
+    sb.WriteString("\"fmt\"\n\"net\"\n\"os\"\n\"time\"\n)\n\ntype Level int\n\n")
+    sb.WriteString(database.String() + "\n\n")
+    sb.WriteString(config.String() + "\n\n")
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(`
+func main() {
+    c := Config{Name: "demo", HTTPPort: 8080, DB: Database{Port: 5432}}
+    fs := flag.NewFlagSet("test", flag.ContinueOnError)
+    fs.SetOutput(os.Stdout)
+    RegisterConfigFlags(fs, &c, "")
+
+    err := fs.Parse([]string{
+        "-http-port", "9090",
+        "-debug",
+        "-ratio", "0.5",
+        "-verbosity", "3",
+        "-max-conns", "10",
+        "-tags", "a", "-tags", "b",
+        "-addr", "127.0.0.1",
+        "-start", "2020-01-02T03:04:05Z",
+        "-db.host", "db.local",
+        "-db.timeout", "5s",
+        "rest",
+    })
+    fmt.Println(err, fs.Args())
+    fmt.Println(c.Name, c.HTTPPort, c.Debug, c.Ratio, c.Verbosity, *c.MaxConns, c.Tags, c.Addr, c.Start.Year())
+    fmt.Println(c.DB.Host, c.DB.Port, c.DB.Timeout)
+
+    fs = flag.NewFlagSet("test", flag.ContinueOnError)
+    fs.SetOutput(os.Stdout)
+    RegisterConfigFlags(fs, &c, "app.")
+    fmt.Println(fs.Parse([]string{"-app.db.port", "70000"}))
+    fmt.Println(fs.Lookup("app.service-name").Usage, fs.Lookup("app.service-name").DefValue)
+    fmt.Println(fs.Lookup("app.db.host").Usage, fs.Lookup("app.db.host").DefValue)
+    fmt.Println(fs.Lookup("app.secret") == nil)
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        expected := strings.Join([]string{
+            "<nil> [rest]",
+            "demo 9090 true 0.5 3 10 [a b] 127.0.0.1 2020",
+            "db.local 5432 5s",
+            "invalid value \"70000\" for flag -app.db.port: strconv.ParseUint: parsing \"70000\": value out of range",
+        }, "\n") + "\n"
+        // on a parse error, the FlagSet also prints the error, and the usage
+        // of every flag, before the error is returned
+        if !strings.HasPrefix(stdout, expected) {
+            return fmt.Errorf("got %q, expected prefix %q", stdout, expected)
+        }
+        suffix := strings.Join([]string{
+            "invalid value \"70000\" for flag -app.db.port: strconv.ParseUint: parsing \"70000\": value out of range",
+            "Name of the service. demo",
+            "Host is the database host name. db.local",
+            "true",
+        }, "\n") + "\n"
+        if !strings.HasSuffix(stdout, suffix) {
+            return fmt.Errorf("got %q, expected suffix %q", stdout, suffix)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_errors(t *testing.T) {
+    inner := morph.Struct{Name: "Inner", Fields: []morph.Field{{Name: "X", Type: "int"}}}
+    loop := morph.Struct{Name: "Loop", Fields: []morph.Field{{Name: "L", Type: "Loop"}}}
+    for _, s := range []morph.Struct{
+        {Name: "A", Fields: []morph.Field{{Name: "X", Type: "map[string]int"}}},
+        {Name: "B", Fields: []morph.Field{{Name: "X", Type: "int"}, {Name: "Y", Type: "int", Tag: `flag:"x"`}}},
+        {Name: "C", Fields: []morph.Field{{Name: "X", Type: "*[]int"}}},
+        {Name: "D", TypeParams: []morph.Field{{Name: "T", Type: "any"}}, Fields: []morph.Field{{Name: "X", Type: "int"}}},
+        {Name: "E", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `flag:"in.x"`}, {Name: "In", Type: "Inner"}}},
+        {Name: "F", Fields: []morph.Field{{Name: "X", Type: "*bool"}}},
+        {Name: "G", Fields: []morph.Field{{Name: "X", Type: "[]bool"}}},
+        loop,
+    } {
+        if _, err := flagset.Functions(s, flagset.Options{Structs: []morph.Struct{inner, loop}}); err == nil {
+            t.Errorf("expected error for struct %s", s.Name)
+        }
+    }
+}
@@ -0,0 +1,370 @@
+// Package histogram generates histogram types that count the values of
+// each field of a struct in buckets.
+//
+// For a struct Foo, and a [Rule] for each field to be counted, [Generate]
+// returns a struct FooHistogram, and the functions:
+//
+//     func (fooHistogram *FooHistogram) Add(foo Foo)
+//     func (fooHistogram *FooHistogram) Merge(other FooHistogram)
+//     func NewFooHistogram(foos []Foo) FooHistogram
+package histogram
+
+import (
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/internal"
+)
+
+type ruleKind int
+
+const (
+    ruleFixedWidth ruleKind = iota + 1
+    ruleBoundaries
+    ruleTruncate
+    ruleCategories
+)
+
+// Rule describes how the values of a field are assigned to buckets.
+//
+// Create a Rule with [FixedWidth], [Boundaries], [Truncate], or
+// [Categories].
+type Rule struct {
+    kind     ruleKind
+    min      float64
+    width    float64
+    n        int
+    bounds   []float64
+    truncate time.Duration
+    values   []string
+}
+
+// FixedWidth returns a Rule for a numeric field that counts values in n
+// buckets, each of the given width, starting at min.
+//
+// The histogram field is an array of n+2 counts. Index 0 counts values below
+// min. Index i, for i from 1 to n, counts values from min + (i-1)*width
+// (inclusive) to min + i*width (exclusive). Index n+1 counts values at or
+// above min + n*width, and NaN values.
+func FixedWidth(min float64, width float64, n int) Rule {
+    return Rule{kind: ruleFixedWidth, min: min, width: width, n: n}
+}
+
+// Boundaries returns a Rule for a numeric field that counts values in
+// buckets separated by the given boundaries, which must be in increasing
+// order.
+//
+// The histogram field is an array of len(boundaries)+1 counts. Index 0 counts
+// values below boundaries[0]. Index i counts values from boundaries[i-1]
+// (inclusive) to boundaries[i] (exclusive). The last index counts values at
+// or above the last boundary, and NaN values.
+func Boundaries(boundaries ... float64) Rule {
+    return Rule{kind: ruleBoundaries, bounds: append([]float64(nil), boundaries...)}
+}
+
+// Truncate returns a Rule for a time.Time field that counts values in
+// buckets of the given duration, using the Truncate method on the value in
+// UTC.
+//
+// The histogram field is a map from the truncated time to a count.
+func Truncate(d time.Duration) Rule {
+    return Rule{kind: ruleTruncate, truncate: d}
+}
+
+// Categories returns a Rule for a field that counts each distinct value.
+//
+// If no values are given, the histogram field is a map from each value to a
+// count, and the field type must be comparable.
+//
+// Otherwise, the field must be a string, and the histogram field is an array
+// of len(values)+1 counts. Index i counts values equal to values[i], and the
+// last index counts any other value.
+func Categories(values ... string) Rule {
+    return Rule{kind: ruleCategories, values: append([]string(nil), values...)}
+}
+
+// Generate returns a histogram struct, and the functions described in the
+// package documentation, for the given struct.
+//
+// The histogram struct has a Count field, counting every value added, and a
+// field for each field of the input struct that has a Rule, with the same
+// name. Fields without a Rule are not counted. It is an error to give a
+// Rule for a field named Count.
+//
+// In the provided name, the token "$" is rewritten to the name of the input
+// struct. For example, "$Histogram" on a struct named "Foo" gives a struct
+// named "FooHistogram".
+func Generate(s morph.Struct, name string, rules map[string]Rule) (morph.Struct, []morph.Function, error) {
+    esc := func(err error) (morph.Struct, []morph.Function, error) {
+        return morph.Struct{}, nil, fmt.Errorf(
+            "error generating histogram for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    for fieldName := range rules {
+        if !hasField(s, fieldName) {
+            return esc(fmt.Errorf("rule for missing field %q", fieldName))
+        }
+        if fieldName == "Count" {
+            return esc(fmt.Errorf("rule for field %q conflicts with the histogram's Count field", fieldName))
+        }
+    }
+
+    h := morph.Struct{
+        Name:       strings.ReplaceAll(name, "$", s.Name),
+        TypeParams: append([]morph.Field(nil), s.TypeParams...),
+    }
+    h.Comment = fmt.Sprintf("%s counts [%s] values in buckets, for each field.", h.Name, s.Name)
+    h.Fields = []morph.Field{{
+        Name:    "Count",
+        Type:    "int",
+        Comment: "total number of values",
+    }}
+
+    g := generator{
+        s:        s,
+        h:        h,
+        receiver: internal.Untitle(h.Name),
+        arg:      internal.Untitle(s.Name),
+    }
+    if g.arg == g.receiver { g.arg = "_" + g.arg }
+
+    for _, f := range s.Fields {
+        rule, ok := rules[f.Name]
+        if !ok { continue }
+        hf, err := rule.field(f)
+        if err != nil { return esc(err) }
+        g.h.Fields = append(g.h.Fields, hf)
+        g.fields = append(g.fields, f)
+        g.rules = append(g.rules, rule)
+    }
+
+    return g.h, []morph.Function{g.add(), g.merge(), g.new()}, nil
+}
+
+// field returns the histogram field for an input field.
+func (r Rule) field(f morph.Field) (morph.Field, error) {
+    out := morph.Field{Name: f.Name}
+    switch r.kind {
+    case ruleFixedWidth:
+        if (r.n < 1) || !(r.width > 0) || !finite(r.min, r.width, r.min + float64(r.n) * r.width) {
+            return out, fmt.Errorf("invalid FixedWidth rule for field %q", f.Name)
+        }
+        out.Type = fmt.Sprintf("[%d]int", r.n + 2)
+        out.Comment = fmt.Sprintf(
+            "%s counts values below %s, then in %d buckets of width %s, then at or above %s",
+            f.Name, float(r.min), r.n, float(r.width), float(r.min + float64(r.n) * r.width),
+        )
+    case ruleBoundaries:
+        if len(r.bounds) == 0 {
+            return out, fmt.Errorf("empty Boundaries rule for field %q", f.Name)
+        }
+        if !finite(r.bounds...) {
+            return out, fmt.Errorf("Boundaries rule for field %q has a value that is not finite", f.Name)
+        }
+        for i := 1; i < len(r.bounds); i++ {
+            if !(r.bounds[i] > r.bounds[i-1]) {
+                return out, fmt.Errorf("Boundaries rule for field %q not in increasing order", f.Name)
+            }
+        }
+        out.Type = fmt.Sprintf("[%d]int", len(r.bounds) + 1)
+        out.Comment = fmt.Sprintf(
+            "%s counts values in buckets separated by %s",
+            f.Name, strings.Join(mapFloats(r.bounds), ", "),
+        )
+    case ruleTruncate:
+        if f.Type != "time.Time" {
+            return out, fmt.Errorf("Truncate rule for field %q of type %q (expected time.Time)", f.Name, f.Type)
+        }
+        if r.truncate <= 0 {
+            return out, fmt.Errorf("invalid Truncate rule for field %q", f.Name)
+        }
+        out.Type = "map[time.Time]int"
+        out.Comment = fmt.Sprintf("%s counts values in buckets of duration %s", f.Name, r.truncate)
+    case ruleCategories:
+        if len(r.values) == 0 {
+            out.Type = "map[" + f.Type + "]int"
+            out.Comment = fmt.Sprintf("%s counts each distinct value", f.Name)
+        } else if f.Type != "string" {
+            return out, fmt.Errorf("Categories rule with values for field %q of type %q (expected string)", f.Name, f.Type)
+        } else {
+            seen := make(map[string]bool)
+            for _, v := range r.values {
+                if seen[v] {
+                    return out, fmt.Errorf("Categories rule for field %q has duplicate value %q", f.Name, v)
+                }
+                seen[v] = true
+            }
+            out.Type = fmt.Sprintf("[%d]int", len(r.values) + 1)
+            out.Comment = fmt.Sprintf(
+                "%s counts values equal to %s, then any other value",
+                f.Name, strings.Join(mapQuote(r.values), ", "),
+            )
+        }
+    default:
+        return out, fmt.Errorf("invalid rule for field %q", f.Name)
+    }
+    return out, nil
+}
+
+// isMap returns true if the rule has a map histogram field.
+func (r Rule) isMap() bool {
+    return (r.kind == ruleTruncate) || ((r.kind == ruleCategories) && (len(r.values) == 0))
+}
+
+type generator struct {
+    s        morph.Struct
+    h        morph.Struct
+    receiver string // receiver name for methods on the histogram
+    arg      string // argument name for input values
+    fields   []morph.Field // input fields with a rule
+    rules    []Rule // rule for each input field
+}
+
+func (g generator) add() morph.Function {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "%s.Count++\n", g.receiver)
+    for i, f := range g.fields {
+        r := g.rules[i]
+        dest := g.receiver + "." + f.Name
+        value := g.arg + "." + f.Name
+        fmt.Fprintf(&sb, "\n// %s\n", f.Name)
+
+        switch r.kind {
+        case ruleFixedWidth:
+            fmt.Fprintf(&sb, "if _v := float64(%s); _v < %s {\n", value, float(r.min))
+            fmt.Fprintf(&sb, "%s[0]++\n", dest)
+            fmt.Fprintf(&sb, "} else if (_v >= %s) || (_v != _v) {\n", float(r.min + float64(r.n) * r.width))
+            fmt.Fprintf(&sb, "%s[%d]++\n", dest, r.n + 1)
+            fmt.Fprintf(&sb, "} else {\n")
+            fmt.Fprintf(&sb, "_i := 1 + int((_v - %s) / %s)\n", float(r.min), float(r.width))
+            fmt.Fprintf(&sb, "if _i > %d { _i = %d }\n", r.n, r.n)
+            fmt.Fprintf(&sb, "%s[_i]++\n", dest)
+            fmt.Fprintf(&sb, "}\n")
+        case ruleBoundaries:
+            fmt.Fprintf(&sb, "{\n")
+            fmt.Fprintf(&sb, "_v, _i := float64(%s), %d\n", value, len(r.bounds))
+            fmt.Fprintf(&sb, "for _j, _b := range [...]float64{%s} {\n", strings.Join(mapFloats(r.bounds), ", "))
+            fmt.Fprintf(&sb, "if _v < _b { _i = _j; break }\n")
+            fmt.Fprintf(&sb, "}\n")
+            fmt.Fprintf(&sb, "%s[_i]++\n", dest)
+            fmt.Fprintf(&sb, "}\n")
+        case ruleTruncate:
+            fmt.Fprintf(&sb, "if %s == nil { %s = make(map[time.Time]int) }\n", dest, dest)
+            fmt.Fprintf(&sb, "%s[%s.UTC().Truncate(%d)]++\n", dest, value, int64(r.truncate))
+        case ruleCategories:
+            if len(r.values) == 0 {
+                fmt.Fprintf(&sb, "if %s == nil { %s = make(%s) }\n", dest, dest, g.h.Fields[i+1].Type)
+                fmt.Fprintf(&sb, "%s[%s]++\n", dest, value)
+            } else {
+                fmt.Fprintf(&sb, "switch %s {\n", value)
+                for j, v := range r.values {
+                    fmt.Fprintf(&sb, "case %s: %s[%d]++\n", strconv.Quote(v), dest, j)
+                }
+                fmt.Fprintf(&sb, "default: %s[%d]++\n", dest, len(r.values))
+                fmt.Fprintf(&sb, "}\n")
+            }
+        }
+    }
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("Add counts a [%s] value.", g.s.Name),
+            Name: "Add",
+            Arguments: []morph.Argument{{Name: g.arg, Type: g.s.Type()}},
+            Receiver: morph.Argument{Name: g.receiver, Type: "*" + g.h.Type()},
+        },
+        Body: strings.TrimSpace(sb.String()),
+    }
+}
+
+func (g generator) merge() morph.Function {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "%s.Count += other.Count\n", g.receiver)
+    for i, f := range g.fields {
+        r := g.rules[i]
+        dest := g.receiver + "." + f.Name
+        src := "other." + f.Name
+        if r.isMap() {
+            fmt.Fprintf(&sb, "if (%s == nil) && (len(%s) > 0) { %s = make(%s) }\n", dest, src, dest, g.h.Fields[i+1].Type)
+            fmt.Fprintf(&sb, "for _k, _v := range %s { %s[_k] += _v }\n", src, dest)
+        } else {
+            fmt.Fprintf(&sb, "for _i, _v := range %s { %s[_i] += _v }\n", src, dest)
+        }
+    }
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: "Merge adds the counts of another histogram to this one.",
+            Name: "Merge",
+            Arguments: []morph.Argument{{Name: "other", Type: g.h.Type()}},
+            Receiver: morph.Argument{Name: g.receiver, Type: "*" + g.h.Type()},
+        },
+        Body: strings.TrimSpace(sb.String()),
+    }
+}
+
+func (g generator) new() morph.Function {
+    xs := g.arg + "s"
+    body := fmt.Sprintf(`var _out %s
+for _, _x := range %s {
+    _out.Add(_x)
+}
+return _out`, g.h.Type(), xs)
+
+    var typeParams []morph.Argument
+    for _, tp := range g.h.TypeParams {
+        typeParams = append(typeParams, morph.Argument{Name: tp.Name, Type: tp.Type})
+    }
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf(
+                "New%s returns a new [%s] counting each [%s] value in %s.",
+                g.h.Name, g.h.Name, g.s.Name, xs,
+            ),
+            Name:      "New" + g.h.Name,
+            Type:      typeParams,
+            Arguments: []morph.Argument{{Name: xs, Type: "[]" + g.s.Type()}},
+            Returns:   []morph.Argument{{Type: g.h.Type()}},
+        },
+        Body: body,
+    }
+}
+
+func hasField(s morph.Struct, name string) bool {
+    for _, f := range s.Fields {
+        if f.Name == name { return true }
+    }
+    return false
+}
+
+// float formats a float64 as a Go literal.
+func float(x float64) string {
+    return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+// finite returns true if every x is neither infinite nor NaN.
+func finite(xs ... float64) bool {
+    for _, x := range xs {
+        if math.IsInf(x, 0) || math.IsNaN(x) { return false }
+    }
+    return true
+}
+
+func mapFloats(xs []float64) []string {
+    result := make([]string, len(xs))
+    for i, x := range xs { result[i] = float(x) }
+    return result
+}
+
+func mapQuote(xs []string) []string {
+    result := make([]string, len(xs))
+    for i, x := range xs { result[i] = strconv.Quote(x) }
+    return result
+}
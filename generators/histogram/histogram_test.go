@@ -0,0 +1,122 @@
+package histogram_test
+
+import (
+    "fmt"
+    "math"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/histogram"
+    "github.com/tawesoft/morph/internal"
+)
+
+func TestGenerate(t *testing.T) {
+    source := `
+package example
+
+type Apple struct {
+    Picked time.Time
+    Weight float64
+    Price  int
+    Colour string
+    Grade  int
+    Secret string
+}
+`
+    apple := internal.Must(morph.ParseStruct("test.go", source, "Apple"))
+
+    h, functions, err := histogram.Generate(apple, "$Histogram", map[string]histogram.Rule{
+        "Picked": histogram.Truncate(24 * time.Hour),
+        "Weight": histogram.FixedWidth(100, 50, 4),
+        "Price":  histogram.Boundaries(10, 20, 50),
+        "Colour": histogram.Categories("red", "green"),
+        "Grade":  histogram.Categories(),
+    })
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    var sb strings.Builder
+    sb.WriteString(`package main
+
+import (
+    "fmt"
+    "math"
+    "time"
+)
+
+`)
+    sb.WriteString(apple.String() + "\n\n")
+    sb.WriteString(h.String() + "\n\n")
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(`
+func main() {
+    day := func(d int, hour int) time.Time {
+        return time.Date(2023, 1, d, hour, 0, 0, 0, time.UTC)
+    }
+    h := NewAppleHistogram([]Apple{
+        {Picked: day(1, 9),  Weight: 99,  Price: 5,  Colour: "red",    Grade: 1},
+        {Picked: day(1, 17), Weight: 100, Price: 10, Colour: "green",  Grade: 2},
+        {Picked: day(2, 9),  Weight: 175, Price: 49, Colour: "yellow", Grade: 1},
+    })
+    var h2 AppleHistogram
+    h2.Add(Apple{Picked: day(2, 1), Weight: 300, Price: 50, Colour: "red", Grade: 3})
+    h.Merge(h2)
+
+    fmt.Println(h.Count)
+    fmt.Println(h.Picked[day(1, 0)], h.Picked[day(2, 0)])
+    fmt.Println(h.Weight)
+    fmt.Println(h.Price)
+    fmt.Println(h.Colour)
+    fmt.Println(h.Grade)
+
+    var h3 AppleHistogram
+    h3.Add(Apple{Weight: math.NaN()})
+    fmt.Println(h3.Weight)
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        expected := "4\n" +
+            "2 2\n" +
+            "[1 1 1 0 0 1]\n" +
+            "[1 1 1 1]\n" +
+            "[2 1 1]\n" +
+            "map[1:2 2:1 3:1]\n" +
+            "[0 0 0 0 0 1]\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+
+    // errors
+    for _, rules := range []map[string]histogram.Rule{
+        {"Missing": histogram.Categories()},
+        {"Weight": histogram.Truncate(time.Hour)},
+        {"Price": histogram.Boundaries(2, 1)},
+        {"Grade": histogram.Categories("a")},
+        {"Weight": histogram.FixedWidth(0, 0, 1)},
+        {"Weight": histogram.FixedWidth(math.Inf(-1), 1, 1)},
+        {"Weight": histogram.FixedWidth(0, math.Inf(1), 1)},
+        {"Weight": histogram.FixedWidth(0, math.MaxFloat64, 2)},
+        {"Price": histogram.Boundaries(0, 10, math.Inf(1))},
+        {"Price": histogram.Boundaries(math.NaN())},
+        {"Colour": histogram.Categories("red", "green", "red")},
+    } {
+        if _, _, err := histogram.Generate(apple, "$Histogram", rules); err == nil {
+            t.Errorf("expected error for rules %v", rules)
+        }
+    }
+
+    counted := morph.Struct{Name: "Counted", Fields: []morph.Field{{Name: "Count", Type: "int"}}}
+    if _, _, err := histogram.Generate(counted, "$Histogram", map[string]histogram.Rule{
+        "Count": histogram.Categories(),
+    }); err == nil {
+        t.Errorf("expected error for a rule on a field named Count")
+    }
+}
@@ -0,0 +1,39 @@
+package source
+
+// Kind classifies a Go type by how a generator formats or parses its values.
+type Kind int
+
+const (
+    KindString Kind = iota
+    KindBool
+    KindInt
+    KindUint
+    KindFloat
+
+    // KindUser is the first Kind that a generator may use for its own kinds
+    // of type, such as time.Duration or pointers, as KindUser + iota.
+    KindUser
+)
+
+// Builtin returns the Kind of a builtin string, boolean, integer or floating
+// point type, and its size in bits, or zero for int, uint and uintptr. It
+// returns false for any other type.
+func Builtin(Type string) (k Kind, bits int, ok bool) {
+    switch Type {
+    case "string":  return KindString, 0, true
+    case "bool":    return KindBool, 0, true
+    case "int":     return KindInt, 0, true
+    case "int8":    return KindInt, 8, true
+    case "int16":   return KindInt, 16, true
+    case "int32", "rune": return KindInt, 32, true
+    case "int64":   return KindInt, 64, true
+    case "uint", "uintptr": return KindUint, 0, true
+    case "uint8", "byte": return KindUint, 8, true
+    case "uint16":  return KindUint, 16, true
+    case "uint32":  return KindUint, 32, true
+    case "uint64":  return KindUint, 64, true
+    case "float32": return KindFloat, 32, true
+    case "float64": return KindFloat, 64, true
+    }
+    return 0, 0, false
+}
@@ -0,0 +1,63 @@
+package source
+
+import (
+    "go/token"
+    "go/types"
+    "strings"
+    "unicode"
+
+    "github.com/tawesoft/morph/internal"
+)
+
+// Receiver returns the name of the receiver, or other argument, of a
+// generated function for a value of the named type, e.g. "foo" for "Foo".
+//
+// The name is prefixed with "_" if it would otherwise be a keyword, shadow a
+// predeclared identifier or any package that generated code may import (see
+// [Imports]), or match any of the reserved names, such as the other
+// arguments of the generated function.
+func Receiver(typeName string, reserved ... string) string {
+    name := internal.Untitle(typeName)
+    clash := token.IsKeyword(name) || (types.Universe.Lookup(name) != nil)
+    if _, ok := packages[name]; ok { clash = true }
+    for _, r := range reserved {
+        if name == r { clash = true }
+    }
+    if clash { return "_" + name }
+    return name
+}
+
+// Words splits a Go field name into words, keeping initialisms together
+// e.g. "HTTPPort" to "HTTP", "Port". Join them to convert the name to
+// another case e.g. snake case with "_".
+func Words(s string) []string {
+    rs := []rune(s)
+    var words []string
+    start := 0
+    for i, r := range rs {
+        if unicode.IsUpper(r) && (i > 0) {
+            prev := rs[i-1]
+            nextLower := (i + 1 < len(rs)) && unicode.IsLower(rs[i+1])
+            if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+                words = append(words, string(rs[start:i]))
+                start = i
+            }
+        }
+    }
+    if start < len(rs) { words = append(words, string(rs[start:])) }
+    return words
+}
+
+// ValidJSONKey returns true if a tag name is accepted as a key by
+// encoding/json.
+func ValidJSONKey(s string) bool {
+    if s == "" { return false }
+    for _, c := range s {
+        switch {
+        case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+        case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+            return false
+        }
+    }
+    return true
+}
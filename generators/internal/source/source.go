@@ -0,0 +1,111 @@
+// Package source implements helpers shared by the generators.
+package source
+
+import (
+    "fmt"
+    "go/ast"
+    "go/parser"
+    "go/token"
+    "sort"
+    "strings"
+
+    "github.com/tawesoft/morph"
+)
+
+// Functions parses every function declared in the source code of a Go file
+// into a [morph.Function], in order. It panics on error, because src is
+// expected to be a constant in the calling package.
+func Functions(src string) []morph.Function {
+    fset := token.NewFileSet()
+    astf, err := parser.ParseFile(fset, "source.go", src, parser.ParseComments|parser.SkipObjectResolution)
+    if err != nil {
+        panic(fmt.Errorf("error parsing source: %w", err))
+    }
+
+    var result []morph.Function
+    for _, decl := range astf.Decls {
+        funcDecl, ok := decl.(*ast.FuncDecl)
+        if !ok || funcDecl.Body == nil { continue }
+
+        start := fset.Position(funcDecl.Pos()).Offset
+        if funcDecl.Doc != nil {
+            start = fset.Position(funcDecl.Doc.Pos()).Offset
+        }
+        end := fset.Position(funcDecl.Body.Lbrace).Offset
+        signature, err := morph.ParseFirstFunctionSignature("source.go", "package source\n\n" + src[start:end] + "{}")
+        if err != nil {
+            panic(fmt.Errorf("error parsing signature of %s: %w", funcDecl.Name, err))
+        }
+
+        lbrace := fset.Position(funcDecl.Body.Lbrace).Offset
+        rbrace := fset.Position(funcDecl.Body.Rbrace).Offset
+        result = append(result, morph.Function{
+            Signature: signature,
+            Body:      strings.TrimSpace(src[lbrace+1:rbrace]),
+        })
+    }
+    return result
+}
+
+// packages maps the package identifier of each standard library package
+// that generated code may use to its import path.
+var packages = map[string]string{
+    "base64":  "encoding/base64",
+    "binary":  "encoding/binary",
+    "bytes":   "bytes",
+    "csv":     "encoding/csv",
+    "driver":  "database/sql/driver",
+    "errors":  "errors",
+    "flag":    "flag",
+    "fmt":     "fmt",
+    "io":      "io",
+    "math":    "math",
+    "os":      "os",
+    "sort":    "sort",
+    "sql":     "database/sql",
+    "strconv": "strconv",
+    "strings": "strings",
+    "time":    "time",
+    "url":     "net/url",
+    "utf16":   "unicode/utf16",
+    "utf8":    "unicode/utf8",
+}
+
+// Imports returns the sorted import paths of the standard library packages
+// referenced in the signatures and bodies of the given functions, for
+// example "strconv" if a body contains "strconv.".
+//
+// This is a simple textual search, so it may return an import path for a
+// package that is only mentioned in a string literal or comment, or that is
+// shadowed by a local identifier.
+func Imports(functions ... morph.Function) []string {
+    seen := make(map[string]bool)
+    for _, f := range functions {
+        body := f.Signature.String() + "\n" + f.Body
+        for ident, path := range packages {
+            for i := strings.Index(body, ident + "."); i >= 0; {
+                if (i == 0) || !isIdentByte(body[i-1]) {
+                    seen[path] = true
+                    break
+                }
+                j := strings.Index(body[i+1:], ident + ".")
+                if j < 0 { break }
+                i += 1 + j
+            }
+        }
+    }
+
+    result := make([]string, 0, len(seen))
+    for path := range seen {
+        result = append(result, path)
+    }
+    sort.Strings(result)
+    return result
+}
+
+func isIdentByte(c byte) bool {
+    return (c == '_') || (c == '.') ||
+        ((c >= 'a') && (c <= 'z')) ||
+        ((c >= 'A') && (c <= 'Z')) ||
+        ((c >= '0') && (c <= '9'))
+}
@@ -0,0 +1,322 @@
+package jsoncodec
+
+import (
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+)
+
+// Helpers returns unexported helper functions, each with a name starting
+// with "json", that are called by the generated methods. They must be
+// generated exactly once in each package that uses the generated methods.
+//
+// Use [Imports] to find the import paths they require.
+func Helpers() []morph.Function {
+    return source.Functions(helpers)
+}
+
+const helpers = `package helpers
+
+// jsonAppendString appends s to b as a JSON string, escaped in the same way
+// as encoding/json.
+func jsonAppendString(b []byte, s string) []byte {
+    const hex = "0123456789abcdef"
+    b = append(b, '"')
+    start := 0
+    for i := 0; i < len(s); {
+        if c := s[i]; c < utf8.RuneSelf {
+            if (c >= 0x20) && (c != '"') && (c != '\\') && (c != '<') && (c != '>') && (c != '&') {
+                i++
+                continue
+            }
+            b = append(b, s[start:i]...)
+            switch c {
+            case '"', '\\': b = append(b, '\\', c)
+            case '\b':      b = append(b, '\\', 'b')
+            case '\f':      b = append(b, '\\', 'f')
+            case '\n':      b = append(b, '\\', 'n')
+            case '\r':      b = append(b, '\\', 'r')
+            case '\t':      b = append(b, '\\', 't')
+            default:        b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+            }
+            i++
+            start = i
+            continue
+        }
+        r, size := utf8.DecodeRuneInString(s[i:])
+        if (r == utf8.RuneError) && (size == 1) {
+            b = append(b, s[start:i]...)
+            b = utf8.AppendRune(b, utf8.RuneError)
+            i += size
+            start = i
+            continue
+        }
+        if (r == '\u2028') || (r == '\u2029') {
+            b = append(b, s[start:i]...)
+            b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xF])
+            i += size
+            start = i
+            continue
+        }
+        i += size
+    }
+    b = append(b, s[start:]...)
+    return append(b, '"')
+}
+
+// jsonAppendFloat appends f to b as a JSON number, formatted in the same way
+// as encoding/json for a float with the given bit size (32 or 64).
+func jsonAppendFloat(b []byte, f float64, bits int) ([]byte, error) {
+    if math.IsInf(f, 0) || math.IsNaN(f) {
+        return b, fmt.Errorf("json: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, bits))
+    }
+    format := byte('f')
+    if abs := math.Abs(f); abs != 0 {
+        if ((bits == 64) && ((abs < 1e-6) || (abs >= 1e21))) ||
+            ((bits == 32) && ((float32(abs) < 1e-6) || (float32(abs) >= 1e21))) {
+            format = 'e'
+        }
+    }
+    b = strconv.AppendFloat(b, f, format, -1, bits)
+    if format == 'e' {
+        // clean up e-09 to e-9
+        n := len(b)
+        if (n >= 4) && (b[n-4] == 'e') && (b[n-3] == '-') && (b[n-2] == '0') {
+            b[n-2] = b[n-1]
+            b = b[:n-1]
+        }
+    }
+    return b, nil
+}
+
+// jsonSpace returns the index of the first non-whitespace byte in data at or
+// after index i.
+func jsonSpace(data []byte, i int) int {
+    for i < len(data) {
+        switch data[i] {
+        case ' ', '\t', '\n', '\r': i++
+        default: return i
+        }
+    }
+    return i
+}
+
+// jsonSyntaxError returns an error for invalid JSON at index i of data.
+func jsonSyntaxError(data []byte, i int) error {
+    if i >= len(data) {
+        return fmt.Errorf("json: unexpected end of JSON input")
+    }
+    return fmt.Errorf("json: invalid character %q at offset %d", data[i], i)
+}
+
+// jsonTypeError returns an error for a JSON value that cannot be decoded
+// into a Go value of the given type.
+func jsonTypeError(value []byte, Type string) error {
+    kind := "number"
+    if len(value) > 0 {
+        switch value[0] {
+        case '"':      kind = "string"
+        case '{':      kind = "object"
+        case '[':      kind = "array"
+        case 't', 'f': kind = "bool"
+        case 'n':      kind = "null"
+        }
+    }
+    return fmt.Errorf("json: cannot unmarshal %s into Go value of type %s", kind, Type)
+}
+
+// jsonValue validates the JSON value starting at index i of data (after any
+// whitespace), and returns it, and the index of the following byte.
+func jsonValue(data []byte, i int) ([]byte, int, error) {
+    i = jsonSpace(data, i)
+    if i >= len(data) { return nil, i, jsonSyntaxError(data, i) }
+    start := i
+
+    digits := func() bool {
+        n := i
+        for (i < len(data)) && (data[i] >= '0') && (data[i] <= '9') { i++ }
+        return i > n
+    }
+    literal := func(s string) ([]byte, int, error) {
+        if (len(data) - i < len(s)) || (string(data[i:i+len(s)]) != s) {
+            return nil, i, jsonSyntaxError(data, i)
+        }
+        return data[i:i+len(s)], i + len(s), nil
+    }
+
+    switch c := data[i]; c {
+    case '{', '[':
+        end := byte('}')
+        if c == '[' { end = ']' }
+        i = jsonSpace(data, i+1)
+        if (i < len(data)) && (data[i] == end) { return data[start:i+1], i+1, nil }
+        for {
+            if c == '{' {
+                i = jsonSpace(data, i)
+                if (i >= len(data)) || (data[i] != '"') { return nil, i, jsonSyntaxError(data, i) }
+                var err error
+                _, i, err = jsonValue(data, i)
+                if err != nil { return nil, i, err }
+                i = jsonSpace(data, i)
+                if (i >= len(data)) || (data[i] != ':') { return nil, i, jsonSyntaxError(data, i) }
+                i++
+            }
+            var err error
+            _, i, err = jsonValue(data, i)
+            if err != nil { return nil, i, err }
+            i = jsonSpace(data, i)
+            if i >= len(data) { return nil, i, jsonSyntaxError(data, i) }
+            if data[i] == end { return data[start:i+1], i+1, nil }
+            if data[i] != ',' { return nil, i, jsonSyntaxError(data, i) }
+            i++
+        }
+    case '"':
+        for i++; i < len(data); {
+            switch {
+            case data[i] == '\\': i += 2
+            case data[i] == '"':  return data[start:i+1], i+1, nil
+            case data[i] < 0x20:  return nil, i, jsonSyntaxError(data, i)
+            default: i++
+            }
+        }
+        return nil, len(data), jsonSyntaxError(data, len(data))
+    case 't': return literal("true")
+    case 'f': return literal("false")
+    case 'n': return literal("null")
+    default:
+        if data[i] == '-' { i++ }
+        if (i < len(data)) && (data[i] == '0') {
+            i++
+        } else if (i >= len(data)) || (data[i] < '1') || (data[i] > '9') || !digits() {
+            return nil, i, jsonSyntaxError(data, i)
+        }
+        if (i < len(data)) && (data[i] == '.') {
+            i++
+            if !digits() { return nil, i, jsonSyntaxError(data, i) }
+        }
+        if (i < len(data)) && ((data[i] == 'e') || (data[i] == 'E')) {
+            i++
+            if (i < len(data)) && ((data[i] == '+') || (data[i] == '-')) { i++ }
+            if !digits() { return nil, i, jsonSyntaxError(data, i) }
+        }
+        return data[start:i], i, nil
+    }
+}
+
+// jsonHex4 decodes four hexadecimal digits at index i of data.
+func jsonHex4(data []byte, i int) (rune, bool) {
+    if len(data) - i < 4 { return 0, false }
+    var r rune
+    for _, c := range data[i:i+4] {
+        switch {
+        case (c >= '0') && (c <= '9'): c = c - '0'
+        case (c >= 'a') && (c <= 'f'): c = c - 'a' + 10
+        case (c >= 'A') && (c <= 'F'): c = c - 'A' + 10
+        default: return 0, false
+        }
+        r = (r * 16) + rune(c)
+    }
+    return r, true
+}
+
+// jsonUnquote decodes a JSON string value, previously validated by
+// jsonValue, in the same way as encoding/json.
+func jsonUnquote(value []byte) (string, error) {
+    if (len(value) < 2) || (value[0] != '"') {
+        return "", jsonTypeError(value, "string")
+    }
+    value = value[1:len(value)-1]
+
+    simple := true
+    for _, c := range value {
+        if (c == '\\') || (c >= utf8.RuneSelf) { simple = false; break }
+    }
+    if simple { return string(value), nil }
+
+    b := make([]byte, 0, len(value))
+    for i := 0; i < len(value); {
+        c := value[i]
+        if c >= utf8.RuneSelf {
+            r, size := utf8.DecodeRune(value[i:])
+            if (r == utf8.RuneError) && (size == 1) {
+                b = utf8.AppendRune(b, utf8.RuneError)
+            } else {
+                b = append(b, value[i:i+size]...)
+            }
+            i += size
+            continue
+        }
+        if c != '\\' {
+            b = append(b, c)
+            i++
+            continue
+        }
+        if i + 1 >= len(value) { return "", fmt.Errorf("json: invalid escape in string") }
+        switch value[i+1] {
+        case '"', '\\', '/': b = append(b, value[i+1])
+        case 'b': b = append(b, '\b')
+        case 'f': b = append(b, '\f')
+        case 'n': b = append(b, '\n')
+        case 'r': b = append(b, '\r')
+        case 't': b = append(b, '\t')
+        case 'u':
+            r, ok := jsonHex4(value, i+2)
+            if !ok { return "", fmt.Errorf("json: invalid escape in string") }
+            i += 6
+            if utf16.IsSurrogate(r) {
+                r2, ok2 := rune(0), false
+                if (len(value) - i >= 6) && (value[i] == '\\') && (value[i+1] == 'u') {
+                    r2, ok2 = jsonHex4(value, i+2)
+                }
+                if dec := utf16.DecodeRune(r, r2); ok2 && (dec != utf8.RuneError) {
+                    r = dec
+                    i += 6
+                } else {
+                    r = utf8.RuneError
+                }
+            }
+            b = utf8.AppendRune(b, r)
+            continue
+        default:
+            return "", fmt.Errorf("json: invalid escape in string")
+        }
+        i += 2
+    }
+    return string(b), nil
+}
+
+// jsonEachMember calls fn for each member of a JSON object value, previously
+// validated by jsonValue, stopping at the first error.
+func jsonEachMember(value []byte, Type string, fn func(key string, value []byte) error) error {
+    if (len(value) == 0) || (value[0] != '{') { return jsonTypeError(value, Type) }
+    i := jsonSpace(value, 1)
+    if value[i] == '}' { return nil }
+    for {
+        k, i2, err := jsonValue(value, i)
+        if err != nil { return err }
+        key, err := jsonUnquote(k)
+        if err != nil { return err }
+        v, i3, err := jsonValue(value, jsonSpace(value, i2) + 1)
+        if err != nil { return err }
+        if err := fn(key, v); err != nil { return err }
+        i = jsonSpace(value, i3)
+        if value[i] == '}' { return nil }
+        i++
+    }
+}
+
+// jsonEachElement calls fn for each element of a JSON array value,
+// previously validated by jsonValue, stopping at the first error.
+func jsonEachElement(value []byte, Type string, fn func(value []byte) error) error {
+    if (len(value) == 0) || (value[0] != '[') { return jsonTypeError(value, Type) }
+    i := jsonSpace(value, 1)
+    if value[i] == ']' { return nil }
+    for {
+        v, i2, err := jsonValue(value, i)
+        if err != nil { return err }
+        if err := fn(v); err != nil { return err }
+        i = jsonSpace(value, i2)
+        if value[i] == ']' { return nil }
+        i++
+    }
+}
+`
@@ -0,0 +1,484 @@
+// Package jsoncodec generates methods that encode and decode structs as JSON,
+// without using reflection at runtime.
+//
+// For a struct Foo, [Functions] generates:
+//
+//     func (foo Foo) AppendJSON(b []byte) ([]byte, error)
+//     func (foo Foo) MarshalJSON() ([]byte, error)
+//     func (foo *Foo) UnmarshalJSON(data []byte) error
+//
+// The generated methods call unexported helper functions, returned by
+// [Helpers], which must be generated once in the same package.
+//
+// The output is byte-compatible with encoding/json for the same struct tags,
+// and the input is decoded in the same way, except as noted on [Functions].
+package jsoncodec
+
+import (
+    "encoding/json"
+    "fmt"
+    "strconv"
+    "strings"
+    "unicode"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+    "github.com/tawesoft/morph/tag"
+)
+
+// Options configure the generated methods.
+type Options struct {
+    // DisallowUnknownFields, if true, causes UnmarshalJSON to return an
+    // error when the input contains an object key that does not match any
+    // field, like [encoding/json.Decoder.DisallowUnknownFields].
+    DisallowUnknownFields bool
+
+    // Underlying maps the name of a named type, such as "Celsius", to its
+    // underlying type, such as "float64", so that fields of that type can be
+    // encoded and decoded without requiring the type to implement
+    // json.Marshaler and json.Unmarshaler.
+    Underlying map[string]string
+}
+
+// Imports returns the sorted import paths required by the given generated
+// functions, for example from [Functions] and [Helpers].
+func Imports(functions ... morph.Function) []string {
+    return source.Imports(functions...)
+}
+
+// Functions generates the methods, described in the package documentation,
+// for the given struct.
+//
+// Each exported field is encoded as an object member, in order, using the
+// key and options in the field's "json" tag, if any, as documented by
+// [encoding/json.Marshal]. Keys are otherwise the field name. A field with
+// the tag `json:"-"` is skipped. The "omitempty" option is supported. It is
+// an error if a field has the "string" or "omitzero" option.
+//
+// The following field types are supported, and may be nested:
+//
+//   - string, bool, and each builtin integer and floating point type;
+//   - []byte, as a base64 string;
+//   - pointers, slices, and maps with string keys of a supported type;
+//   - any other type that implements json.Marshaler and json.Unmarshaler,
+//     including other structs with generated methods, and time.Time.
+//
+// Embedded fields, arrays, interfaces, channels, and functions are not
+// supported. The output of a nested MarshalJSON method is copied as-is,
+// without the compaction or validation performed by encoding/json.
+//
+// As in encoding/json, UnmarshalJSON matches object keys to fields exactly
+// or, failing that, case-insensitively. It leaves fields that are missing
+// from the input, or that are given as null, unchanged, except that a null
+// pointer, slice, or map field is set to nil.
+func Functions(s morph.Struct, options Options) ([]morph.Function, error) {
+    esc := func(err error) ([]morph.Function, error) {
+        return nil, fmt.Errorf(
+            "error generating JSON methods for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    g := &generator{
+        s:        s,
+        options:  options,
+        receiver: source.Receiver(s.Name, "b", "data"),
+    }
+
+    seen := make(map[string]string)
+    for _, f := range s.Fields {
+        m, ok, err := g.member(f)
+        if err != nil { return esc(err) }
+        if !ok { continue }
+        if other, exists := seen[m.key]; exists {
+            return esc(fmt.Errorf("fields %q and %q have the same key %q", other, f.Name, m.key))
+        }
+        seen[m.key] = f.Name
+        g.members = append(g.members, m)
+    }
+
+    appendJSON, err := g.appendJSON()
+    if err != nil { return esc(err) }
+    unmarshalJSON, err := g.unmarshalJSON()
+    if err != nil { return esc(err) }
+
+    functions := []morph.Function{appendJSON, g.marshalJSON(), unmarshalJSON}
+    for i := range functions {
+        functions[i].Body = strings.TrimSpace(functions[i].Body)
+    }
+    return functions, nil
+}
+
+// member is a field encoded as a JSON object member.
+type member struct {
+    field     morph.Field
+    key       string
+    omitEmpty bool
+}
+
+type generator struct {
+    s        morph.Struct
+    options  Options
+    receiver string
+    members  []member
+    n        int // counter for unique local variable names
+}
+
+// next returns a unique suffix for local variable names.
+func (g *generator) next() int {
+    g.n++
+    return g.n
+}
+
+// member returns the member for a field, or false if the field is skipped.
+func (g *generator) member(f morph.Field) (member, bool, error) {
+    if (f.Name == "") || (f.Name == f.Type) || strings.Contains(f.Name, ".") {
+        return member{}, false, fmt.Errorf("embedded field %q is not supported", f.Type)
+    }
+    if r := []rune(f.Name)[0]; !unicode.IsUpper(r) { return member{}, false, nil }
+
+    m := member{field: f, key: f.Name}
+    value, ok := tag.Lookup(f.Tag, "json")
+    if !ok { return m, true, nil }
+    if value == "-" { return member{}, false, nil }
+
+    name, opts, _ := strings.Cut(value, ",")
+    if source.ValidJSONKey(name) { m.key = name }
+    for opts != "" {
+        var opt string
+        opt, opts, _ = strings.Cut(opts, ",")
+        switch opt {
+        case "omitempty":
+            m.omitEmpty = true
+        case "string", "omitzero":
+            return member{}, false, fmt.Errorf("unsupported option %q on field %q", opt, f.Name)
+        }
+    }
+    return m, true, nil
+}
+
+type kind int
+
+const (
+    kindOther kind = iota // implements json.Marshaler and json.Unmarshaler
+    kindString
+    kindBool
+    kindInt
+    kindUint
+    kindFloat
+    kindBytes
+    kindPointer
+    kindSlice
+    kindMap
+)
+
+// kind returns the kind of a type, and its bit size for numeric types, or
+// its element type for pointers, slices and maps.
+func (g *generator) kind(Type string) (k kind, bits int, elem string, err error) {
+    if underlying, ok := g.options.Underlying[Type]; ok { Type = underlying }
+    switch Type {
+    case "string":  return kindString, 0, "", nil
+    case "bool":    return kindBool, 0, "", nil
+    case "int":     return kindInt, 0, "", nil
+    case "int8":    return kindInt, 8, "", nil
+    case "int16":   return kindInt, 16, "", nil
+    case "int32", "rune": return kindInt, 32, "", nil
+    case "int64":   return kindInt, 64, "", nil
+    case "uint", "uintptr": return kindUint, 0, "", nil
+    case "uint8", "byte": return kindUint, 8, "", nil
+    case "uint16":  return kindUint, 16, "", nil
+    case "uint32":  return kindUint, 32, "", nil
+    case "uint64":  return kindUint, 64, "", nil
+    case "float32": return kindFloat, 32, "", nil
+    case "float64": return kindFloat, 64, "", nil
+    case "[]byte", "[]uint8": return kindBytes, 0, "", nil
+    }
+
+    switch {
+    case strings.HasPrefix(Type, "*"):
+        return kindPointer, 0, Type[1:], nil
+    case strings.HasPrefix(Type, "[]"):
+        return kindSlice, 0, Type[2:], nil
+    case strings.HasPrefix(Type, "map[string]"):
+        return kindMap, 0, strings.TrimPrefix(Type, "map[string]"), nil
+    case strings.HasPrefix(Type, "["),
+        strings.HasPrefix(Type, "map["),
+        strings.HasPrefix(Type, "chan "),
+        strings.HasPrefix(Type, "<-chan "),
+        strings.HasPrefix(Type, "func("),
+        strings.HasPrefix(Type, "interface{"),
+        strings.HasPrefix(Type, "struct{"),
+        Type == "any", Type == "error":
+        return 0, 0, "", fmt.Errorf("unsupported type %q", Type)
+    }
+    return kindOther, 0, "", nil
+}
+
+// literal returns the Go string literal that appends a member's key, with a
+// leading comma, in the same form as encoding/json.
+func (m member) literal() string {
+    key, _ := json.Marshal(m.key)
+    return strconv.Quote("," + string(key) + ":")
+}
+
+func (g *generator) appendJSON() (morph.Function, error) {
+    var sb strings.Builder
+    sb.WriteString("_start := len(b)\n")
+    for _, m := range g.members {
+        x := g.receiver + "." + m.field.Name
+        fmt.Fprintf(&sb, "\n// %s\n", m.field.Name)
+        if m.omitEmpty {
+            cond, err := g.nonEmpty(x, m.field.Type)
+            if err != nil { return morph.Function{}, err }
+            if cond != "" {
+                fmt.Fprintf(&sb, "if %s {\n", cond)
+            } else {
+                m.omitEmpty = false
+            }
+        }
+        fmt.Fprintf(&sb, "b = append(b, %s...)\n", m.literal())
+        if err := g.encode(&sb, x, m.field.Type); err != nil {
+            return morph.Function{}, fmt.Errorf("field %q: %w", m.field.Name, err)
+        }
+        if m.omitEmpty { sb.WriteString("}\n") }
+    }
+    sb.WriteString("\nif len(b) == _start {\n")
+    sb.WriteString("b = append(b, '{')\n")
+    sb.WriteString("} else {\n")
+    sb.WriteString("b[_start] = '{'\n")
+    sb.WriteString("}\n")
+    sb.WriteString("return append(b, '}'), nil\n")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("AppendJSON appends the JSON encoding of a [%s] value to b, "+
+                "and returns the extended buffer.", g.s.Name),
+            Name:      "AppendJSON",
+            Arguments: []morph.Argument{{Name: "b", Type: "[]byte"}},
+            Returns:   []morph.Argument{{Type: "[]byte"}, {Type: "error"}},
+            Receiver:  morph.Argument{Name: g.receiver, Type: g.s.Type()},
+        },
+        Body: sb.String(),
+    }, nil
+}
+
+func (g *generator) marshalJSON() morph.Function {
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment:  fmt.Sprintf("MarshalJSON returns the JSON encoding of a [%s] value.", g.s.Name),
+            Name:     "MarshalJSON",
+            Returns:  []morph.Argument{{Type: "[]byte"}, {Type: "error"}},
+            Receiver: morph.Argument{Name: g.receiver, Type: g.s.Type()},
+        },
+        Body: fmt.Sprintf("return %s.AppendJSON(nil)", g.receiver),
+    }
+}
+
+// nonEmpty returns a condition that is true if x is not empty, as defined by
+// the "omitempty" option of encoding/json, or the empty string if x is never
+// empty.
+func (g *generator) nonEmpty(x string, Type string) (string, error) {
+    k, _, _, err := g.kind(Type)
+    if err != nil { return "", err }
+    switch k {
+    case kindString:
+        return x + ` != ""`, nil
+    case kindBool:
+        return x, nil
+    case kindInt, kindUint, kindFloat:
+        return x + " != 0", nil
+    case kindBytes, kindSlice, kindMap:
+        return "len(" + x + ") != 0", nil
+    case kindPointer:
+        return x + " != nil", nil
+    default:
+        return "", nil
+    }
+}
+
+// encode writes code that appends the JSON encoding of x, of the given type,
+// to b.
+func (g *generator) encode(sb *strings.Builder, x string, Type string) error {
+    k, bits, elem, err := g.kind(Type)
+    if err != nil { return err }
+
+    switch k {
+    case kindString:
+        fmt.Fprintf(sb, "b = jsonAppendString(b, string(%s))\n", x)
+    case kindBool:
+        fmt.Fprintf(sb, "b = strconv.AppendBool(b, bool(%s))\n", x)
+    case kindInt:
+        fmt.Fprintf(sb, "b = strconv.AppendInt(b, int64(%s), 10)\n", x)
+    case kindUint:
+        fmt.Fprintf(sb, "b = strconv.AppendUint(b, uint64(%s), 10)\n", x)
+    case kindFloat:
+        n := g.next()
+        fmt.Fprintf(sb, "if _b%d, _err := jsonAppendFloat(b, float64(%s), %d); _err != nil {\n", n, x, bits)
+        sb.WriteString("return nil, _err\n")
+        fmt.Fprintf(sb, "} else {\nb = _b%d\n}\n", n)
+    case kindBytes:
+        fmt.Fprintf(sb, "if %s == nil {\nb = append(b, \"null\"...)\n} else {\n", x)
+        fmt.Fprintf(sb, "b = jsonAppendString(b, base64.StdEncoding.EncodeToString([]byte(%s)))\n}\n", x)
+    case kindPointer:
+        fmt.Fprintf(sb, "if %s == nil {\nb = append(b, \"null\"...)\n} else {\n", x)
+        if err := g.encode(sb, "(*" + x + ")", elem); err != nil { return err }
+        sb.WriteString("}\n")
+    case kindSlice:
+        n := g.next()
+        fmt.Fprintf(sb, "if %s == nil {\nb = append(b, \"null\"...)\n} else {\n", x)
+        sb.WriteString("b = append(b, '[')\n")
+        fmt.Fprintf(sb, "for _i%d, _x%d := range %s {\n", n, n, x)
+        fmt.Fprintf(sb, "if _i%d > 0 {\nb = append(b, ',')\n}\n", n)
+        if err := g.encode(sb, fmt.Sprintf("_x%d", n), elem); err != nil { return err }
+        sb.WriteString("}\n")
+        sb.WriteString("b = append(b, ']')\n")
+        sb.WriteString("}\n")
+    case kindMap:
+        n := g.next()
+        fmt.Fprintf(sb, "if %s == nil {\nb = append(b, \"null\"...)\n} else {\n", x)
+        fmt.Fprintf(sb, "_keys%d := make([]string, 0, len(%s))\n", n, x)
+        fmt.Fprintf(sb, "for _k%d := range %s {\n_keys%d = append(_keys%d, _k%d)\n}\n", n, x, n, n, n)
+        fmt.Fprintf(sb, "sort.Strings(_keys%d)\n", n)
+        sb.WriteString("b = append(b, '{')\n")
+        fmt.Fprintf(sb, "for _i%d, _k%d := range _keys%d {\n", n, n, n)
+        fmt.Fprintf(sb, "if _i%d > 0 {\nb = append(b, ',')\n}\n", n)
+        fmt.Fprintf(sb, "b = jsonAppendString(b, _k%d)\n", n)
+        sb.WriteString("b = append(b, ':')\n")
+        if err := g.encode(sb, fmt.Sprintf("%s[_k%d]", x, n), elem); err != nil { return err }
+        sb.WriteString("}\n")
+        sb.WriteString("b = append(b, '}')\n")
+        sb.WriteString("}\n")
+    default:
+        n := g.next()
+        fmt.Fprintf(sb, "if _v%d, _err := %s.MarshalJSON(); _err != nil {\n", n, x)
+        sb.WriteString("return nil, _err\n")
+        fmt.Fprintf(sb, "} else {\nb = append(b, _v%d...)\n}\n", n)
+    }
+    return nil
+}
+
+func (g *generator) unmarshalJSON() (morph.Function, error) {
+    var sb strings.Builder
+    sb.WriteString("_value, _i, _err := jsonValue(data, 0)\n")
+    sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+    sb.WriteString("if _i = jsonSpace(data, _i); _i < len(data) {\nreturn jsonSyntaxError(data, _i)\n}\n")
+    sb.WriteString("if string(_value) == \"null\" {\nreturn nil\n}\n\n")
+
+    fmt.Fprintf(&sb, "return jsonEachMember(_value, %q, func(_key string, _value []byte) error {\n", g.s.Name)
+    sb.WriteString("_field := -1\n")
+    sb.WriteString("switch _key {\n")
+    for i, m := range g.members {
+        fmt.Fprintf(&sb, "case %s:\n_field = %d\n", strconv.Quote(m.key), i)
+    }
+    sb.WriteString("}\n")
+    if len(g.members) > 0 {
+        sb.WriteString("if _field < 0 {\nswitch {\n")
+        for i, m := range g.members {
+            fmt.Fprintf(&sb, "case strings.EqualFold(_key, %s):\n_field = %d\n", strconv.Quote(m.key), i)
+        }
+        sb.WriteString("}\n}\n")
+    }
+    sb.WriteString("\nswitch _field {\n")
+    for i, m := range g.members {
+        fmt.Fprintf(&sb, "case %d: // %s\n", i, m.field.Name)
+        if err := g.decode(&sb, g.receiver + "." + m.field.Name, m.field.Type, "_value"); err != nil {
+            return morph.Function{}, fmt.Errorf("field %q: %w", m.field.Name, err)
+        }
+    }
+    if g.options.DisallowUnknownFields {
+        sb.WriteString("default:\nreturn fmt.Errorf(\"json: unknown field %q\", _key)\n")
+    }
+    sb.WriteString("}\n")
+    sb.WriteString("return nil\n")
+    sb.WriteString("})\n")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment:   fmt.Sprintf("UnmarshalJSON decodes a [%s] value from its JSON encoding in data.", g.s.Name),
+            Name:      "UnmarshalJSON",
+            Arguments: []morph.Argument{{Name: "data", Type: "[]byte"}},
+            Returns:   []morph.Argument{{Type: "error"}},
+            Receiver:  morph.Argument{Name: g.receiver, Type: "*" + g.s.Type()},
+        },
+        Body: sb.String(),
+    }, nil
+}
+
+// decode writes code that decodes the JSON value in the []byte variable
+// named value into the addressable target of the given type, returning any
+// error.
+func (g *generator) decode(sb *strings.Builder, target string, Type string, value string) error {
+    k, bits, elem, err := g.kind(Type)
+    if err != nil { return err }
+    null := fmt.Sprintf("string(%s) == \"null\"", value)
+
+    switch k {
+    case kindString:
+        n := g.next()
+        fmt.Fprintf(sb, "if !(%s) {\n", null)
+        fmt.Fprintf(sb, "_s%d, _err := jsonUnquote(%s)\n", n, value)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+        fmt.Fprintf(sb, "%s = %s(_s%d)\n", target, Type, n)
+        sb.WriteString("}\n")
+    case kindBool:
+        fmt.Fprintf(sb, "switch string(%s) {\n", value)
+        fmt.Fprintf(sb, "case \"true\":\n%s = %s(true)\n", target, Type)
+        fmt.Fprintf(sb, "case \"false\":\n%s = %s(false)\n", target, Type)
+        sb.WriteString("case \"null\":\n")
+        fmt.Fprintf(sb, "default:\nreturn jsonTypeError(%s, %q)\n", value, Type)
+        sb.WriteString("}\n")
+    case kindInt, kindUint, kindFloat:
+        n := g.next()
+        parse := map[kind]string{
+            kindInt:   "strconv.ParseInt(string(%s), 10, %d)",
+            kindUint:  "strconv.ParseUint(string(%s), 10, %d)",
+            kindFloat: "strconv.ParseFloat(string(%s), %d)",
+        }[k]
+        fmt.Fprintf(sb, "if !(%s) {\n", null)
+        fmt.Fprintf(sb, "_n%d, _err := " + parse + "\n", n, value, bits)
+        fmt.Fprintf(sb, "if _err != nil {\nreturn jsonTypeError(%s, %q)\n}\n", value, Type)
+        fmt.Fprintf(sb, "%s = %s(_n%d)\n", target, Type, n)
+        sb.WriteString("}\n")
+    case kindBytes:
+        n := g.next()
+        fmt.Fprintf(sb, "if %s {\n%s = nil\n} else {\n", null, target)
+        fmt.Fprintf(sb, "_s%d, _err := jsonUnquote(%s)\n", n, value)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+        fmt.Fprintf(sb, "_b%d, _err := base64.StdEncoding.DecodeString(_s%d)\n", n, n)
+        sb.WriteString("if _err != nil {\nreturn _err\n}\n")
+        fmt.Fprintf(sb, "%s = %s(_b%d)\n", target, Type, n)
+        sb.WriteString("}\n")
+    case kindPointer:
+        fmt.Fprintf(sb, "if %s {\n%s = nil\n} else {\n", null, target)
+        fmt.Fprintf(sb, "if %s == nil {\n%s = new(%s)\n}\n", target, target, elem)
+        if err := g.decode(sb, "(*" + target + ")", elem, value); err != nil { return err }
+        sb.WriteString("}\n")
+    case kindSlice:
+        n := g.next()
+        fmt.Fprintf(sb, "if %s {\n%s = nil\n} else {\n", null, target)
+        fmt.Fprintf(sb, "_s%d := %s[:0]\n", n, target)
+        fmt.Fprintf(sb, "if _err := jsonEachElement(%s, %q, func(_v%d []byte) error {\n", value, Type, n)
+        fmt.Fprintf(sb, "var _x%d %s\n", n, elem)
+        if err := g.decode(sb, fmt.Sprintf("_x%d", n), elem, fmt.Sprintf("_v%d", n)); err != nil { return err }
+        fmt.Fprintf(sb, "_s%d = append(_s%d, _x%d)\n", n, n, n)
+        sb.WriteString("return nil\n")
+        sb.WriteString("}); _err != nil {\nreturn _err\n}\n")
+        fmt.Fprintf(sb, "if _s%d == nil {\n_s%d = %s{}\n}\n", n, n, Type)
+        fmt.Fprintf(sb, "%s = _s%d\n", target, n)
+        sb.WriteString("}\n")
+    case kindMap:
+        n := g.next()
+        fmt.Fprintf(sb, "if %s {\n%s = nil\n} else {\n", null, target)
+        fmt.Fprintf(sb, "if %s == nil {\n%s = make(%s)\n}\n", target, target, Type)
+        fmt.Fprintf(sb, "if _err := jsonEachMember(%s, %q, func(_k%d string, _v%d []byte) error {\n", value, Type, n, n)
+        fmt.Fprintf(sb, "var _x%d %s\n", n, elem)
+        if err := g.decode(sb, fmt.Sprintf("_x%d", n), elem, fmt.Sprintf("_v%d", n)); err != nil { return err }
+        fmt.Fprintf(sb, "%s[_k%d] = _x%d\n", target, n, n)
+        sb.WriteString("return nil\n")
+        sb.WriteString("}); _err != nil {\nreturn _err\n}\n")
+        sb.WriteString("}\n")
+    default:
+        fmt.Fprintf(sb, "if _err := %s.UnmarshalJSON(%s); _err != nil {\nreturn _err\n}\n", target, value)
+    }
+    return nil
+}
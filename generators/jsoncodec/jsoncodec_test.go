@@ -0,0 +1,249 @@
+package jsoncodec_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/jsoncodec"
+    "github.com/tawesoft/morph/internal"
+)
+
+const source = `
+package example
+
+type Celsius float64
+
+type Address struct {
+    Street string ` + "`json:\"street\"`" + `
+    City   string ` + "`json:\"city,omitempty\"`" + `
+}
+
+type Person struct {
+    Name      string
+    Nickname  *string            ` + "`json:\"nick,omitempty\"`" + `
+    Age       int8               ` + "`json:\"age\"`" + `
+    Big       uint64
+    Ratio     float64
+    Small     float32            ` + "`json:\",omitempty\"`" + `
+    Temp      Celsius
+    Admin     bool               ` + "`json:\"admin,omitempty\"`" + `
+    Tags      []string
+    Scores    map[string][]int   ` + "`json:\"scores\"`" + `
+    Data      []byte
+    Born      time.Time
+    Home      Address
+    Previous  []*Address         ` + "`json:\"previous,omitempty\"`" + `
+    Secret    string             ` + "`json:\"-\"`" + `
+    Dash      string             ` + "`json:\"-,\"`" + `
+    Odd       string             ` + "`json:\"odd-key.x\"`" + `
+    private   int
+}
+`
+
+func TestFunctions(t *testing.T) {
+    address := internal.Must(morph.ParseStruct("test.go", source, "Address"))
+    person := internal.Must(morph.ParseStruct("test.go", source, "Person"))
+
+    options := jsoncodec.Options{Underlying: map[string]string{"Celsius": "float64"}}
+    var functions []morph.Function
+    functions = append(functions, internal.Must(jsoncodec.Functions(address, options))...)
+    functions = append(functions, internal.Must(jsoncodec.Functions(person, options))...)
+    functions = append(functions, jsoncodec.Helpers()...)
+
+    imports := jsoncodec.Imports(functions...)
+    imports = append(imports, "encoding/json", "reflect")
+
+    var sb strings.Builder
+    sb.WriteString("package main\n\nimport (\n")
+    for _, i := range imports {
+        if i == "fmt" || i == "time" { continue }
+        fmt.Fprintf(&sb, "%q\n", i)
+    }
+    sb.WriteString("\"fmt\"\n\"time\"\n)\n\n")
+    sb.WriteString("type Celsius float64\n\n")
+    sb.WriteString(address.String() + "\n\n")
+    sb.WriteString(person.String() + "\n\n")
+    sb.WriteString("// reference has the same fields as Person, but not its methods.\n")
+    sb.WriteString("type reference Person\n\n")
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(`
+func main() {
+    nick := "<Bob> & \u2028"
+    people := []Person{
+        {},
+        {
+            Name:     "Bob \"B\" \\ \x01\b\f\n\r\t \xff héllo",
+            Nickname: &nick,
+            Age:      -12,
+            Big:      18446744073709551615,
+            Ratio:    1e21,
+            Small:    0.1,
+            Temp:     -40.5,
+            Admin:    true,
+            Tags:     []string{},
+            Scores:   map[string][]int{"b": {1, 2}, "a": nil},
+            Data:     []byte("hello"),
+            Born:     time.Date(2000, 1, 2, 3, 4, 5, 6, time.UTC),
+            Home:     Address{Street: "High St"},
+            Previous: []*Address{nil, {Street: "Low St", City: "X"}},
+            Secret:   "secret",
+            Dash:     "dash",
+            Odd:      "odd",
+            private:  1,
+        },
+        {Ratio: 1e-7, Small: 1e-7, Tags: []string{"x"}},
+        {Ratio: 123456789.125, Small: 3.4e38},
+    }
+
+    for i, p := range people {
+        got, err := p.MarshalJSON()
+        expected, err2 := json.Marshal(reference(p))
+        if (err != nil) || (err2 != nil) || (string(got) != string(expected)) {
+            fmt.Printf("marshal %d: got %s (%v), expected %s (%v)\n", i, got, err, expected, err2)
+        }
+
+        inputs := []string{
+            string(expected),
+            string(got),
+        }
+        for _, input := range inputs {
+            unmarshal(input)
+        }
+    }
+
+    for _, input := range []string{
+        "null",
+        "  {}  ",
+        "{\"NAME\": \"upper\", \"name\": \"lower\", \"AGE\": 3, \"unknown\": [1, {\"x\": null}]}",
+        "{\"Name\": null, \"age\": null, \"Tags\": null, \"scores\": null, \"nick\": null, \"Admin\": null}",
+        "{\"Name\": \"\\ud83d\\ude00 \\u00e9 \\ud83d x \\/ \\\"\"}",
+        "{\"Name\": \"\xff\"}",
+        "{\"Tags\": [\"a\", \"b\"], \"scores\": {\"x\": [1, 2, 3]}, \"Data\": \"aGk=\"}",
+        "{\"Ratio\": -1.5e-3, \"Big\": 1}",
+        "{\"age\": 300}",
+        "{\"age\": 1.5}",
+        "{\"age\": \"1\"}",
+        "{\"Name\": 1}",
+        "{\"Tags\": {}}",
+        "{\"Name\": \"x\",}",
+        "{\"Name\": \"x\"",
+        "{\"Name\": \"x\"} x",
+        "{\"Name\" \"x\"}",
+        "[]",
+        "{\"Big\": -1}",
+        "{\"Ratio\": 01}",
+        "{\"Ratio\": 1.}",
+        "{\"Admin\": tru}",
+        "{\"Data\": \"!!\"}",
+        "{\"Name\": \"\\q\"}",
+        "{\"Born\": \"2000-01-02T03:04:05Z\", \"Home\": {\"street\": \"S\", \"CITY\": \"C\"}}",
+    } {
+        unmarshal(input)
+    }
+    fmt.Println("done")
+}
+
+func unmarshal(input string) {
+    start := Person{Name: "start", Tags: []string{"start"}}
+    var got, expected Person = start, start
+    err := got.UnmarshalJSON([]byte(input))
+    err2 := json.Unmarshal([]byte(input), (*reference)(&expected))
+    if (err == nil) != (err2 == nil) {
+        fmt.Printf("unmarshal %s: got error %v, expected %v\n", input, err, err2)
+    } else if (err == nil) && !reflect.DeepEqual(got, expected) {
+        fmt.Printf("unmarshal %s: got %+v, expected %+v\n", input, got, expected)
+    }
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        if stdout != "done\n" {
+            return fmt.Errorf("got %q", stdout)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_disallowUnknownFields(t *testing.T) {
+    address := internal.Must(morph.ParseStruct("test.go", source, "Address"))
+    functions := internal.Must(jsoncodec.Functions(address, jsoncodec.Options{DisallowUnknownFields: true}))
+    functions = append(functions, jsoncodec.Helpers()...)
+
+    var sb strings.Builder
+    sb.WriteString("package main\n\nimport (\n")
+    for _, i := range jsoncodec.Imports(functions...) {
+        fmt.Fprintf(&sb, "%q\n", i)
+    }
+    sb.WriteString(")\n\n")
+    sb.WriteString(address.String() + "\n\n")
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(`
+func main() {
+    var a Address
+    fmt.Println(a.UnmarshalJSON([]byte("{\"Street\": \"x\", \"town\": \"y\"}")))
+    fmt.Println(a.UnmarshalJSON([]byte("{\"Street\": \"x\"}")), a.Street)
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        expected := "json: unknown field \"town\"\n<nil> x\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_receiverName(t *testing.T) {
+    // receivers that would clash with an argument or an imported package
+    data := morph.Struct{Name: "Data", Fields: []morph.Field{{Name: "X", Type: "int"}}}
+    strconv := morph.Struct{Name: "Strconv", Fields: []morph.Field{{Name: "X", Type: "int"}}}
+    var functions []morph.Function
+    functions = append(functions, internal.Must(jsoncodec.Functions(data, jsoncodec.Options{}))...)
+    functions = append(functions, internal.Must(jsoncodec.Functions(strconv, jsoncodec.Options{}))...)
+    functions = append(functions, jsoncodec.Helpers()...)
+
+    main := `
+func main() {
+    var d Data
+    fmt.Println(d.UnmarshalJSON([]byte("{\"X\": 2}")), d.X)
+    b, err := Data{X: 3}.MarshalJSON()
+    fmt.Println(string(b), err)
+
+    var s Strconv
+    fmt.Println(s.UnmarshalJSON([]byte("{\"X\": 4}")), s.X)
+    b, err = Strconv{X: 5}.MarshalJSON()
+    fmt.Println(string(b), err)
+}
+`
+    imports := append(jsoncodec.Imports(functions...), "fmt")
+    program := internal.TestProgram(imports, []fmt.Stringer{data, strconv}, functions, main)
+
+    internal.TestCompileAndRun(t, program, func(stdout string) error {
+        expected := "<nil> 2\n{\"X\":3} <nil>\n<nil> 4\n{\"X\":5} <nil>\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_errors(t *testing.T) {
+    for _, s := range []morph.Struct{
+        {Name: "A", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `json:",string"`}}},
+        {Name: "B", Fields: []morph.Field{{Name: "X", Type: "[4]int"}}},
+        {Name: "C", Fields: []morph.Field{{Name: "X", Type: "map[int]string"}}},
+        {Name: "D", Fields: []morph.Field{{Name: "X", Type: "int"}, {Name: "Y", Type: "int", Tag: `json:"X"`}}},
+        {Name: "E", Fields: []morph.Field{{Name: "Address", Type: "Address"}}},
+    } {
+        if _, err := jsoncodec.Functions(s, jsoncodec.Options{}); err == nil {
+            t.Errorf("expected error for struct %s", s.Name)
+        }
+    }
+}
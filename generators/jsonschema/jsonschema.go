@@ -0,0 +1,312 @@
+// Package jsonschema generates JSON Schema (draft 2020-12) documents that
+// describe the JSON encoding of structs by encoding/json.
+package jsonschema
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "sort"
+    "strings"
+    "unicode"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+    "github.com/tawesoft/morph/tag"
+)
+
+// Draft is the URI of the JSON Schema dialect used in the "$schema" keyword
+// of a generated document.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Options configure a generated schema.
+type Options struct {
+    // ID, if not empty, is the "$id" of the generated document.
+    ID string
+
+    // Structs are other structs that may be the types of fields, or the
+    // element types of fields, of the struct or of each other. Each struct
+    // that is referenced is described under "$defs".
+    Structs []morph.Struct
+
+    // Types maps the name of a type, such as "Celsius", to a schema that
+    // describes it, such as map[string]any{"type": "number"}, overriding the
+    // default schema for that type, if any. The keywords of each schema are
+    // encoded with encoding/json, in sorted order.
+    Types map[string]map[string]any
+
+    // Strict, if true, disallows object properties that do not match a
+    // field (with "additionalProperties": false).
+    Strict bool
+}
+
+// Schema returns a JSON Schema document, as indented JSON, that describes
+// the JSON encoding of a struct by encoding/json.
+//
+// Each exported field is a property, using the key and options in the
+// field's "json" tag, if any, as documented by [encoding/json.Marshal], or
+// otherwise the field name. A field with the tag `json:"-"` is skipped. A
+// field without the "omitempty" option is required. A field's comment, and
+// the struct's comment, is its "description".
+//
+// Go types are described as follows:
+//
+//   - bool as a boolean, and string as a string;
+//   - integers as an integer, with a minimum and maximum for the sized
+//     types up to 32 bits, and a minimum of zero for unsigned types;
+//   - floats as a number;
+//   - []byte as a base64-encoded string;
+//   - time.Time as a date-time string;
+//   - slices and arrays as an array of their elements;
+//   - maps as an object with properties of the element type;
+//   - pointers as their element type, or null;
+//   - any and interface{} as any value;
+//   - a struct in [Options].Structs as a reference to its definition under
+//     "$defs", or to the root schema for the struct itself.
+//
+// It is an error if a field has another type that is not in
+// [Options].Types. Embedded fields and generic structs are not supported.
+//
+// As nil slices and maps are encoded as null, use the "omitempty" option,
+// or make sure they are not nil, to match the generated schema.
+func Schema(s morph.Struct, options Options) ([]byte, error) {
+    esc := func(err error) ([]byte, error) {
+        return nil, fmt.Errorf(
+            "error generating JSON Schema for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    g := &generator{
+        root:    s.Name,
+        options: options,
+        structs: make(map[string]morph.Struct),
+        defs:    make(map[string]object),
+    }
+    for _, x := range options.Structs {
+        g.structs[x.Name] = x
+    }
+
+    root, err := g.object(s)
+    if err != nil { return esc(err) }
+
+    doc := object{
+        {"$schema", Draft},
+    }
+    if options.ID != "" { doc = append(doc, member{"$id", options.ID}) }
+    doc = append(doc, member{"title", s.Name})
+    doc = append(doc, root...)
+    if len(g.order) > 0 {
+        defs := make(object, 0, len(g.order))
+        for _, name := range g.order {
+            defs = append(defs, member{name, g.defs[name]})
+        }
+        doc = append(doc, member{"$defs", defs})
+    }
+
+    var buf bytes.Buffer
+    encoder := json.NewEncoder(&buf)
+    encoder.SetEscapeHTML(false)
+    encoder.SetIndent("", "    ")
+    if err := encoder.Encode(doc); err != nil { return esc(err) }
+    return buf.Bytes(), nil
+}
+
+// member is a member of an object.
+type member struct {
+    key   string
+    value any
+}
+
+// object is a JSON object that keeps its members in order.
+type object []member
+
+// MarshalJSON implements the json.Marshaler interface.
+func (o object) MarshalJSON() ([]byte, error) {
+    var buf bytes.Buffer
+    encoder := json.NewEncoder(&buf)
+    encoder.SetEscapeHTML(false)
+
+    buf.WriteByte('{')
+    for i, m := range o {
+        if i > 0 { buf.WriteByte(',') }
+        if err := encoder.Encode(m.key); err != nil { return nil, err }
+        buf.WriteByte(':')
+        if err := encoder.Encode(m.value); err != nil { return nil, err }
+    }
+    buf.WriteByte('}')
+    return buf.Bytes(), nil
+}
+
+type generator struct {
+    root    string
+    options Options
+    structs map[string]morph.Struct
+    defs    map[string]object
+    order   []string // names of defs, in order of first reference
+}
+
+// description returns a comment as a description, with the lines of each
+// paragraph joined by spaces.
+func description(comment string) string {
+    paragraphs := strings.Split(strings.TrimSpace(comment), "\n\n")
+    for i, p := range paragraphs {
+        paragraphs[i] = strings.Join(strings.Fields(p), " ")
+    }
+    return strings.Join(paragraphs, "\n\n")
+}
+
+// object returns the schema for a struct.
+func (g *generator) object(s morph.Struct) (object, error) {
+    if len(s.TypeParams) > 0 {
+        return nil, fmt.Errorf("generic struct %q is not supported", s.Name)
+    }
+
+    var properties object
+    var required []string
+    seen := make(map[string]string)
+    for _, f := range s.Fields {
+        if (f.Name == "") || (f.Name == f.Type) || strings.Contains(f.Name, ".") {
+            return nil, fmt.Errorf("embedded field %q is not supported", f.Type)
+        }
+        if r := []rune(f.Name)[0]; !unicode.IsUpper(r) { continue }
+
+        key, omitEmpty, asString := f.Name, false, false
+        if value, ok := tag.Lookup(f.Tag, "json"); ok {
+            if value == "-" { continue }
+            name, opts, _ := strings.Cut(value, ",")
+            if source.ValidJSONKey(name) { key = name }
+            for opts != "" {
+                var opt string
+                opt, opts, _ = strings.Cut(opts, ",")
+                switch opt {
+                case "omitempty": omitEmpty = true
+                case "string":    asString = true
+                }
+            }
+        }
+        if other, exists := seen[key]; exists {
+            return nil, fmt.Errorf("fields %q and %q of struct %q have the same key %q", other, f.Name, s.Name, key)
+        }
+        seen[key] = f.Name
+
+        schema, err := g.schema(f.Type)
+        if err != nil { return nil, fmt.Errorf("field %q of struct %q: %w", f.Name, s.Name, err) }
+        if asString { schema = g.quoted(f.Type, schema) }
+        if f.Comment != "" {
+            schema = append(object{{"description", description(f.Comment)}}, schema...)
+        }
+        properties = append(properties, member{key, schema})
+        if !omitEmpty { required = append(required, key) }
+    }
+
+    var result object
+    if s.Comment != "" { result = append(result, member{"description", description(s.Comment)}) }
+    result = append(result, member{"type", "object"})
+    if properties == nil { properties = object{} }
+    result = append(result, member{"properties", properties})
+    if len(required) > 0 { result = append(result, member{"required", required}) }
+    if g.options.Strict { result = append(result, member{"additionalProperties", false}) }
+    return result, nil
+}
+
+// quoted returns the schema for a field with the "string" option, which
+// only applies to strings, bools, and numbers.
+func (g *generator) quoted(Type string, schema object) object {
+    switch strings.TrimPrefix(Type, "*") {
+    case "bool", "float32", "float64", "string",
+        "int", "int8", "int16", "int32", "int64", "rune",
+        "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte":
+        quoted := object{{"type", "string"}}
+        if strings.HasPrefix(Type, "*") { quoted = nullable(quoted) }
+        return quoted
+    }
+    return schema
+}
+
+// nullable returns a schema that also allows null.
+func nullable(schema object) object {
+    if (len(schema) == 1) && (schema[0].key == "type") {
+        if t, ok := schema[0].value.(string); ok {
+            return object{{"type", []string{t, "null"}}}
+        }
+    }
+    return object{{"anyOf", []any{schema, object{{"type", "null"}}}}}
+}
+
+// integer returns the schema for an integer with the given bounds.
+func integer(min int64, max uint64) object {
+    return object{{"type", "integer"}, {"minimum", min}, {"maximum", max}}
+}
+
+// schema returns the schema for a Go type.
+func (g *generator) schema(Type string) (object, error) {
+    if schema, ok := g.options.Types[Type]; ok {
+        keys := make([]string, 0, len(schema))
+        for k := range schema { keys = append(keys, k) }
+        sort.Strings(keys)
+        result := make(object, 0, len(keys))
+        for _, k := range keys { result = append(result, member{k, schema[k]}) }
+        return result, nil
+    }
+
+    switch Type {
+    case "bool":             return object{{"type", "boolean"}}, nil
+    case "string":           return object{{"type", "string"}}, nil
+    case "int", "int64":     return object{{"type", "integer"}}, nil
+    case "int8":             return integer(-1 << 7, 1 << 7 - 1), nil
+    case "int16":            return integer(-1 << 15, 1 << 15 - 1), nil
+    case "int32", "rune":    return integer(-1 << 31, 1 << 31 - 1), nil
+    case "uint8", "byte":    return integer(0, 1 << 8 - 1), nil
+    case "uint16":           return integer(0, 1 << 16 - 1), nil
+    case "uint32":           return integer(0, 1 << 32 - 1), nil
+    case "uint", "uint64", "uintptr":
+        return object{{"type", "integer"}, {"minimum", 0}}, nil
+    case "float32", "float64":
+        return object{{"type", "number"}}, nil
+    case "[]byte", "[]uint8":
+        return object{{"type", "string"}, {"contentEncoding", "base64"}}, nil
+    case "time.Time":
+        return object{{"type", "string"}, {"format", "date-time"}}, nil
+    case "any", "interface{}", "json.RawMessage":
+        return object{}, nil
+    }
+
+    switch {
+    case strings.HasPrefix(Type, "*"):
+        elem, err := g.schema(Type[1:])
+        if err != nil { return nil, err }
+        return nullable(elem), nil
+    case strings.HasPrefix(Type, "[]"):
+        elem, err := g.schema(Type[2:])
+        if err != nil { return nil, err }
+        return object{{"type", "array"}, {"items", elem}}, nil
+    case strings.HasPrefix(Type, "["):
+        n, elemType, ok := strings.Cut(Type[1:], "]")
+        var size int
+        if _, err := fmt.Sscanf(n, "%d", &size); !ok || (err != nil) {
+            return nil, fmt.Errorf("unsupported type %q", Type)
+        }
+        elem, err := g.schema(elemType)
+        if err != nil { return nil, err }
+        return object{{"type", "array"}, {"items", elem}, {"minItems", size}, {"maxItems", size}}, nil
+    case strings.HasPrefix(Type, "map["):
+        _, elemType, ok := strings.Cut(Type[4:], "]")
+        if !ok { return nil, fmt.Errorf("unsupported type %q", Type) }
+        elem, err := g.schema(elemType)
+        if err != nil { return nil, err }
+        return object{{"type", "object"}, {"additionalProperties", elem}}, nil
+    }
+
+    if Type == g.root { return object{{"$ref", "#"}}, nil }
+    s, ok := g.structs[Type]
+    if !ok { return nil, fmt.Errorf("unsupported type %q", Type) }
+    if _, exists := g.defs[Type]; !exists {
+        g.defs[Type] = nil // placeholder, for recursive types
+        g.order = append(g.order, Type)
+        def, err := g.object(s)
+        if err != nil { return nil, err }
+        g.defs[Type] = def
+    }
+    return object{{"$ref", "#/$defs/" + Type}}, nil
+}
@@ -0,0 +1,196 @@
+package jsonschema_test
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/jsonschema"
+    "github.com/tawesoft/morph/internal"
+)
+
+const source = `
+package example
+
+type Celsius float64
+
+// Address is a postal address.
+type Address struct {
+    Street string ` + "`json:\"street\"`" + `
+    City   string ` + "`json:\"city,omitempty\"`" + `
+}
+
+// Person is a person.
+type Person struct {
+    // Name is the full name of
+    // the person.
+    Name      string
+    Nickname  *string            ` + "`json:\"nick,omitempty\"`" + `
+    Age       int8               ` + "`json:\"age\"`" + `
+    Count     uint64             ` + "`json:\",string\"`" + `
+    Temp      Celsius
+    Admin     bool               ` + "`json:\"admin,omitempty\"`" + `
+    Tags      [2]string
+    Scores    map[string][]int   ` + "`json:\"scores,omitempty\"`" + `
+    Data      []byte
+    Born      time.Time
+    Home      *Address
+    Previous  []Address          ` + "`json:\"previous,omitempty\"`" + `
+    Parent    *Person            ` + "`json:\"parent,omitempty\"`" + `
+    Extra     any                ` + "`json:\"extra,omitempty\"`" + `
+    Secret    string             ` + "`json:\"-\"`" + `
+    private   int
+}
+`
+
+const expected = `
+{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "$id": "https://example.org/person.json",
+    "title": "Person",
+    "description": "Person is a person.",
+    "type": "object",
+    "properties": {
+        "Name": {
+            "description": "Name is the full name of the person.",
+            "type": "string"
+        },
+        "nick": {
+            "type": [
+                "string",
+                "null"
+            ]
+        },
+        "age": {
+            "type": "integer",
+            "minimum": -128,
+            "maximum": 127
+        },
+        "Count": {
+            "type": "string"
+        },
+        "Temp": {
+            "exclusiveMinimum": -273.15,
+            "type": "number"
+        },
+        "admin": {
+            "type": "boolean"
+        },
+        "Tags": {
+            "type": "array",
+            "items": {
+                "type": "string"
+            },
+            "minItems": 2,
+            "maxItems": 2
+        },
+        "scores": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "array",
+                "items": {
+                    "type": "integer"
+                }
+            }
+        },
+        "Data": {
+            "type": "string",
+            "contentEncoding": "base64"
+        },
+        "Born": {
+            "type": "string",
+            "format": "date-time"
+        },
+        "Home": {
+            "anyOf": [
+                {
+                    "$ref": "#/$defs/Address"
+                },
+                {
+                    "type": "null"
+                }
+            ]
+        },
+        "previous": {
+            "type": "array",
+            "items": {
+                "$ref": "#/$defs/Address"
+            }
+        },
+        "parent": {
+            "anyOf": [
+                {
+                    "$ref": "#"
+                },
+                {
+                    "type": "null"
+                }
+            ]
+        },
+        "extra": {}
+    },
+    "required": [
+        "Name",
+        "age",
+        "Count",
+        "Temp",
+        "Tags",
+        "Data",
+        "Born",
+        "Home"
+    ],
+    "additionalProperties": false,
+    "$defs": {
+        "Address": {
+            "description": "Address is a postal address.",
+            "type": "object",
+            "properties": {
+                "street": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string"
+                }
+            },
+            "required": [
+                "street"
+            ],
+            "additionalProperties": false
+        }
+    }
+}
+`
+
+func TestSchema(t *testing.T) {
+    person := internal.Must(morph.ParseStruct("test.go", source, "Person"))
+    address := internal.Must(morph.ParseStruct("test.go", source, "Address"))
+
+    got, err := jsonschema.Schema(person, jsonschema.Options{
+        ID:      "https://example.org/person.json",
+        Structs: []morph.Struct{address},
+        Types: map[string]map[string]any{
+            "Celsius": {"type": "number", "exclusiveMinimum": -273.15},
+        },
+        Strict: true,
+    })
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if string(got) != strings.TrimPrefix(expected, "\n") {
+        t.Errorf("got:\n%s\nexpected:\n%s", got, expected)
+    }
+}
+
+func TestSchema_errors(t *testing.T) {
+    for _, s := range []morph.Struct{
+        {Name: "A", Fields: []morph.Field{{Name: "X", Type: "Celsius"}}},
+        {Name: "B", Fields: []morph.Field{{Name: "X", Type: "int"}, {Name: "Y", Type: "int", Tag: `json:"X"`}}},
+        {Name: "C", Fields: []morph.Field{{Name: "Point", Type: "Point"}}},
+        {Name: "D", TypeParams: []morph.Field{{Name: "T", Type: "any"}}},
+        {Name: "E", Fields: []morph.Field{{Name: "X", Type: "[N]int"}}},
+    } {
+        if _, err := jsonschema.Schema(s, jsonschema.Options{}); err == nil {
+            t.Errorf("expected error for struct %s", s.Name)
+        }
+    }
+}
@@ -0,0 +1,357 @@
+// Package protobuf generates Protocol Buffers (proto3) message definitions
+// from structs.
+package protobuf
+
+import (
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "unicode"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+    "github.com/tawesoft/morph/tag"
+)
+
+// Options configure a generated message definition.
+type Options struct {
+    // Numbers, if not nil, persistently maps a field name to its field
+    // number. Message adds an entry for each field of the message, whether
+    // its number comes from a tag or is new, so that, when the map is saved
+    // and passed again the next time the message is generated, each field
+    // keeps its number, and the number of a field that is later removed is
+    // reserved instead of being reused.
+    Numbers map[string]int
+
+    // Names, if not nil, maps a field name to a protobuf field name for
+    // each field without a name in its tag. Otherwise, the field name is
+    // converted to snake case e.g. "UserID" to "user_id".
+    Names func(field string) string
+
+    // Types maps the name of a Go type, such as "Celsius", to a protobuf
+    // type, such as "double", overriding the default protobuf type for that
+    // Go type, if any.
+    Types map[string]string
+}
+
+// Field numbers reserved by the protobuf implementation, and the maximum
+// field number.
+const (
+    firstReserved = 19000
+    lastReserved  = 19999
+    maxNumber     = 1<<29 - 1
+)
+
+// Message returns a proto3 message definition for a struct.
+//
+// Each exported field has a field number, from the first of:
+//
+//   - the field's "proto" tag e.g. `proto:"3"` or `proto:"3,name"`, where
+//     the optional name is the protobuf field name, which must be an ASCII
+//     letter followed by ASCII letters, digits and underscores;
+//   - [Options].Numbers;
+//   - a new number, one greater than any number already used by the struct
+//     or in [Options].Numbers.
+//
+// Every field's number is added to Options.Numbers. It is an error if a tag
+// and Options.Numbers disagree. A field with the tag `proto:"-"` is skipped.
+// Each number in Options.Numbers that does not belong to a field of the
+// message is reserved. The names of removed fields are not reserved, because
+// a field's protobuf name may have come from a tag that no longer exists.
+//
+// Go types are mapped to protobuf types as follows:
+//
+//   - bool, string, float32 (float), and float64 (double) to themselves;
+//   - int, int64, and uint64 to int64 and uint64, and smaller integers to
+//     int32 or uint32;
+//   - []byte to bytes;
+//   - time.Time to google.protobuf.Timestamp, and time.Duration to
+//     google.protobuf.Duration;
+//   - slices and arrays to a repeated field of their elements;
+//   - maps, with integer, bool or string keys, to a map field;
+//   - pointers to scalars, including types mapped to a scalar by
+//     [Options].Types, to an optional field, and pointers to messages to a
+//     message field;
+//   - any other unqualified type name, such as the name of another struct,
+//     to a message of the same name.
+//
+// The struct's comment, and each field's comment, is kept. Embedded fields,
+// and generic structs, are not supported.
+//
+// Use [File] to write one or more messages to a .proto file.
+func Message(s morph.Struct, options Options) (string, error) {
+    esc := func(err error) (string, error) {
+        return "", fmt.Errorf(
+            "error generating protobuf message for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    if len(s.TypeParams) > 0 {
+        return esc(fmt.Errorf("generic structs are not supported"))
+    }
+
+    type field struct {
+        goName  string
+        comment string
+        name    string
+        label   string // "", "optional", or "repeated"
+        Type    string
+        number  int
+    }
+    var fields []*field
+
+    names := make(map[string]string) // protobuf field name => Go field name
+    numbers := make(map[int]string)  // field number => Go field name
+    present := make(map[string]bool) // Go field names of message fields
+    next := 0
+    for _, n := range options.Numbers {
+        if n > next { next = n }
+    }
+
+    // first pass: names, types, and numbers from tags or the map
+    for _, f := range s.Fields {
+        if (f.Name == "") || (f.Name == f.Type) || strings.Contains(f.Name, ".") {
+            return esc(fmt.Errorf("embedded field %q is not supported", f.Type))
+        }
+        if r := []rune(f.Name)[0]; !unicode.IsUpper(r) { continue }
+
+        x := &field{goName: f.Name, comment: f.Comment}
+        x.name = strings.ToLower(strings.Join(source.Words(f.Name), "_"))
+        if options.Names != nil { x.name = options.Names(f.Name) }
+        if value, ok := tag.Lookup(f.Tag, "proto"); ok {
+            if value == "-" { continue }
+            number, name, _ := strings.Cut(value, ",")
+            n, err := strconv.Atoi(number)
+            if (err != nil) || (n <= 0) {
+                return esc(fmt.Errorf("invalid field number %q for field %q", number, f.Name))
+            }
+            x.number = n
+            if name != "" { x.name = name }
+        }
+        if n, ok := options.Numbers[f.Name]; ok {
+            if (x.number != 0) && (x.number != n) {
+                return esc(fmt.Errorf("field %q has number %d in its tag, but %d in Options.Numbers", f.Name, x.number, n))
+            }
+            x.number = n
+        }
+        if x.number != 0 {
+            if (x.number <= 0) || (x.number > maxNumber) || ((x.number >= firstReserved) && (x.number <= lastReserved)) {
+                return esc(fmt.Errorf("invalid field number %d for field %q", x.number, f.Name))
+            }
+            if other, exists := numbers[x.number]; exists {
+                return esc(fmt.Errorf("fields %q and %q have the same number %d", other, f.Name, x.number))
+            }
+            numbers[x.number] = f.Name
+            if x.number > next { next = x.number }
+        }
+        if !isFieldName(x.name) {
+            return esc(fmt.Errorf("invalid protobuf field name %q for field %q", x.name, f.Name))
+        }
+        if other, exists := names[x.name]; exists {
+            return esc(fmt.Errorf("fields %q and %q have the same name %q", other, f.Name, x.name))
+        }
+        names[x.name] = f.Name
+        present[f.Name] = true
+
+        var err error
+        x.label, x.Type, err = protoType(f.Type, options.Types)
+        if err != nil { return esc(fmt.Errorf("field %q: %w", f.Name, err)) }
+        fields = append(fields, x)
+    }
+
+    // a number in the map for another field must not be used by this message
+    for goName, n := range options.Numbers {
+        if other, exists := numbers[n]; exists && (other != goName) && !present[goName] {
+            return esc(fmt.Errorf("field %q has number %d, which is reserved for removed field %q", other, n, goName))
+        }
+    }
+
+    // second pass: new numbers
+    for _, x := range fields {
+        if x.number != 0 { continue }
+        next++
+        if next == firstReserved { next = lastReserved + 1 }
+        if next > maxNumber { return esc(fmt.Errorf("no field numbers left")) }
+        x.number = next
+    }
+    if options.Numbers != nil {
+        for _, x := range fields {
+            options.Numbers[x.goName] = x.number
+        }
+    }
+
+    // reserved numbers of removed fields
+    var reservedNumbers []int
+    for goName, n := range options.Numbers {
+        if present[goName] { continue }
+        reservedNumbers = append(reservedNumbers, n)
+    }
+    sort.Ints(reservedNumbers)
+
+    var sb strings.Builder
+    sb.WriteString(comment(s.Comment, ""))
+    fmt.Fprintf(&sb, "message %s {\n", s.Name)
+    if len(reservedNumbers) > 0 {
+        xs := make([]string, len(reservedNumbers))
+        for i, n := range reservedNumbers { xs[i] = strconv.Itoa(n) }
+        fmt.Fprintf(&sb, "    reserved %s;\n", strings.Join(xs, ", "))
+        if len(fields) > 0 { sb.WriteString("\n") }
+    }
+    for _, x := range fields {
+        sb.WriteString(comment(x.comment, "    "))
+        sb.WriteString("    ")
+        if x.label != "" { sb.WriteString(x.label + " ") }
+        fmt.Fprintf(&sb, "%s %s = %d;\n", x.Type, x.name, x.number)
+    }
+    sb.WriteString("}\n")
+    return sb.String(), nil
+}
+
+// File returns the contents of a proto3 .proto file in the given package,
+// containing the given message definitions, and importing any well-known
+// types that they use.
+func File(pkg string, messages ... string) string {
+    var sb strings.Builder
+    sb.WriteString("syntax = \"proto3\";\n")
+    if pkg != "" { fmt.Fprintf(&sb, "\npackage %s;\n", pkg) }
+
+    body := strings.Join(messages, "\n")
+    var imports []string
+    for _, wkt := range []struct{ Type, file string }{
+        {"google.protobuf.Duration",  "google/protobuf/duration.proto"},
+        {"google.protobuf.Timestamp", "google/protobuf/timestamp.proto"},
+    } {
+        if strings.Contains(body, wkt.Type + " ") || strings.Contains(body, wkt.Type + ">") {
+            imports = append(imports, wkt.file)
+        }
+    }
+    if len(imports) > 0 { sb.WriteString("\n") }
+    for _, i := range imports {
+        fmt.Fprintf(&sb, "import %q;\n", i)
+    }
+
+    for _, m := range messages {
+        sb.WriteString("\n" + m)
+    }
+    return sb.String()
+}
+
+// comment returns a comment as protobuf line comments, with each line
+// indented, or the empty string for an empty comment.
+func comment(text string, indent string) string {
+    text = strings.TrimSpace(text)
+    if text == "" { return "" }
+    var sb strings.Builder
+    for _, line := range strings.Split(text, "\n") {
+        if line == "" {
+            sb.WriteString(indent + "//\n")
+        } else {
+            sb.WriteString(indent + "// " + line + "\n")
+        }
+    }
+    return sb.String()
+}
+
+// scalar returns the protobuf scalar type for a Go type, or the empty
+// string if there is none.
+func scalar(Type string) string {
+    switch Type {
+    case "bool":                                 return "bool"
+    case "string":                               return "string"
+    case "[]byte", "[]uint8":                    return "bytes"
+    case "float32":                              return "float"
+    case "float64":                              return "double"
+    case "int", "int64":                         return "int64"
+    case "int8", "int16", "int32", "rune":       return "int32"
+    case "uint", "uint64", "uintptr":            return "uint64"
+    case "uint8", "uint16", "uint32", "byte":    return "uint32"
+    }
+    return ""
+}
+
+// isScalar returns true if a protobuf type is a scalar type.
+func isScalar(Type string) bool {
+    switch Type {
+    case "double", "float", "int32", "int64", "uint32", "uint64",
+        "sint32", "sint64", "fixed32", "fixed64", "sfixed32", "sfixed64",
+        "bool", "string", "bytes":
+        return true
+    }
+    return false
+}
+
+// element returns the protobuf type for a Go type that can be the element
+// of a repeated or map field, and whether it is a scalar.
+func element(Type string, types map[string]string) (string, bool, error) {
+    if t, ok := types[Type]; ok { return t, isScalar(t), nil }
+    if t := scalar(Type); t != "" { return t, true, nil }
+    switch Type {
+    case "time.Time":     return "google.protobuf.Timestamp", false, nil
+    case "time.Duration": return "google.protobuf.Duration", false, nil
+    }
+    if isIdentifier(Type) { return Type, false, nil }
+    return "", false, fmt.Errorf("unsupported type %q", Type)
+}
+
+// protoType returns the protobuf label and type for a Go type.
+func protoType(Type string, types map[string]string) (string, string, error) {
+    switch {
+    case strings.HasPrefix(Type, "*"):
+        t, isScalar, err := element(Type[1:], types)
+        if err != nil { return "", "", err }
+        if isScalar { return "optional", t, nil }
+        return "", t, nil
+    case (Type == "[]byte") || (Type == "[]uint8"):
+        return "", "bytes", nil
+    case strings.HasPrefix(Type, "["):
+        _, elemType, ok := strings.Cut(Type[1:], "]")
+        if !ok { break }
+        t, _, err := element(elemType, types)
+        if err != nil { return "", "", err }
+        return "repeated", t, nil
+    case strings.HasPrefix(Type, "map["):
+        keyType, elemType, ok := strings.Cut(Type[4:], "]")
+        if !ok { break }
+        k := scalar(keyType)
+        if (k == "") || (k == "float") || (k == "double") || (k == "bytes") {
+            return "", "", fmt.Errorf("unsupported map key type %q", keyType)
+        }
+        v, _, err := element(elemType, types)
+        if err != nil { return "", "", err }
+        return "", "map<" + k + ", " + v + ">", nil
+    default:
+        t, _, err := element(Type, types)
+        return "", t, err
+    }
+    return "", "", fmt.Errorf("unsupported type %q", Type)
+}
+
+// isFieldName returns true if a name is a valid protobuf field name: an
+// ASCII letter followed by ASCII letters, digits, and underscores.
+func isFieldName(s string) bool {
+    for i, c := range s {
+        switch {
+        case ((c >= 'a') && (c <= 'z')) || ((c >= 'A') && (c <= 'Z')):
+        case ((c >= '0') && (c <= '9')) || (c == '_'):
+            if i == 0 { return false }
+        default:
+            return false
+        }
+    }
+    return s != ""
+}
+
+// isIdentifier returns true if a type name is an unqualified identifier.
+func isIdentifier(s string) bool {
+    for i, c := range s {
+        switch {
+        case (c == '_') || unicode.IsLetter(c):
+        case unicode.IsDigit(c) && (i > 0):
+        default:
+            return false
+        }
+    }
+    return s != ""
+}
@@ -0,0 +1,137 @@
+package protobuf_test
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/protobuf"
+    "github.com/tawesoft/morph/internal"
+)
+
+const source = `
+package example
+
+type Celsius float64
+
+// Reading is a sensor reading.
+type Reading struct {
+    // SensorID identifies the sensor.
+    SensorID  string
+    Seq       int64             ` + "`proto:\"2\"`" + `
+    Temp      Celsius           ` + "`proto:\"5,temperature\"`" + `
+    Ok        *bool
+    Tags      []string
+    Counts    map[string]uint16
+    Raw       []byte
+    When      time.Time
+    Window    time.Duration
+    Site      *Site
+    Previous  []Reading
+    Low       *Celsius
+    Secret    string            ` + "`proto:\"-\"`" + `
+    private   int
+}
+`
+
+const expected = `
+syntax = "proto3";
+
+package example.v1;
+
+import "google/protobuf/duration.proto";
+import "google/protobuf/timestamp.proto";
+
+// Reading is a sensor reading.
+message Reading {
+    reserved 4, 7;
+
+    // SensorID identifies the sensor.
+    string sensor_id = 1;
+    int64 seq = 2;
+    double temperature = 5;
+    optional bool ok = 8;
+    repeated string tags = 9;
+    map<string, uint32> counts = 10;
+    bytes raw = 11;
+    google.protobuf.Timestamp when = 12;
+    google.protobuf.Duration window = 13;
+    Site site = 3;
+    repeated Reading previous = 14;
+    optional double low = 15;
+}
+`
+
+func TestMessage(t *testing.T) {
+    reading := internal.Must(morph.ParseStruct("test.go", source, "Reading"))
+
+    numbers := map[string]int{
+        "SensorID": 1,
+        "Site":     3,
+        "Legacy":   4,
+        "Secret":   7,
+    }
+    message, err := protobuf.Message(reading, protobuf.Options{
+        Numbers: numbers,
+        Types:   map[string]string{"Celsius": "double"},
+    })
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+
+    got := protobuf.File("example.v1", message)
+    if got != strings.TrimPrefix(expected, "\n") {
+        t.Errorf("got:\n%s\nexpected:\n%s", got, expected)
+    }
+
+    // every number is persisted, and used again
+    for name, n := range map[string]int{"Seq": 2, "Temp": 5, "Ok": 8, "Previous": 14, "Legacy": 4} {
+        if numbers[name] != n {
+            t.Errorf("got number %d for field %s, expected %d", numbers[name], name, n)
+        }
+    }
+    again, err := protobuf.Message(reading, protobuf.Options{
+        Numbers: numbers,
+        Types:   map[string]string{"Celsius": "double"},
+    })
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+    if again != message {
+        t.Errorf("got:\n%s\nexpected:\n%s", again, message)
+    }
+
+    // a removed field's number, from its tag, is reserved, and not reused
+    numbers = make(map[string]int)
+    x := morph.Struct{Name: "X", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `proto:"3"`}}}
+    internal.Must(protobuf.Message(x, protobuf.Options{Numbers: numbers}))
+    w := morph.Struct{Name: "X", Fields: []morph.Field{{Name: "W", Type: "int"}}}
+    message = internal.Must(protobuf.Message(w, protobuf.Options{Numbers: numbers}))
+    if expected := "message X {\n    reserved 3;\n\n    int64 w = 4;\n}\n"; message != expected {
+        t.Errorf("got:\n%s\nexpected:\n%s", message, expected)
+    }
+}
+
+func TestMessage_errors(t *testing.T) {
+    for _, tt := range []struct {
+        s       morph.Struct
+        numbers map[string]int
+    }{
+        {morph.Struct{Name: "A", Fields: []morph.Field{{Name: "X", Type: "big.Int"}}}, nil},
+        {morph.Struct{Name: "B", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `proto:"1"`}, {Name: "Y", Type: "int", Tag: `proto:"1"`}}}, nil},
+        {morph.Struct{Name: "C", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `proto:"19000"`}}}, nil},
+        {morph.Struct{Name: "D", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `proto:"2"`}}}, map[string]int{"X": 1}},
+        {morph.Struct{Name: "E", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `proto:"2"`}}}, map[string]int{"Old": 2}},
+        {morph.Struct{Name: "F", Fields: []morph.Field{{Name: "X", Type: "map[float64]int"}}}, nil},
+        {morph.Struct{Name: "G", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `proto:"0"`}}}, nil},
+        {morph.Struct{Name: "H", TypeParams: []morph.Field{{Name: "T", Type: "any"}}}, nil},
+        {morph.Struct{Name: "I", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `proto:"1,x-ray"`}}}, nil},
+        {morph.Struct{Name: "J", Fields: []morph.Field{{Name: "Über", Type: "int"}}}, nil},
+    } {
+        if _, err := protobuf.Message(tt.s, protobuf.Options{Numbers: tt.numbers}); err == nil {
+            t.Errorf("expected error for struct %s", tt.s.Name)
+        }
+    }
+
+    s := morph.Struct{Name: "K", Fields: []morph.Field{{Name: "X", Type: "int"}}}
+    names := func(field string) string { return "1" + field }
+    if _, err := protobuf.Message(s, protobuf.Options{Names: names}); err == nil {
+        t.Errorf("expected error for struct %s", s.Name)
+    }
+}
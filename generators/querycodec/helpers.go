@@ -0,0 +1,26 @@
+package querycodec
+
+import (
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+)
+
+// Helpers returns unexported helper functions, each with a name starting
+// with "query", that are called by the generated functions. They must be
+// generated exactly once in each package that uses the generated functions.
+//
+// Use [Imports] to find the import paths they require.
+func Helpers() []morph.Function {
+    return source.Functions(helpers)
+}
+
+const helpers = `package helpers
+
+// queryParseError returns an error for the value of a query parameter that
+// cannot be parsed.
+func queryParseError(name string, value string, err error) error {
+    var numErr *strconv.NumError
+    if errors.As(err, &numErr) { err = numErr.Err }
+    return fmt.Errorf("query: parameter %q: cannot parse %q: %w", name, value, err)
+}
+`
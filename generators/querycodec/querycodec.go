@@ -0,0 +1,355 @@
+// Package querycodec generates functions that encode structs as URL query
+// parameters, and decode them again, without using reflection at runtime.
+//
+// For a struct Foo, [Functions] generates:
+//
+//     func EncodeFooQuery(foo Foo) url.Values
+//     func DecodeFooQuery(values url.Values) (Foo, error)
+//
+// The generated functions call unexported helper functions, returned by
+// [Helpers], which must be generated once in the same package.
+package querycodec
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+    "unicode"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+    "github.com/tawesoft/morph/tag"
+)
+
+// Options configure the generated functions.
+type Options struct {
+    // Names, if not nil, maps a field name to a parameter name for each
+    // field without a name in its "query" tag. Otherwise, the field name is
+    // converted to snake case e.g. "MaxPrice" to "max_price".
+    Names func(field string) string
+
+    // TimeLayout is the layout used to format and parse time.Time fields.
+    // If empty, [time.RFC3339] is used.
+    TimeLayout string
+
+    // Underlying maps the name of a named type, such as "Celsius", to its
+    // underlying type, such as "float64", so that fields of that type can be
+    // converted.
+    Underlying map[string]string
+}
+
+// Imports returns the sorted import paths required by the given generated
+// functions, for example from [Functions] and [Helpers].
+func Imports(functions ... morph.Function) []string {
+    return source.Imports(functions...)
+}
+
+// Functions generates the functions, described in the package
+// documentation, for the given struct.
+//
+// Each exported field is a parameter, named by the field's "query" tag e.g.
+// `query:"q"`, if any, or by [Options].Names. A field with the tag
+// `query:"-"` is skipped.
+//
+// A field with the "omitempty" option e.g. `query:"q,omitempty"` or
+// `query:",omitempty"` is not encoded if its [morph.Field.Truther]
+// expression, formatted by [morph.FieldExpressionType.FormatField] e.g.
+// "!$self.$.IsZero()", is false, or, if it has none, if it is equal to its
+// zero value. A Truther of "skip" means the field is never omitted. A slice is encoded as the parameter repeated once for each element,
+// so an empty slice is always omitted.
+//
+// The following field types are supported:
+//
+//   - string, bool, and each builtin integer and floating point type;
+//   - time.Time, using [Options].TimeLayout;
+//   - time.Duration, parsed with [time.ParseDuration];
+//   - pointers to any of these, which are nil if the parameter is missing;
+//   - slices of any of these.
+//
+// When decoding, a missing or empty parameter leaves the field as its zero
+// value, and a parameter that is repeated sets a field that is not a slice
+// to its first value. Parse errors name the parameter.
+func Functions(s morph.Struct, options Options) ([]morph.Function, error) {
+    esc := func(err error) ([]morph.Function, error) {
+        return nil, fmt.Errorf(
+            "error generating query functions for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    if len(s.TypeParams) > 0 {
+        return esc(fmt.Errorf("generic structs are not supported"))
+    }
+    if options.TimeLayout == "" { options.TimeLayout = time.RFC3339 }
+
+    g := &generator{
+        s:        s,
+        options:  options,
+        receiver: source.Receiver(s.Name, "values", "url"),
+    }
+
+    seen := make(map[string]string)
+    for _, f := range s.Fields {
+        p, ok, err := g.parameter(f)
+        if err != nil { return esc(err) }
+        if !ok { continue }
+        if other, exists := seen[p.name]; exists {
+            return esc(fmt.Errorf("fields %q and %q have the same parameter name %q", other, f.Name, p.name))
+        }
+        seen[p.name] = f.Name
+        g.parameters = append(g.parameters, p)
+    }
+
+    encode, err := g.encode()
+    if err != nil { return esc(err) }
+    decode, err := g.decode()
+    if err != nil { return esc(err) }
+
+    functions := []morph.Function{encode, decode}
+    for i := range functions {
+        functions[i].Body = strings.TrimSpace(functions[i].Body)
+    }
+    return functions, nil
+}
+
+// parameter is a field converted to a query parameter.
+type parameter struct {
+    field     morph.Field
+    name      string
+    omitEmpty bool
+}
+
+type generator struct {
+    s          morph.Struct
+    options    Options
+    receiver   string
+    parameters []parameter
+}
+
+// parameter returns the parameter for a field, or false if the field is
+// skipped.
+func (g *generator) parameter(f morph.Field) (parameter, bool, error) {
+    if (f.Name == "") || (f.Name == f.Type) || strings.Contains(f.Name, ".") {
+        return parameter{}, false, fmt.Errorf("embedded field %q is not supported", f.Type)
+    }
+    if r := []rune(f.Name)[0]; !unicode.IsUpper(r) { return parameter{}, false, nil }
+
+    p := parameter{field: f, name: strings.ToLower(strings.Join(source.Words(f.Name), "_"))}
+    if g.options.Names != nil { p.name = g.options.Names(f.Name) }
+    if value, ok := tag.Lookup(f.Tag, "query"); ok {
+        if value == "-" { return parameter{}, false, nil }
+        name, opts, _ := strings.Cut(value, ",")
+        if name != "" { p.name = name }
+        for opts != "" {
+            var opt string
+            opt, opts, _ = strings.Cut(opts, ",")
+            switch opt {
+            case "omitempty": p.omitEmpty = true
+            default:
+                return parameter{}, false, fmt.Errorf("unsupported option %q in tag of field %q", opt, f.Name)
+            }
+        }
+    }
+    return p, true, nil
+}
+
+const (
+    kindTime = source.KindUser + iota
+    kindDuration
+    kindPointer
+    kindSlice
+)
+
+// kind returns the kind of a type, and its bit size for numeric types, or
+// its element type for pointers and slices.
+func (g *generator) kind(Type string) (k source.Kind, bits int, elem string, err error) {
+    if underlying, ok := g.options.Underlying[Type]; ok { Type = underlying }
+    if k, bits, ok := source.Builtin(Type); ok { return k, bits, "", nil }
+    switch Type {
+    case "time.Time": return kindTime, 0, "", nil
+    case "time.Duration": return kindDuration, 0, "", nil
+    }
+    for _, x := range []struct{ prefix string; k source.Kind }{{"*", kindPointer}, {"[]", kindSlice}} {
+        if !strings.HasPrefix(Type, x.prefix) { continue }
+        elem := Type[len(x.prefix):]
+        if ek, _, _, err := g.kind(elem); (err == nil) && (ek < kindPointer) {
+            return x.k, 0, elem, nil
+        }
+    }
+    return 0, 0, "", fmt.Errorf("unsupported type %q", Type)
+}
+
+// nonZero returns a condition, from a field's Truther expression, that is
+// true if x, the value of the field, is not zero, or the empty string if the
+// field's Truther is "skip", so that the field is never omitted.
+func nonZero(f morph.Field, x string) (string, error) {
+    fet := morph.LookupFieldExpressionType("Truther")
+    if fet == nil {
+        return "", fmt.Errorf("no Truther FieldExpressionType is registered")
+    }
+    pattern := fet.Pattern(f)
+    if pattern == "skip" { return "", nil }
+    cond, err := fet.FormatField(pattern, f, x)
+    if err != nil { return "", fmt.Errorf("field %q: %w", f.Name, err) }
+    return cond, nil
+}
+
+func (g *generator) encodeName() string {
+    return "Encode" + g.s.Name + "Query"
+}
+
+func (g *generator) decodeName() string {
+    return "Decode" + g.s.Name + "Query"
+}
+
+func (g *generator) encode() (morph.Function, error) {
+    var sb strings.Builder
+    sb.WriteString("_out := make(url.Values)\n")
+    for _, p := range g.parameters {
+        f := p.field
+        x := g.receiver + "." + f.Name
+        fmt.Fprintf(&sb, "\n// %s\n", f.Name)
+
+        k, _, elem, err := g.kind(f.Type)
+        if err != nil { return morph.Function{}, fmt.Errorf("field %q: %w", f.Name, err) }
+
+        closing := ""
+        if p.omitEmpty && ((k != kindSlice) || (f.Truther != "")) {
+            cond, err := nonZero(f, x)
+            if err != nil { return morph.Function{}, err }
+            if cond != "" {
+                fmt.Fprintf(&sb, "if %s {\n", cond)
+                closing = "}\n"
+            }
+        }
+
+        name := strconv.Quote(p.name)
+        switch k {
+        case kindPointer:
+            fmt.Fprintf(&sb, "if %s != nil {\n", x)
+            fmt.Fprintf(&sb, "_out.Set(%s, %s)\n", name, g.format("(*" + x + ")", elem))
+            sb.WriteString("}\n")
+        case kindSlice:
+            fmt.Fprintf(&sb, "for _, _x := range %s {\n", x)
+            fmt.Fprintf(&sb, "_out.Add(%s, %s)\n", name, g.format("_x", elem))
+            sb.WriteString("}\n")
+        default:
+            fmt.Fprintf(&sb, "_out.Set(%s, %s)\n", name, g.format(x, f.Type))
+        }
+        sb.WriteString(closing)
+    }
+    sb.WriteString("\nreturn _out\n")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("%s returns a [%s] value as URL query parameters, "+
+                "with a repeated parameter for each element of a slice.",
+                g.encodeName(), g.s.Name),
+            Name:      g.encodeName(),
+            Arguments: []morph.Argument{{Name: g.receiver, Type: g.s.Name}},
+            Returns:   []morph.Argument{{Type: "url.Values"}},
+        },
+        Body: sb.String(),
+    }, nil
+}
+
+// format returns an expression that formats x, of a type that is not a
+// pointer or slice, as a string.
+func (g *generator) format(x string, Type string) string {
+    k, bits, _, _ := g.kind(Type)
+    switch k {
+    case source.KindBool:
+        return fmt.Sprintf("strconv.FormatBool(bool(%s))", x)
+    case source.KindInt:
+        return fmt.Sprintf("strconv.FormatInt(int64(%s), 10)", x)
+    case source.KindUint:
+        return fmt.Sprintf("strconv.FormatUint(uint64(%s), 10)", x)
+    case source.KindFloat:
+        return fmt.Sprintf("strconv.FormatFloat(float64(%s), 'g', -1, %d)", x, bits)
+    case kindTime:
+        return fmt.Sprintf("time.Time(%s).Format(%s)", x, strconv.Quote(g.options.TimeLayout))
+    case kindDuration:
+        return fmt.Sprintf("time.Duration(%s).String()", x)
+    default:
+        return fmt.Sprintf("string(%s)", x)
+    }
+}
+
+func (g *generator) decode() (morph.Function, error) {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "var _result %s\n\n", g.s.Name)
+    for _, p := range g.parameters {
+        f := p.field
+        target := "_result." + f.Name
+        name := strconv.Quote(p.name)
+        fmt.Fprintf(&sb, "// %s\n", f.Name)
+
+        k, _, elem, err := g.kind(f.Type)
+        if err != nil { return morph.Function{}, fmt.Errorf("field %q: %w", f.Name, err) }
+
+        switch k {
+        case kindPointer:
+            fmt.Fprintf(&sb, "if _v := values.Get(%s); _v != \"\" {\n", name)
+            fmt.Fprintf(&sb, "%s = new(%s)\n", target, elem)
+            g.parse(&sb, "(*" + target + ")", elem, name)
+            sb.WriteString("}\n\n")
+        case kindSlice:
+            fmt.Fprintf(&sb, "if _vs := values[%s]; len(_vs) > 0 {\n", name)
+            fmt.Fprintf(&sb, "%s = make(%s, len(_vs))\n", target, f.Type)
+            sb.WriteString("for _i, _v := range _vs {\n")
+            g.parse(&sb, target + "[_i]", elem, name)
+            sb.WriteString("}\n}\n\n")
+        default:
+            fmt.Fprintf(&sb, "if _v := values.Get(%s); _v != \"\" {\n", name)
+            g.parse(&sb, target, f.Type, name)
+            sb.WriteString("}\n\n")
+        }
+    }
+    sb.WriteString("return _result, nil\n")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("%s parses a [%s] value from URL query parameters. "+
+                "Missing or empty parameters leave fields as their zero value.\n\n"+
+                "On error, it returns the value parsed so far, and an error naming the parameter.",
+                g.decodeName(), g.s.Name),
+            Name:      g.decodeName(),
+            Arguments: []morph.Argument{{Name: "values", Type: "url.Values"}},
+            Returns:   []morph.Argument{{Type: g.s.Name}, {Type: "error"}},
+        },
+        Body: sb.String(),
+    }, nil
+}
+
+// parse writes code that parses the string _v, from the parameter with the
+// given quoted name, into the addressable target, of a type that is not a
+// pointer or slice, returning any error.
+func (g *generator) parse(sb *strings.Builder, target string, Type string, name string) {
+    k, bits, _, _ := g.kind(Type)
+
+    // call writes code that parses _v with a function returning a value and
+    // an error.
+    call := func(fn string) {
+        fmt.Fprintf(sb, "_x, _err := %s\n", fn)
+        fmt.Fprintf(sb, "if _err != nil {\nreturn _result, queryParseError(%s, _v, _err)\n}\n", name)
+        fmt.Fprintf(sb, "%s = %s(_x)\n", target, Type)
+    }
+
+    switch k {
+    case source.KindString:
+        fmt.Fprintf(sb, "%s = %s(_v)\n", target, Type)
+    case source.KindBool:
+        call("strconv.ParseBool(_v)")
+    case source.KindInt:
+        call(fmt.Sprintf("strconv.ParseInt(_v, 10, %d)", bits))
+    case source.KindUint:
+        call(fmt.Sprintf("strconv.ParseUint(_v, 10, %d)", bits))
+    case source.KindFloat:
+        call(fmt.Sprintf("strconv.ParseFloat(_v, %d)", bits))
+    case kindTime:
+        call(fmt.Sprintf("time.Parse(%s, _v)", strconv.Quote(g.options.TimeLayout)))
+    case kindDuration:
+        call("time.ParseDuration(_v)")
+    }
+}
@@ -0,0 +1,163 @@
+package querycodec_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/querycodec"
+    "github.com/tawesoft/morph/internal"
+)
+
+const source = `
+package example
+
+type Sort string
+
+type Stamp time.Time
+
+type Filter struct {
+    Query     string         ` + "`query:\"q,omitempty\"`" + `
+    Order     Sort
+    Page      int            ` + "`query:\",omitempty\"`" + `
+    MaxPrice  float64
+    InStock   bool           ` + "`query:\"in_stock,omitempty\"`" + `
+    MinRating *uint8
+    Tags      []string       ` + "`query:\"tag,omitempty\"`" + `
+    IDs       []int64        ` + "`query:\"id\"`" + `
+    Since     time.Time      ` + "`query:\",omitempty\"`" + `
+    Timeout   time.Duration
+    Until     Stamp          ` + "`query:\",omitempty\"`" + `
+    Secret    string         ` + "`query:\"-\"`" + `
+    private   int
+}
+`
+
+func TestFunctions(t *testing.T) {
+    filter := internal.Must(morph.ParseStruct("test.go", source, "Filter"))
+    for i, f := range filter.Fields {
+        if f.Name == "Since" { filter.Fields[i].Truther = "!$self.$.IsZero()" }
+    }
+
+    functions := internal.Must(querycodec.Functions(filter, querycodec.Options{
+        Underlying: map[string]string{"Sort": "string", "Stamp": "time.Time"},
+    }))
+    functions = append(functions, querycodec.Helpers()...)
+
+    var sb strings.Builder
+    sb.WriteString("package main\n\nimport (\n")
+    for _, i := range querycodec.Imports(functions...) {
+        if i == "fmt" || i == "net/url" || i == "time" { continue }
+        fmt.Fprintf(&sb, "%q\n", i)
+    }
+    sb.WriteString("\"fmt\"\n\"net/url\"\n\"time\"\n)\n\ntype Sort string\n\ntype Stamp time.Time\n\n")
+    sb.WriteString(filter.String() + "\n\n")
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(`
+func main() {
+    fmt.Println(EncodeFilterQuery(Filter{}).Encode())
+
+    rating := uint8(4)
+    f := Filter{
+        Query:     "red shoes",
+        Order:     "price",
+        Page:      2,
+        MaxPrice:  49.5,
+        InStock:   true,
+        MinRating: &rating,
+        Tags:      []string{"sale", "new"},
+        IDs:       []int64{1, 2},
+        Since:     time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+        Timeout:   5 * time.Second,
+        Until:     Stamp(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)),
+        Secret:    "secret",
+    }
+    q := EncodeFilterQuery(f)
+    fmt.Println(q.Encode())
+
+    g, err := DecodeFilterQuery(q)
+    fmt.Println(err)
+    fmt.Println(g.Query, g.Order, g.Page, g.MaxPrice, g.InStock, *g.MinRating, g.Tags, g.IDs, g.Since.Year(), g.Timeout, time.Time(g.Until).Year(), g.Secret == "")
+
+    g, err = DecodeFilterQuery(url.Values{"q": {"a", "b"}, "page": {""}})
+    fmt.Println(err, g.Query, g.Page, g.MinRating == nil, g.Tags == nil)
+
+    for _, s := range []string{"page=x", "min_rating=300", "id=1&id=two", "since=yesterday", "timeout=soon"} {
+        v, _ := url.ParseQuery(s)
+        _, err = DecodeFilterQuery(v)
+        fmt.Println(err)
+    }
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        expected := strings.Join([]string{
+            "max_price=0&order=&timeout=0s",
+            "id=1&id=2&in_stock=true&max_price=49.5&min_rating=4&order=price&page=2&q=red+shoes&since=2020-01-02T03%3A04%3A05Z&tag=sale&tag=new&timeout=5s&until=2021-01-02T03%3A04%3A05Z",
+            "<nil>",
+            "red shoes price 2 49.5 true 4 [sale new] [1 2] 2020 5s 2021 true",
+            "<nil> a 0 true true",
+            "query: parameter \"page\": cannot parse \"x\": invalid syntax",
+            "query: parameter \"min_rating\": cannot parse \"300\": value out of range",
+            "query: parameter \"id\": cannot parse \"two\": invalid syntax",
+            "query: parameter \"since\": cannot parse \"yesterday\": parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\"",
+            "query: parameter \"timeout\": cannot parse \"soon\": time: invalid duration \"soon\"",
+        }, "\n") + "\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_receiverName(t *testing.T) {
+    values := internal.Must(morph.ParseStruct("test.go", "package example\n\ntype Values struct {\n    Out int\n    Page int\n}\n", "Values"))
+    functions := internal.Must(querycodec.Functions(values, querycodec.Options{}))
+    functions = append(functions, querycodec.Helpers()...)
+
+    var sb strings.Builder
+    sb.WriteString("package main\n\nimport (\n")
+    for _, i := range querycodec.Imports(functions...) {
+        fmt.Fprintf(&sb, "%q\n", i)
+    }
+    sb.WriteString(")\n\n" + values.String() + "\n\n")
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(`
+func main() {
+    q := EncodeValuesQuery(Values{Out: 1, Page: 2})
+    fmt.Println(q.Encode())
+    v, err := DecodeValuesQuery(q)
+    fmt.Println(v, err)
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        expected := "out=1&page=2\n{1 2} <nil>\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_errors(t *testing.T) {
+    for _, s := range []morph.Struct{
+        {Name: "A", Fields: []morph.Field{{Name: "X", Type: "map[string]int"}}},
+        {Name: "B", Fields: []morph.Field{{Name: "X", Type: "int"}, {Name: "Y", Type: "int", Tag: `query:"x"`}}},
+        {Name: "C", Fields: []morph.Field{{Name: "X", Type: "*[]int"}}},
+        {Name: "D", TypeParams: []morph.Field{{Name: "T", Type: "any"}}, Fields: []morph.Field{{Name: "X", Type: "int"}}},
+        {Name: "E", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `query:",required"`}}},
+        {Name: "F", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `query:",omitempty"`, Truther: "$that != 0"}}},
+        {Name: "G", Fields: []morph.Field{{Name: "Inner", Type: "Inner"}}},
+        {Name: "H", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `query:",omitempty"`, Truther: "$this.Foo.$type != 0"}}},
+    } {
+        if _, err := querycodec.Functions(s, querycodec.Options{}); err == nil {
+            t.Errorf("expected error for struct %s", s.Name)
+        }
+    }
+}
@@ -0,0 +1,208 @@
+package sqlcodec
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/tag"
+)
+
+// Types returns a new map of the default SQL column type for each supported
+// Go type, used by [CreateTable] in a dialect, or nil if the dialect is not
+// supported by CreateTable.
+func (d Dialect) Types() map[string]string {
+    switch d {
+    case SQLite:
+        return map[string]string{
+            "bool":      "INTEGER",
+            "int":       "INTEGER",
+            "int8":      "INTEGER",
+            "int16":     "INTEGER",
+            "int32":     "INTEGER",
+            "int64":     "INTEGER",
+            "rune":      "INTEGER",
+            "uint":      "INTEGER",
+            "uint8":     "INTEGER",
+            "uint16":    "INTEGER",
+            "uint32":    "INTEGER",
+            "uint64":    "INTEGER",
+            "byte":      "INTEGER",
+            "float32":   "REAL",
+            "float64":   "REAL",
+            "string":    "TEXT",
+            "[]byte":    "BLOB",
+            "time.Time": "DATETIME",
+        }
+    case PostgreSQL:
+        return map[string]string{
+            "bool":      "BOOLEAN",
+            "int":       "BIGINT",
+            "int8":      "SMALLINT",
+            "int16":     "SMALLINT",
+            "int32":     "INTEGER",
+            "int64":     "BIGINT",
+            "rune":      "INTEGER",
+            "uint":      "NUMERIC(20)",
+            "uint8":     "SMALLINT",
+            "uint16":    "INTEGER",
+            "uint32":    "BIGINT",
+            "uint64":    "NUMERIC(20)",
+            "byte":      "SMALLINT",
+            "float32":   "REAL",
+            "float64":   "DOUBLE PRECISION",
+            "string":    "TEXT",
+            "[]byte":    "BYTEA",
+            "time.Time": "TIMESTAMP WITH TIME ZONE",
+        }
+    default:
+        return nil
+    }
+}
+
+// CreateTable returns SQL statements that create a table, and any indexes,
+// for storing the given struct, for the SQLite or PostgreSQL [Dialect].
+//
+// Columns are named, and key columns are marked, by the "db" tag, as
+// documented by [Functions]. Key columns form the primary key, so must not be
+// nullable. Each column is NOT NULL, unless it is nullable.
+//
+// Each column type is looked up, by the field's Go type (or, for a pointer,
+// its element type), in [Options].Types, and then in [Dialect.Types]. The
+// annotations in a field's "sql" tag, separated by semicolons, may also
+// include:
+//
+//   - "type:X", to use the column type X;
+//   - "default:X", to use the SQL expression X as the default value, where
+//     X is the rest of the tag, so may contain semicolons, and this must be
+//     the last annotation;
+//   - "unique", for a unique column, or "unique:name", for a named unique
+//     constraint across every column with the same name;
+//   - "index", for an index on the column, or "index:name", for a named
+//     index across every column with the same name, in order.
+//
+// For example:
+//
+//     type Person struct {
+//         Id    int64     `db:"id,key"`
+//         Email string    `db:"email" sql:"unique"`
+//         Name  string    `db:"name" sql:"index"`
+//         Score float64   `db:"score" sql:"default:0"`
+//         Seen  time.Time `db:"seen,nullable" sql:"type:TIMESTAMP"`
+//     }
+func CreateTable(s morph.Struct, options Options) (string, error) {
+    esc := func(err error) (string, error) {
+        return "", fmt.Errorf(
+            "error generating CREATE TABLE statement for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    types := options.Dialect.Types()
+    if types == nil { return esc(fmt.Errorf("unsupported dialect")) }
+    for k, v := range options.Types { types[k] = v }
+    if options.Table == "" { options.Table = s.Name }
+    g := &generator{s: s, options: options}
+    d := options.Dialect
+
+    // named constraints and indexes, in order of first appearance
+    type group struct {
+        name    string
+        columns []string
+    }
+    var uniques, indexes []*group
+    add := func(groups *[]*group, name string, column string) {
+        for _, g := range *groups {
+            if g.name == name {
+                g.columns = append(g.columns, column)
+                return
+            }
+        }
+        *groups = append(*groups, &group{name: name, columns: []string{column}})
+    }
+
+    var lines, keys []string
+    seen := make(map[string]string)
+    for _, f := range s.Fields {
+        c, ok, err := g.column(f)
+        if err != nil { return esc(err) }
+        if !ok { continue }
+        if other, exists := seen[c.name]; exists {
+            return esc(fmt.Errorf("fields %q and %q have the same column name %q", other, f.Name, c.name))
+        }
+        seen[c.name] = f.Name
+        if c.key && (c.pointer() || c.nullable) {
+            return esc(fmt.Errorf("key field %q is nullable", f.Name))
+        }
+
+        Type := strings.TrimPrefix(f.Type, "*")
+        sqlType := types[Type]
+        var constraints []string
+        if !c.pointer() && !c.nullable { constraints = append(constraints, "NOT NULL") }
+
+        value, _ := tag.Lookup(f.Tag, "sql")
+        for value != "" {
+            var raw string
+            var more bool
+            raw, value, more = strings.Cut(value, ";")
+            annotation := strings.TrimSpace(raw)
+            key, arg, hasArg := strings.Cut(annotation, ":")
+            switch {
+            case annotation == "":
+            case (key == "type") && hasArg:
+                sqlType = arg
+            case (key == "default") && hasArg:
+                // the rest of the tag, which may contain semicolons
+                if more {
+                    _, arg, _ = strings.Cut(raw + ";" + value, ":")
+                    arg = strings.TrimSpace(arg)
+                    value = ""
+                }
+                constraints = append(constraints, "DEFAULT " + arg)
+            case (key == "unique") && !hasArg:
+                constraints = append(constraints, "UNIQUE")
+            case (key == "unique"):
+                add(&uniques, arg, c.name)
+            case (key == "index") && !hasArg:
+                add(&indexes, options.Table + "_" + c.name + "_idx", c.name)
+            case (key == "index"):
+                add(&indexes, arg, c.name)
+            default:
+                return esc(fmt.Errorf("unsupported annotation %q on field %q", annotation, f.Name))
+            }
+        }
+        if sqlType == "" {
+            return esc(fmt.Errorf("no column type for type %q of field %q", Type, f.Name))
+        }
+
+        line := d.Quote(c.name) + " " + sqlType
+        if len(constraints) > 0 { line += " " + strings.Join(constraints, " ") }
+        lines = append(lines, line)
+        if c.key { keys = append(keys, d.Quote(c.name)) }
+    }
+    if len(lines) == 0 { return esc(fmt.Errorf("no columns")) }
+
+    // quoteAll returns quoted column names, separated by commas
+    quoteAll := func(names []string) string {
+        xs := make([]string, len(names))
+        for i, name := range names {
+            xs[i] = d.Quote(name)
+        }
+        return strings.Join(xs, ", ")
+    }
+
+    if len(keys) > 0 {
+        lines = append(lines, "PRIMARY KEY (" + strings.Join(keys, ", ") + ")")
+    }
+    for _, u := range uniques {
+        lines = append(lines, "CONSTRAINT " + d.Quote(u.name) + " UNIQUE (" + quoteAll(u.columns) + ")")
+    }
+
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "CREATE TABLE %s (\n    %s\n);\n", d.Quote(options.Table), strings.Join(lines, ",\n    "))
+    for _, index := range indexes {
+        fmt.Fprintf(&sb, "CREATE INDEX %s ON %s (%s);\n",
+            d.Quote(index.name), d.Quote(options.Table), quoteAll(index.columns))
+    }
+    return sb.String(), nil
+}
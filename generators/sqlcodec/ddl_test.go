@@ -0,0 +1,104 @@
+package sqlcodec_test
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/sqlcodec"
+    "github.com/tawesoft/morph/internal"
+)
+
+const ddlSource = `
+package example
+
+type Celsius float64
+
+type Reading struct {
+    Sensor  string      ` + "`db:\"sensor,key\" sql:\"index:by_sensor_time\"`" + `
+    Seq     int64       ` + "`db:\"seq,key\"`" + `
+    Serial  string      ` + "`db:\"serial\" sql:\"unique\"`" + `
+    Temp    Celsius     ` + "`db:\"temp\" sql:\"default:0\"`" + `
+    Ok      bool        ` + "`db:\"ok\" sql:\"index\"`" + `
+    Raw     []byte      ` + "`db:\"raw\"`" + `
+    Note    *string     ` + "`db:\"note\" sql:\"unique; default:'a; b'\"`" + `
+    Site    string      ` + "`db:\"site,nullable\" sql:\"unique:site_when; type:VARCHAR(32)\"`" + `
+    When    time.Time   ` + "`db:\"when\" sql:\"unique:site_when;index:by_sensor_time\"`" + `
+    Secret  string      ` + "`db:\"-\"`" + `
+}
+`
+
+func TestCreateTable(t *testing.T) {
+    reading := internal.Must(morph.ParseStruct("test.go", ddlSource, "Reading"))
+
+    for _, tt := range []struct {
+        dialect  sqlcodec.Dialect
+        expected string
+    }{
+        {sqlcodec.SQLite, `
+CREATE TABLE "readings" (
+    "sensor" TEXT NOT NULL,
+    "seq" INTEGER NOT NULL,
+    "serial" TEXT NOT NULL UNIQUE,
+    "temp" REAL NOT NULL DEFAULT 0,
+    "ok" INTEGER NOT NULL,
+    "raw" BLOB NOT NULL,
+    "note" TEXT UNIQUE DEFAULT 'a; b',
+    "site" VARCHAR(32),
+    "when" DATETIME NOT NULL,
+    PRIMARY KEY ("sensor", "seq"),
+    CONSTRAINT "site_when" UNIQUE ("site", "when")
+);
+CREATE INDEX "by_sensor_time" ON "readings" ("sensor", "when");
+CREATE INDEX "readings_ok_idx" ON "readings" ("ok");
+`},
+        {sqlcodec.PostgreSQL, `
+CREATE TABLE "readings" (
+    "sensor" TEXT NOT NULL,
+    "seq" BIGINT NOT NULL,
+    "serial" TEXT NOT NULL UNIQUE,
+    "temp" REAL NOT NULL DEFAULT 0,
+    "ok" BOOLEAN NOT NULL,
+    "raw" BYTEA NOT NULL,
+    "note" TEXT UNIQUE DEFAULT 'a; b',
+    "site" VARCHAR(32),
+    "when" TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY ("sensor", "seq"),
+    CONSTRAINT "site_when" UNIQUE ("site", "when")
+);
+CREATE INDEX "by_sensor_time" ON "readings" ("sensor", "when");
+CREATE INDEX "readings_ok_idx" ON "readings" ("ok");
+`},
+    } {
+        got, err := sqlcodec.CreateTable(reading, sqlcodec.Options{
+            Dialect: tt.dialect,
+            Table:   "readings",
+            Types:   map[string]string{"Celsius": "REAL"},
+        })
+        if err != nil {
+            t.Errorf("dialect %d: unexpected error: %v", tt.dialect, err)
+        } else if got != strings.TrimPrefix(tt.expected, "\n") {
+            t.Errorf("dialect %d: got:\n%s\nexpected:\n%s", tt.dialect, got, tt.expected)
+        }
+    }
+}
+
+func TestCreateTable_errors(t *testing.T) {
+    for _, s := range []morph.Struct{
+        {Name: "A", Fields: []morph.Field{{Name: "X", Type: "Celsius"}}},
+        {Name: "B", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `sql:"check:X > 0"`}}},
+        {Name: "C", Fields: []morph.Field{{Name: "X", Type: "int"}, {Name: "Y", Type: "int", Tag: `db:"X"`}}},
+        {Name: "D", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `db:"-"`}}},
+        {Name: "F", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `db:"x,key,nullable"`}}},
+        {Name: "G", Fields: []morph.Field{{Name: "X", Type: "*int", Tag: `db:"x,key"`}}},
+    } {
+        if _, err := sqlcodec.CreateTable(s, sqlcodec.Options{}); err == nil {
+            t.Errorf("expected error for struct %s", s.Name)
+        }
+    }
+
+    s := morph.Struct{Name: "E", Fields: []morph.Field{{Name: "X", Type: "int"}}}
+    if _, err := sqlcodec.CreateTable(s, sqlcodec.Options{Dialect: sqlcodec.Dialect(-1)}); err == nil {
+        t.Errorf("expected error for unsupported dialect")
+    }
+}
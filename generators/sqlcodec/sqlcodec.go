@@ -0,0 +1,426 @@
+// Package sqlcodec generates functions that scan structs from database/sql
+// rows, and SQL statements, with arguments, that insert, update, and select
+// them, without using reflection at runtime.
+//
+// For a struct Foo, stored in a table "foo", [Functions] generates:
+//
+//     func FooColumns() []string
+//     func ScanFoo(row interface{ Scan(...any) error }) (Foo, error)
+//     func SelectFooSQL() string        // SELECT columns FROM "foo"
+//     func InsertFooSQL() string        // INSERT INTO "foo" (columns) VALUES (...)
+//     func (foo Foo) InsertArgs() []any
+//
+// and, if the struct has at least one key column:
+//
+//     func SelectFooByKeySQL() string   // SELECT columns FROM "foo" WHERE keys = ...
+//     func (foo Foo) KeyArgs() []any
+//
+// and, if the struct also has at least one column that is not a key:
+//
+//     func UpdateFooSQL() string        // UPDATE "foo" SET columns = ... WHERE keys = ...
+//     func (foo Foo) UpdateArgs() []any
+//
+// ScanFoo expects the columns in the same order as FooColumns, as selected
+// by SelectFooSQL, and accepts a [database/sql.Row] or [database/sql.Rows].
+//
+// [CreateTable] generates the statements that create the table.
+package sqlcodec
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "unicode"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+    "github.com/tawesoft/morph/tag"
+)
+
+// Dialect is a database system with its own flavour of SQL.
+type Dialect int
+
+const (
+    SQLite     Dialect = iota // uses "?" placeholders
+    PostgreSQL                // uses "$1", "$2", ... placeholders
+)
+
+// Placeholder returns the placeholder for the nth (1-based) argument of a
+// statement.
+func (d Dialect) Placeholder(n int) string {
+    if d == PostgreSQL { return "$" + strconv.Itoa(n) }
+    return "?"
+}
+
+// Quote returns an identifier, such as a table or column name, quoted for
+// use in a statement.
+func (d Dialect) Quote(identifier string) string {
+    return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// Options configure the generated functions.
+type Options struct {
+    // Dialect determines the placeholder and identifier quoting style.
+    Dialect Dialect
+
+    // Table is the name of the table. If empty, the struct name is used.
+    Table string
+
+    // Names, if not nil, maps a field name to a column name for each field
+    // without a column name in its "db" tag. Otherwise, the column name is
+    // the field name.
+    Names func(field string) string
+
+    // Types, if not nil, maps a Go type, such as "Celsius", to a SQL column
+    // type, such as "REAL", for [CreateTable], overriding [Dialect.Types].
+    Types map[string]string
+}
+
+// Imports returns the sorted import paths required by the given generated
+// functions.
+func Imports(functions ... morph.Function) []string {
+    return source.Imports(functions...)
+}
+
+// Functions generates the functions, described in the package
+// documentation, for the given struct.
+//
+// Each exported field is a column, in order, named by the field's "db" tag,
+// if any, or by [Options].Names. A field with the tag `db:"-"` is skipped.
+// The tag may also have the options:
+//
+//   - "key", to mark a column as part of the key used to update or select a
+//     single row, e.g. `db:"id,key"`;
+//   - "nullable", to scan a NULL value into the field's zero value, and to
+//     store the zero value as NULL.
+//
+// A pointer field is also nullable, where NULL is nil. A nullable field is
+// scanned through the matching sql.Null* type e.g. [database/sql.NullString].
+// Nullable fields must have a string, bool, integer (other than uint and
+// uint64, which may not fit in an int64), floating point, or time.Time
+// type, or a pointer to one of these. Other fields are scanned
+// directly, so may have any type supported by [database/sql.Rows.Scan].
+func Functions(s morph.Struct, options Options) ([]morph.Function, error) {
+    esc := func(err error) ([]morph.Function, error) {
+        return nil, fmt.Errorf(
+            "error generating SQL functions for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    if len(s.TypeParams) > 0 {
+        return esc(fmt.Errorf("generic structs are not supported"))
+    }
+    if options.Table == "" { options.Table = s.Name }
+
+    g := &generator{
+        s:        s,
+        options:  options,
+        receiver: source.Receiver(s.Name, "row"),
+    }
+
+    seen := make(map[string]string)
+    for _, f := range s.Fields {
+        c, ok, err := g.column(f)
+        if err != nil { return esc(err) }
+        if !ok { continue }
+        if other, exists := seen[c.name]; exists {
+            return esc(fmt.Errorf("fields %q and %q have the same column name %q", other, f.Name, c.name))
+        }
+        seen[c.name] = f.Name
+        g.columns = append(g.columns, c)
+    }
+    if len(g.columns) == 0 {
+        return esc(fmt.Errorf("no columns"))
+    }
+
+    functions := []morph.Function{
+        g.columnsFunction(),
+        g.scan(),
+        g.selectSQL(false),
+        g.insertSQL(),
+        g.args("InsertArgs", "InsertArgs returns the arguments to the statement returned by [Insert" + s.Name + "SQL].", g.columns),
+    }
+    if keys, values := g.split(); len(keys) > 0 {
+        functions = append(functions,
+            g.selectSQL(true),
+            g.args("KeyArgs", "KeyArgs returns the arguments to the statement returned by [Select" + s.Name + "ByKeySQL].", keys),
+        )
+        if len(values) > 0 {
+            functions = append(functions,
+                g.updateSQL(keys, values),
+                g.args("UpdateArgs", "UpdateArgs returns the arguments to the statement returned by [Update" + s.Name + "SQL].", append(values, keys...)),
+            )
+        }
+    }
+    for i := range functions {
+        functions[i].Body = strings.TrimSpace(functions[i].Body)
+    }
+    return functions, nil
+}
+
+// column is a field stored in a table column.
+type column struct {
+    field    morph.Field
+    name     string
+    key      bool
+    nullable bool
+}
+
+type generator struct {
+    s        morph.Struct
+    options  Options
+    receiver string
+    columns  []column
+}
+
+// column returns the column for a field, or false if the field is skipped.
+func (g *generator) column(f morph.Field) (column, bool, error) {
+    if (f.Name == "") || (f.Name == f.Type) || strings.Contains(f.Name, ".") {
+        return column{}, false, fmt.Errorf("embedded field %q is not supported", f.Type)
+    }
+    if r := []rune(f.Name)[0]; !unicode.IsUpper(r) { return column{}, false, nil }
+
+    c := column{field: f, name: f.Name}
+    if g.options.Names != nil { c.name = g.options.Names(f.Name) }
+    if value, ok := tag.Lookup(f.Tag, "db"); ok {
+        if value == "-" { return column{}, false, nil }
+        name, opts, _ := strings.Cut(value, ",")
+        if name != "" { c.name = name }
+        for opts != "" {
+            var opt string
+            opt, opts, _ = strings.Cut(opts, ",")
+            switch opt {
+            case "key":      c.key = true
+            case "nullable": c.nullable = true
+            default:
+                return column{}, false, fmt.Errorf("unsupported option %q on field %q", opt, f.Name)
+            }
+        }
+    }
+
+    Type := f.Type
+    if strings.HasPrefix(Type, "*") {
+        if c.nullable {
+            return column{}, false, fmt.Errorf("nullable option on pointer field %q", f.Name)
+        }
+        Type = Type[1:]
+        if _, _, ok := null(Type); !ok {
+            return column{}, false, fmt.Errorf("unsupported nullable type %q for field %q", Type, f.Name)
+        }
+    } else if c.nullable {
+        if _, _, ok := null(Type); !ok {
+            return column{}, false, fmt.Errorf("unsupported nullable type %q for field %q", Type, f.Name)
+        }
+    }
+    return c, true, nil
+}
+
+// null returns the sql.Null* type used to scan a nullable value of the given
+// type, and the name of its value field.
+func null(Type string) (nullType string, field string, ok bool) {
+    switch Type {
+    case "string":
+        return "sql.NullString", "String", true
+    case "bool":
+        return "sql.NullBool", "Bool", true
+    case "int", "int8", "int16", "int32", "int64", "rune",
+        "uint8", "uint16", "uint32", "byte":
+        return "sql.NullInt64", "Int64", true
+    case "float32", "float64":
+        return "sql.NullFloat64", "Float64", true
+    case "time.Time":
+        return "sql.NullTime", "Time", true
+    }
+    return "", "", false
+}
+
+// pointer returns true if a column has a pointer type.
+func (c column) pointer() bool {
+    return strings.HasPrefix(c.field.Type, "*")
+}
+
+// split returns the key columns and the other columns.
+func (g *generator) split() (keys []column, values []column) {
+    for _, c := range g.columns {
+        if c.key {
+            keys = append(keys, c)
+        } else {
+            values = append(values, c)
+        }
+    }
+    return keys, values
+}
+
+// list returns the quoted names of columns, separated by commas.
+func (g *generator) list(columns []column) string {
+    names := make([]string, len(columns))
+    for i, c := range columns {
+        names[i] = g.options.Dialect.Quote(c.name)
+    }
+    return strings.Join(names, ", ")
+}
+
+// assignments returns the quoted names of columns each followed by " = "
+// and a placeholder, numbered from start, and separated by sep.
+func (g *generator) assignments(columns []column, start int, sep string) string {
+    xs := make([]string, len(columns))
+    for i, c := range columns {
+        xs[i] = g.options.Dialect.Quote(c.name) + " = " + g.options.Dialect.Placeholder(start + i)
+    }
+    return strings.Join(xs, sep)
+}
+
+func (g *generator) columnsFunction() morph.Function {
+    names := make([]string, len(g.columns))
+    for i, c := range g.columns {
+        names[i] = strconv.Quote(c.name)
+    }
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("%sColumns returns the names of the columns storing a [%s] value, "+
+                "in the order scanned by [Scan%s].", g.s.Name, g.s.Name, g.s.Name),
+            Name:    g.s.Name + "Columns",
+            Returns: []morph.Argument{{Type: "[]string"}},
+        },
+        Body: fmt.Sprintf("return []string{%s}", strings.Join(names, ", ")),
+    }
+}
+
+func (g *generator) scan() morph.Function {
+    var sb strings.Builder
+    var after strings.Builder
+    targets := make([]string, len(g.columns))
+
+    fmt.Fprintf(&sb, "var _x %s\n", g.s.Name)
+    for i, c := range g.columns {
+        if !c.pointer() && !c.nullable {
+            targets[i] = "&_x." + c.field.Name
+            continue
+        }
+        Type := strings.TrimPrefix(c.field.Type, "*")
+        nullType, field, _ := null(Type)
+        v := fmt.Sprintf("_null%d", i)
+        targets[i] = "&" + v
+        fmt.Fprintf(&sb, "var %s %s\n", v, nullType)
+
+        fmt.Fprintf(&after, "// %s\n", c.field.Name)
+        if c.pointer() {
+            fmt.Fprintf(&after, "if %s.Valid {\n_v := %s(%s.%s)\n_x.%s = &_v\n}\n", v, Type, v, field, c.field.Name)
+        } else {
+            fmt.Fprintf(&after, "_x.%s = %s(%s.%s)\n", c.field.Name, Type, v, field)
+        }
+    }
+    fmt.Fprintf(&sb, "if _err := row.Scan(%s); _err != nil {\n", strings.Join(targets, ", "))
+    fmt.Fprintf(&sb, "return %s{}, _err\n}\n", g.s.Name)
+    if after.Len() > 0 {
+        sb.WriteString("\n")
+        sb.WriteString(after.String())
+    }
+    sb.WriteString("return _x, nil\n")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("Scan%s scans a [%s] value from a row with the columns "+
+                "returned by [%sColumns], in order, such as a [database/sql.Row] or "+
+                "[database/sql.Rows].", g.s.Name, g.s.Name, g.s.Name),
+            Name:      "Scan" + g.s.Name,
+            Arguments: []morph.Argument{{Name: "row", Type: "interface{ Scan(...any) error }"}},
+            Returns:   []morph.Argument{{Type: g.s.Name}, {Type: "error"}},
+        },
+        Body: sb.String(),
+    }
+}
+
+// statement returns a function returning a constant SQL statement.
+func (g *generator) statement(name string, comment string, statement string) morph.Function {
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: comment,
+            Name:    name,
+            Returns: []morph.Argument{{Type: "string"}},
+        },
+        Body: "return " + strconv.Quote(statement),
+    }
+}
+
+func (g *generator) selectSQL(byKey bool) morph.Function {
+    d := g.options.Dialect
+    statement := fmt.Sprintf("SELECT %s FROM %s", g.list(g.columns), d.Quote(g.options.Table))
+    if !byKey {
+        name := "Select" + g.s.Name + "SQL"
+        return g.statement(name,
+            fmt.Sprintf("%s returns a statement that selects every [%s] value, "+
+                "with the columns scanned by [Scan%s].", name, g.s.Name, g.s.Name),
+            statement)
+    }
+
+    keys, _ := g.split()
+    name := "Select" + g.s.Name + "ByKeySQL"
+    return g.statement(name,
+        fmt.Sprintf("%s returns a statement that selects the [%s] value with the key "+
+            "given by the arguments returned by [%s.KeyArgs], with the columns scanned "+
+            "by [Scan%s].", name, g.s.Name, g.s.Name, g.s.Name),
+        statement + " WHERE " + g.assignments(keys, 1, " AND "))
+}
+
+func (g *generator) insertSQL() morph.Function {
+    d := g.options.Dialect
+    placeholders := make([]string, len(g.columns))
+    for i := range g.columns {
+        placeholders[i] = d.Placeholder(i + 1)
+    }
+    name := "Insert" + g.s.Name + "SQL"
+    return g.statement(name,
+        fmt.Sprintf("%s returns a statement that inserts a [%s] value, "+
+            "given the arguments returned by [%s.InsertArgs].", name, g.s.Name, g.s.Name),
+        fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+            d.Quote(g.options.Table), g.list(g.columns), strings.Join(placeholders, ", ")))
+}
+
+func (g *generator) updateSQL(keys []column, values []column) morph.Function {
+    d := g.options.Dialect
+    name := "Update" + g.s.Name + "SQL"
+    return g.statement(name,
+        fmt.Sprintf("%s returns a statement that updates the [%s] value with the same key, "+
+            "given the arguments returned by [%s.UpdateArgs].", name, g.s.Name, g.s.Name),
+        fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+            d.Quote(g.options.Table),
+            g.assignments(values, 1, ", "),
+            g.assignments(keys, len(values) + 1, " AND ")))
+}
+
+// args returns a method returning the values of the given columns as
+// statement arguments.
+func (g *generator) args(name string, comment string, columns []column) morph.Function {
+    values := make([]string, len(columns))
+    for i, c := range columns {
+        x := g.receiver + "." + c.field.Name
+        values[i] = x
+        if c.nullable {
+            nullType, field, _ := null(c.field.Type)
+            zero := "0"
+            switch field {
+            case "String": zero = `""`
+            case "Bool":   zero = "false"
+            }
+            valid := x + " != " + zero
+            if field == "Time" { valid = "!" + x + ".IsZero()" }
+            value := x
+            switch field {
+            case "Int64":   value = "int64(" + x + ")"
+            case "Float64": value = "float64(" + x + ")"
+            }
+            values[i] = fmt.Sprintf("%s{%s: %s, Valid: %s}", nullType, field, value, valid)
+        }
+    }
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment:  comment,
+            Name:     name,
+            Returns:  []morph.Argument{{Type: "[]any"}},
+            Receiver: morph.Argument{Name: g.receiver, Type: g.s.Name},
+        },
+        Body: fmt.Sprintf("return []any{%s}", strings.Join(values, ", ")),
+    }
+}
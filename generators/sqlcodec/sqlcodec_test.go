@@ -0,0 +1,213 @@
+package sqlcodec_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/sqlcodec"
+    "github.com/tawesoft/morph/internal"
+)
+
+const source = `
+package example
+
+type Person struct {
+    Id      int64      ` + "`db:\"id,key\"`" + `
+    Name    string     ` + "`db:\"name\"`" + `
+    Nick    string     ` + "`db:\"nick,nullable\"`" + `
+    Age     *int16
+    Height  float32
+    Joined  time.Time  ` + "`db:\"joined,nullable\"`" + `
+    Secret  string     ` + "`db:\"-\"`" + `
+    private int
+}
+`
+
+// fakeDriver is an in-process database/sql driver that prints each
+// statement and its arguments, and returns the rows in fakeData for every
+// query.
+const fakeDriver = `
+type fakeDriver struct{}
+type fakeConn struct{}
+type fakeStmt struct{ query string }
+type fakeRows struct{ i int }
+
+var fakeData = [][]driver.Value{
+    {int64(1), "Alice", nil, int64(30), float64(1.5), time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+    {int64(2), []byte("Bob"), "Bobby", nil, float64(2), nil},
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query}, nil }
+func (fakeConn) Close() error { return nil }
+func (fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+func (fakeStmt) Close() error { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+    fmt.Printf("exec %s %v\n", s.query, args)
+    return driver.RowsAffected(1), nil
+}
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+    fmt.Printf("query %s %v\n", s.query, args)
+    return &fakeRows{}, nil
+}
+func (r *fakeRows) Columns() []string { return PersonColumns() }
+func (r *fakeRows) Close() error { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+    if r.i >= len(fakeData) { return io.EOF }
+    copy(dest, fakeData[r.i])
+    r.i++
+    return nil
+}
+`
+
+func TestFunctions(t *testing.T) {
+    person := internal.Must(morph.ParseStruct("test.go", source, "Person"))
+    functions := internal.Must(sqlcodec.Functions(person, sqlcodec.Options{
+        Dialect: sqlcodec.PostgreSQL,
+        Table:   "people",
+        Names:   strings.ToLower,
+    }))
+
+    var sb strings.Builder
+    sb.WriteString("package main\n\nimport (\n")
+    for _, i := range sqlcodec.Imports(functions...) {
+        if i == "database/sql" || i == "time" { continue }
+        fmt.Fprintf(&sb, "%q\n", i)
+    }
+    sb.WriteString("\"database/sql\"\n\"database/sql/driver\"\n\"errors\"\n\"fmt\"\n\"io\"\n\"time\"\n)\n\n")
+    sb.WriteString(person.String() + "\n\n")
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(fakeDriver)
+    sb.WriteString(`
+func main() {
+    sql.Register("fake", fakeDriver{})
+    db, err := sql.Open("fake", "")
+    if err != nil { fmt.Println(err); return }
+    defer db.Close()
+
+    age := int16(40)
+    p := Person{Id: 3, Name: "Carol", Age: &age, Height: 1.25, Secret: "secret"}
+    if _, err := db.Exec(InsertPersonSQL(), p.InsertArgs()...); err != nil { fmt.Println(err) }
+    p.Nick = "C"
+    p.Age = nil
+    p.Joined = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+    if _, err := db.Exec(UpdatePersonSQL(), p.UpdateArgs()...); err != nil { fmt.Println(err) }
+
+    rows, err := db.Query(SelectPersonSQL())
+    if err != nil { fmt.Println(err); return }
+    for rows.Next() {
+        p, err := ScanPerson(rows)
+        if err != nil { fmt.Println(err); continue }
+        age := "nil"
+        if p.Age != nil { age = fmt.Sprint(*p.Age) }
+        fmt.Printf("%d %q %q %s %v %s\n", p.Id, p.Name, p.Nick, age, p.Height, p.Joined.Format(time.RFC3339))
+    }
+    fmt.Println(rows.Err())
+
+    p, err = ScanPerson(db.QueryRow(SelectPersonByKeySQL(), Person{Id: 1}.KeyArgs()...))
+    fmt.Println(p.Name, err)
+
+    var name string
+    fmt.Println(db.QueryRow(SelectPersonSQL()).Scan(&name) != nil)
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        expected := strings.Join([]string{
+            `exec INSERT INTO "people" ("id", "name", "nick", "age", "height", "joined") VALUES ($1, $2, $3, $4, $5, $6) [3 Carol <nil> 40 1.25 <nil>]`,
+            `exec UPDATE "people" SET "name" = $1, "nick" = $2, "age" = $3, "height" = $4, "joined" = $5 WHERE "id" = $6 [Carol C <nil> 1.25 2021-01-01 00:00:00 +0000 UTC 3]`,
+            `query SELECT "id", "name", "nick", "age", "height", "joined" FROM "people" []`,
+            `1 "Alice" "" 30 1.5 2020-01-02T03:04:05Z`,
+            `2 "Bob" "Bobby" nil 2 0001-01-01T00:00:00Z`,
+            `<nil>`,
+            `query SELECT "id", "name", "nick", "age", "height", "joined" FROM "people" WHERE "id" = $1 [1]`,
+            `Alice <nil>`,
+            `query SELECT "id", "name", "nick", "age", "height", "joined" FROM "people" []`,
+            `true`,
+        }, "\n") + "\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestFunctions_dialects(t *testing.T) {
+    s := morph.Struct{Name: "Order", Fields: []morph.Field{
+        {Name: "Id", Type: "int", Tag: `db:"id,key"`},
+        {Name: "Line", Type: "int", Tag: `db:"line,key"`},
+        {Name: "Note", Type: "string", Tag: "db:\"note`s\""},
+    }}
+
+    for _, tt := range []struct {
+        dialect sqlcodec.Dialect
+        update  string
+    }{
+        {sqlcodec.SQLite,     `return "UPDATE \"Order\" SET \"note` + "`" + `s\" = ? WHERE \"id\" = ? AND \"line\" = ?"`},
+        {sqlcodec.PostgreSQL, `return "UPDATE \"Order\" SET \"note` + "`" + `s\" = $1 WHERE \"id\" = $2 AND \"line\" = $3"`},
+    } {
+        functions := internal.Must(sqlcodec.Functions(s, sqlcodec.Options{Dialect: tt.dialect}))
+        for _, f := range functions {
+            if f.Signature.Name != "UpdateOrderSQL" { continue }
+            if f.Body != tt.update {
+                t.Errorf("got %s, expected %s", f.Body, tt.update)
+            }
+        }
+    }
+}
+
+func TestFunctions_keys(t *testing.T) {
+    s := morph.Struct{Name: "Tag", Fields: []morph.Field{
+        {Name: "Post", Type: "int", Tag: `db:"post,key"`},
+        {Name: "Name", Type: "string", Tag: `db:"name,key"`},
+    }}
+
+    var names []string
+    for _, f := range internal.Must(sqlcodec.Functions(s, sqlcodec.Options{})) {
+        names = append(names, f.Signature.Name)
+    }
+    got := strings.Join(names, " ")
+    expected := "TagColumns ScanTag SelectTagSQL InsertTagSQL InsertArgs SelectTagByKeySQL KeyArgs"
+    if got != expected {
+        t.Errorf("got %s, expected %s", got, expected)
+    }
+}
+
+func TestFunctions_receiverName(t *testing.T) {
+    for _, tt := range []struct {
+        name, receiver string
+    }{
+        {"Row",     "_row"},
+        {"Strings", "_strings"},
+        {"Person",  "person"},
+    } {
+        s := morph.Struct{Name: tt.name, Fields: []morph.Field{{Name: "X", Type: "int"}}}
+        for _, f := range internal.Must(sqlcodec.Functions(s, sqlcodec.Options{})) {
+            if f.Signature.Receiver.Name == "" { continue }
+            if f.Signature.Receiver.Name != tt.receiver {
+                t.Errorf("%s: got receiver %s, expected %s", f.Signature.Name, f.Signature.Receiver.Name, tt.receiver)
+            }
+        }
+    }
+}
+
+func TestFunctions_errors(t *testing.T) {
+    for _, s := range []morph.Struct{
+        {Name: "A", Fields: []morph.Field{{Name: "X", Type: "int", Tag: `db:"x,unique"`}}},
+        {Name: "B", Fields: []morph.Field{{Name: "X", Type: "int"}, {Name: "Y", Type: "int", Tag: `db:"X"`}}},
+        {Name: "C", Fields: []morph.Field{{Name: "X", Type: "*[]int"}}},
+        {Name: "D", Fields: []morph.Field{{Name: "X", Type: "Point", Tag: `db:",nullable"`}}},
+        {Name: "E", Fields: []morph.Field{{Name: "x", Type: "int"}}},
+        {Name: "F", Fields: []morph.Field{{Name: "X", Type: "uint64", Tag: `db:",nullable"`}}},
+        {Name: "G", Fields: []morph.Field{{Name: "X", Type: "*uint"}}},
+    } {
+        if _, err := sqlcodec.Functions(s, sqlcodec.Options{}); err == nil {
+            t.Errorf("expected error for struct %s", s.Name)
+        }
+    }
+}
@@ -0,0 +1,208 @@
+// Package stats generates types and functions that compute aggregate
+// statistics for each numeric field over a slice of structs.
+//
+// For a struct Foo, [Generate] returns a struct FooStats, derived from Foo,
+// with a Count field, and, for each selected field Bar, the fields BarSum,
+// BarMin, BarMax, BarMean, and BarNonZero. It also returns the functions:
+//
+//     func ComputeFooStats(foos []Foo) FooStats
+//     func (fooStats *FooStats) Merge(other FooStats)
+//
+// ComputeFooStats computes every statistic in a single pass. Merge combines
+// partial results, for example computed in parallel over parts of a slice.
+package stats
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/fieldmappers"
+    "github.com/tawesoft/morph/internal"
+    "github.com/tawesoft/morph/structmappers"
+)
+
+// Numeric is a filter that returns true for any field with a builtin Go
+// integer or floating point type.
+var Numeric = fieldmappers.FilterTypes(
+    "int", "int8", "int16", "int32", "int64",
+    "uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+    "byte", "rune",
+    "float32", "float64",
+)
+
+// Generate returns a statistics struct, and the functions described in the
+// package documentation, for the given struct.
+//
+// Statistics are computed for each field where filter returns true. If filter
+// is nil, [Numeric] is used. It is an error if no fields are selected. Each
+// selected field must have a numeric type that supports the "<" operator and
+// conversion to float64. It is an error if two statistic fields have the
+// same name.
+//
+// In the provided name, the token "$" is rewritten to the name of the input
+// struct. For example, "$Stats" on a struct named "Foo" gives a struct named
+// "FooStats".
+//
+// The sum of a signed integer field is an int64, the sum of an unsigned
+// integer field is a uint64, and the sum of any other field is a float64.
+func Generate(s morph.Struct, name string, filter func(morph.Field) bool) (morph.Struct, []morph.Function, error) {
+    if filter == nil { filter = Numeric }
+
+    fields := internal.Filter(filter, s.Fields)
+    if len(fields) == 0 {
+        return morph.Struct{}, nil, fmt.Errorf(
+            "error generating stats for struct %q: no fields selected",
+            s.Name,
+        )
+    }
+    stats := s.Map(
+        structmappers.Rename(name),
+        structmappers.SetComment(""),
+    ).MapFields(
+        fieldmappers.Filter(filter),
+        fieldmappers.StripTags,
+        Fields,
+    ).Map(
+        structmappers.PrependFields([]morph.Field{{
+            Name:    "Count",
+            Type:    "int",
+            Comment: "number of values",
+        }}),
+    )
+    seen := make(map[string]bool)
+    for _, f := range stats.Fields {
+        if seen[f.Name] {
+            return morph.Struct{}, nil, fmt.Errorf(
+                "error generating stats for struct %q: more than one field named %q",
+                s.Name, f.Name,
+            )
+        }
+        seen[f.Name] = true
+    }
+    stats.Comment = fmt.Sprintf("%s holds statistics computed over a slice of [%s] values.", stats.Name, s.Name)
+    stats.Reverse = nil
+
+    g := generator{
+        s:        s,
+        stats:    stats,
+        fields:   fields,
+        receiver: internal.Untitle(stats.Name),
+        xs:       internal.Untitle(s.Name) + "s",
+    }
+
+    return stats, []morph.Function{g.compute(), g.merge()}, nil
+}
+
+// Fields is a [morph.FieldMapper] that maps a numeric field, Bar, to the
+// fields BarSum, BarMin, BarMax, BarMean, and BarNonZero, as described in
+// the package documentation.
+func Fields(input morph.Field, emit func(output morph.Field)) {
+    name := input.Name
+    emit(morph.Field{Name: "$Sum",     Type: sumType(input.Type), Comment: "sum of each " + name + " value"})
+    emit(morph.Field{Name: "$Min",     Type: "$",                 Comment: "minimum " + name + " value"})
+    emit(morph.Field{Name: "$Max",     Type: "$",                 Comment: "maximum " + name + " value"})
+    emit(morph.Field{Name: "$Mean",    Type: "float64",           Comment: "mean " + name + " value"})
+    emit(morph.Field{Name: "$NonZero", Type: "int",               Comment: "number of non-zero " + name + " values"})
+}
+
+// sumType returns the type used to hold the sum of values of a numeric
+// type.
+func sumType(Type string) string {
+    switch Type {
+    case "int", "int8", "int16", "int32", "int64", "rune":
+        return "int64"
+    case "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte":
+        return "uint64"
+    default:
+        return "float64"
+    }
+}
+
+type generator struct {
+    s        morph.Struct
+    stats    morph.Struct
+    fields   []morph.Field // selected fields on the input struct
+    receiver string // receiver name for methods on the stats struct
+    xs       string // argument name for the input slice
+}
+
+func (g generator) compute() morph.Function {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "var _out %s\n", g.stats.Type())
+    fmt.Fprintf(&sb, "_out.Count = len(%s)\n", g.xs)
+    fmt.Fprintf(&sb, "for _i, _x := range %s {\n", g.xs)
+    for i, f := range g.fields {
+        x := "_x." + f.Name
+        out := "_out." + f.Name
+        if i > 0 { sb.WriteString("\n") }
+        fmt.Fprintf(&sb, "// %s\n", f.Name)
+        fmt.Fprintf(&sb, "%sSum += %s(%s)\n", out, sumType(f.Type), x)
+        fmt.Fprintf(&sb, "if (_i == 0) || (%s < %sMin) { %sMin = %s }\n", x, out, out, x)
+        fmt.Fprintf(&sb, "if (_i == 0) || (%s > %sMax) { %sMax = %s }\n", x, out, out, x)
+        fmt.Fprintf(&sb, "if %s != 0 { %sNonZero++ }\n", x, out)
+    }
+    sb.WriteString("}\n")
+    g.writeMeans(&sb, "_out")
+    sb.WriteString("return _out")
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf(
+                "Compute%s computes a [%s] over every [%s] value in %s.",
+                g.stats.Name, g.stats.Name, g.s.Name, g.xs,
+            ),
+            Name:      "Compute" + g.stats.Name,
+            Type:      typeParams(g.stats),
+            Arguments: []morph.Argument{{Name: g.xs, Type: "[]" + g.s.Type()}},
+            Returns:   []morph.Argument{{Type: g.stats.Type()}},
+        },
+        Body: sb.String(),
+    }
+}
+
+func (g generator) merge() morph.Function {
+    var sb strings.Builder
+    r := g.receiver
+    fmt.Fprintf(&sb, "if other.Count == 0 { return }\n")
+    fmt.Fprintf(&sb, "if %s.Count == 0 { *%s = other; return }\n", r, r)
+    fmt.Fprintf(&sb, "%s.Count += other.Count\n", r)
+    for _, f := range g.fields {
+        out := r + "." + f.Name
+        in := "other." + f.Name
+        fmt.Fprintf(&sb, "\n// %s\n", f.Name)
+        fmt.Fprintf(&sb, "%sSum += %sSum\n", out, in)
+        fmt.Fprintf(&sb, "if %sMin < %sMin { %sMin = %sMin }\n", in, out, out, in)
+        fmt.Fprintf(&sb, "if %sMax > %sMax { %sMax = %sMax }\n", in, out, out, in)
+        fmt.Fprintf(&sb, "%sNonZero += %sNonZero\n", out, in)
+    }
+    g.writeMeans(&sb, r)
+
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: "Merge combines the statistics of another value, computed over a different slice, with this one.",
+            Name:      "Merge",
+            Arguments: []morph.Argument{{Name: "other", Type: g.stats.Type()}},
+            Receiver:  morph.Argument{Name: r, Type: "*" + g.stats.Type()},
+        },
+        Body: strings.TrimSpace(sb.String()),
+    }
+}
+
+// writeMeans writes code that computes the mean of each field from its sum
+// and the count.
+func (g generator) writeMeans(sb *strings.Builder, target string) {
+    if len(g.fields) == 0 { return }
+    fmt.Fprintf(sb, "\nif %s.Count > 0 {\n", target)
+    for _, f := range g.fields {
+        fmt.Fprintf(sb, "%s.%sMean = float64(%s.%sSum) / float64(%s.Count)\n",
+            target, f.Name, target, f.Name, target)
+    }
+    sb.WriteString("}\n")
+}
+
+func typeParams(s morph.Struct) []morph.Argument {
+    return internal.Map(func(f morph.Field) morph.Argument {
+        return morph.Argument{Name: f.Name, Type: f.Type}
+    }, s.TypeParams)
+}
@@ -0,0 +1,119 @@
+package stats_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/stats"
+    "github.com/tawesoft/morph/internal"
+)
+
+func TestGenerate(t *testing.T) {
+    source := `
+package example
+
+type Apple struct {
+    Name   string
+    Weight float64
+    Seeds  int8
+    Stock  uint
+}
+`
+    apple := internal.Must(morph.ParseStruct("test.go", source, "Apple"))
+
+    appleStats, functions, err := stats.Generate(apple, "$Stats", nil)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    expectedStruct := internal.Must(internal.FormatSource(`
+// AppleStats holds statistics computed over a slice of [Apple] values.
+type AppleStats struct {
+    Count         int     // number of values
+    WeightSum     float64 // sum of each Weight value
+    WeightMin     float64 // minimum Weight value
+    WeightMax     float64 // maximum Weight value
+    WeightMean    float64 // mean Weight value
+    WeightNonZero int     // number of non-zero Weight values
+    SeedsSum      int64   // sum of each Seeds value
+    SeedsMin      int8    // minimum Seeds value
+    SeedsMax      int8    // maximum Seeds value
+    SeedsMean     float64 // mean Seeds value
+    SeedsNonZero  int     // number of non-zero Seeds values
+    StockSum      uint64  // sum of each Stock value
+    StockMin      uint    // minimum Stock value
+    StockMax      uint    // maximum Stock value
+    StockMean     float64 // mean Stock value
+    StockNonZero  int     // number of non-zero Stock values
+}
+`))
+    if appleStats.String() != expectedStruct {
+        t.Logf("got: %s", appleStats.String())
+        t.Logf("expected: %s", expectedStruct)
+        t.Errorf("unexpected struct")
+    }
+
+    var sb strings.Builder
+    sb.WriteString("package main\n\nimport \"fmt\"\n\n")
+    sb.WriteString(apple.String() + "\n\n")
+    sb.WriteString(appleStats.String() + "\n\n")
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(`
+func main() {
+    apples := []Apple{
+        {Name: "a", Weight: 1.5, Seeds: 100, Stock: 0},
+        {Name: "b", Weight: 2.5, Seeds: 100, Stock: 3},
+        {Name: "c", Weight: 0,   Seeds: -5,  Stock: 9},
+    }
+
+    all := ComputeAppleStats(apples)
+    fmt.Printf("%+v\n", all)
+
+    merged := ComputeAppleStats(apples[:1])
+    merged.Merge(ComputeAppleStats(nil))
+    merged.Merge(ComputeAppleStats(apples[1:]))
+    fmt.Println(merged == all)
+
+    var empty AppleStats
+    empty.Merge(all)
+    fmt.Println(empty == all)
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        expected := "{Count:3 " +
+            "WeightSum:4 WeightMin:0 WeightMax:2.5 WeightMean:1.3333333333333333 WeightNonZero:2 " +
+            "SeedsSum:195 SeedsMin:-5 SeedsMax:100 SeedsMean:65 SeedsNonZero:3 " +
+            "StockSum:12 StockMin:0 StockMax:9 StockMean:4 StockNonZero:2}\n" +
+            "true\ntrue\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestGenerate_noFields(t *testing.T) {
+    s := morph.Struct{
+        Name:   "Person",
+        Fields: []morph.Field{{Name: "Name", Type: "string"}},
+    }
+    if _, _, err := stats.Generate(s, "$Stats", nil); err == nil {
+        t.Errorf("expected error for a struct without numeric fields")
+    }
+}
+
+func TestGenerate_collision(t *testing.T) {
+    // both fields map to XSum, XMin, etc.
+    s := morph.Struct{
+        Name:   "A",
+        Fields: []morph.Field{{Name: "X", Type: "int"}, {Name: "X", Type: "float64"}},
+    }
+    if _, _, err := stats.Generate(s, "$Stats", nil); err == nil {
+        t.Errorf("expected error for colliding statistic fields")
+    }
+}
@@ -0,0 +1,232 @@
+// Package typescript generates TypeScript declarations that describe the
+// JSON encoding of structs by encoding/json.
+package typescript
+
+import (
+    "encoding/json"
+    "fmt"
+    "go/token"
+    "strings"
+    "unicode"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+    "github.com/tawesoft/morph/tag"
+)
+
+// Options configure a generated declaration.
+type Options struct {
+    // Alias, if true, declares a type alias to an object type, instead of
+    // an interface.
+    Alias bool
+
+    // Types maps the name of a Go type, such as "Celsius", to a TypeScript
+    // type, such as "number", overriding the default TypeScript type for
+    // that Go type, if any.
+    Types map[string]string
+}
+
+// Interface returns an exported TypeScript interface declaration (or, with
+// [Options].Alias, a type alias) that describes the JSON encoding of a
+// struct by encoding/json.
+//
+// Each exported field is a property, using the key and options in the
+// field's "json" tag, if any, as documented by [encoding/json.Marshal], or
+// otherwise the field name. A field with the tag `json:"-"` is skipped. A
+// field with the "omitempty" option is an optional property. The struct's
+// comment, and each field's comment, is kept as a TSDoc comment. The type
+// parameters of a generic struct become type parameters of the declaration,
+// without their constraints.
+//
+// Go types are mapped to TypeScript types as follows:
+//
+//   - bool to boolean, and string to string;
+//   - integers and floats to number, or to string with the "string" option;
+//   - []byte and time.Time to string;
+//   - slices and arrays to an array of their elements;
+//   - maps to a Record with string keys;
+//   - pointers to their element type, or null;
+//   - any, interface{}, and json.RawMessage to unknown;
+//   - any other unqualified type name, such as the name of another struct
+//     or a type parameter, to itself.
+//
+// It is an error if a field has a qualified type, such as "big.Int", that
+// is not in [Options].Types. Embedded fields are not supported.
+//
+// As nil slices and maps are encoded as null, use the "omitempty" option,
+// or make sure they are not nil, to match the generated declaration.
+func Interface(s morph.Struct, options Options) (string, error) {
+    esc := func(err error) (string, error) {
+        return "", fmt.Errorf(
+            "error generating TypeScript declaration for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    var sb strings.Builder
+    sb.WriteString(comment(s.Comment, ""))
+
+    name := s.Name
+    if len(s.TypeParams) > 0 {
+        params := make([]string, len(s.TypeParams))
+        for i, tp := range s.TypeParams {
+            params[i] = tp.Name
+        }
+        name += "<" + strings.Join(params, ", ") + ">"
+    }
+    if options.Alias {
+        fmt.Fprintf(&sb, "export type %s = {\n", name)
+    } else {
+        fmt.Fprintf(&sb, "export interface %s {\n", name)
+    }
+
+    seen := make(map[string]string)
+    for _, f := range s.Fields {
+        if (f.Name == "") || (f.Name == f.Type) || strings.Contains(f.Name, ".") {
+            return esc(fmt.Errorf("embedded field %q is not supported", f.Type))
+        }
+        if r := []rune(f.Name)[0]; !unicode.IsUpper(r) { continue }
+
+        key, optional, asString := f.Name, false, false
+        if value, ok := tag.Lookup(f.Tag, "json"); ok {
+            if value == "-" { continue }
+            name, opts, _ := strings.Cut(value, ",")
+            if source.ValidJSONKey(name) { key = name }
+            for opts != "" {
+                var opt string
+                opt, opts, _ = strings.Cut(opts, ",")
+                switch opt {
+                case "omitempty": optional = true
+                case "string":    asString = true
+                }
+            }
+        }
+        if other, exists := seen[key]; exists {
+            return esc(fmt.Errorf("fields %q and %q have the same key %q", other, f.Name, key))
+        }
+        seen[key] = f.Name
+
+        Type, err := tsType(f.Type, options.Types)
+        if err != nil { return esc(fmt.Errorf("field %q: %w", f.Name, err)) }
+        if asString && isScalar(strings.TrimPrefix(f.Type, "*")) {
+            Type = "string"
+            if strings.HasPrefix(f.Type, "*") { Type += " | null" }
+        }
+
+        property := key
+        if !isIdentifier(key) {
+            quoted, _ := json.Marshal(key)
+            property = string(quoted)
+        }
+        if optional { property += "?" }
+
+        sb.WriteString(comment(f.Comment, "    "))
+        fmt.Fprintf(&sb, "    %s: %s;\n", property, Type)
+    }
+
+    if options.Alias {
+        sb.WriteString("};\n")
+    } else {
+        sb.WriteString("}\n")
+    }
+    return sb.String(), nil
+}
+
+// comment returns a comment as a TSDoc comment, with each line indented, or
+// the empty string for an empty comment.
+func comment(text string, indent string) string {
+    text = strings.TrimSpace(strings.ReplaceAll(text, "*/", "*\\/"))
+    if text == "" { return "" }
+    lines := strings.Split(text, "\n")
+    if len(lines) == 1 {
+        return indent + "/** " + lines[0] + " */\n"
+    }
+
+    var sb strings.Builder
+    sb.WriteString(indent + "/**\n")
+    for _, line := range lines {
+        if line == "" {
+            sb.WriteString(indent + " *\n")
+        } else {
+            sb.WriteString(indent + " * " + line + "\n")
+        }
+    }
+    sb.WriteString(indent + " */\n")
+    return sb.String()
+}
+
+// isScalar returns true for a Go type that the "string" option applies to.
+func isScalar(Type string) bool {
+    switch Type {
+    case "bool", "float32", "float64", "string",
+        "int", "int8", "int16", "int32", "int64", "rune",
+        "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte":
+        return true
+    }
+    return false
+}
+
+// tsType returns the TypeScript type for a Go type.
+func tsType(Type string, types map[string]string) (string, error) {
+    if t, ok := types[Type]; ok { return t, nil }
+
+    switch Type {
+    case "bool":
+        return "boolean", nil
+    case "string", "[]byte", "[]uint8", "time.Time":
+        return "string", nil
+    case "int", "int8", "int16", "int32", "int64", "rune",
+        "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte",
+        "float32", "float64":
+        return "number", nil
+    case "any", "interface{}", "json.RawMessage":
+        return "unknown", nil
+    }
+
+    // elem returns the TypeScript type for an element type, in parentheses
+    // if it is a union.
+    elem := func(Type string) (string, error) {
+        t, err := tsType(Type, types)
+        if err != nil { return "", err }
+        if strings.Contains(t, "|") { t = "(" + t + ")" }
+        return t, nil
+    }
+
+    switch {
+    case strings.HasPrefix(Type, "*"):
+        t, err := tsType(Type[1:], types)
+        if err != nil { return "", err }
+        if strings.HasSuffix(t, " | null") { return t, nil }
+        return t + " | null", nil
+    case strings.HasPrefix(Type, "["):
+        _, elemType, ok := strings.Cut(Type[1:], "]")
+        if !ok { break }
+        t, err := elem(elemType)
+        if err != nil { return "", err }
+        return t + "[]", nil
+    case strings.HasPrefix(Type, "map["):
+        _, elemType, ok := strings.Cut(Type[4:], "]")
+        if !ok { break }
+        t, err := tsType(elemType, types)
+        if err != nil { return "", err }
+        return "Record<string, " + t + ">", nil
+    case token.IsIdentifier(Type):
+        return Type, nil
+    }
+
+    return "", fmt.Errorf("unsupported type %q", Type)
+}
+
+// isIdentifier returns true if a property name can be used without quotes.
+func isIdentifier(s string) bool {
+    for i, c := range s {
+        switch {
+        case (c == '_') || (c == '$'):
+        case unicode.IsLetter(c):
+        case unicode.IsDigit(c) && (i > 0):
+        default:
+            return false
+        }
+    }
+    return s != ""
+}
@@ -0,0 +1,101 @@
+package typescript_test
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/typescript"
+    "github.com/tawesoft/morph/internal"
+)
+
+const source = `
+package example
+
+type Celsius float64
+
+// Person is a person.
+//
+// Comments may not contain */ unescaped.
+type Person struct {
+    // Name is the full name of the person.
+    Name      string
+    Nickname  *string            ` + "`json:\"nick,omitempty\"`" + `
+    Age       int8               ` + "`json:\"age\"`" + `
+    Count     uint64             ` + "`json:\",string\"`" + `
+    Temp      Celsius
+    Admin     bool               ` + "`json:\"admin,omitempty\"`" + `
+    Tags      [2]string
+    Scores    map[string][]*int  ` + "`json:\"scores,omitempty\"`" + `
+    Data      []byte
+    Born      time.Time
+    Home      *Address
+    Extra     any                ` + "`json:\"extra-data\"`" + `
+    Secret    string             ` + "`json:\"-\"`" + `
+    private   int
+}
+
+type Pair[K comparable, V any] struct {
+    Key   K
+    Value *V
+}
+`
+
+func TestInterface(t *testing.T) {
+    person := internal.Must(morph.ParseStruct("test.go", source, "Person"))
+    pair := internal.Must(morph.ParseStruct("test.go", source, "Pair"))
+
+    for _, tt := range []struct {
+        s        morph.Struct
+        options  typescript.Options
+        expected string
+    }{
+        {person, typescript.Options{Types: map[string]string{"Celsius": "number"}}, `
+/**
+ * Person is a person.
+ *
+ * Comments may not contain *\/ unescaped.
+ */
+export interface Person {
+    /** Name is the full name of the person. */
+    Name: string;
+    nick?: string | null;
+    age: number;
+    Count: string;
+    Temp: number;
+    admin?: boolean;
+    Tags: string[];
+    scores?: Record<string, (number | null)[]>;
+    Data: string;
+    Born: string;
+    Home: Address | null;
+    "extra-data": unknown;
+}
+`},
+        {pair, typescript.Options{Alias: true}, `
+export type Pair<K, V> = {
+    Key: K;
+    Value: V | null;
+};
+`},
+    } {
+        got, err := typescript.Interface(tt.s, tt.options)
+        if err != nil {
+            t.Errorf("struct %s: unexpected error: %v", tt.s.Name, err)
+        } else if got != strings.TrimPrefix(tt.expected, "\n") {
+            t.Errorf("struct %s: got:\n%s\nexpected:\n%s", tt.s.Name, got, tt.expected)
+        }
+    }
+}
+
+func TestInterface_errors(t *testing.T) {
+    for _, s := range []morph.Struct{
+        {Name: "A", Fields: []morph.Field{{Name: "X", Type: "big.Int"}}},
+        {Name: "B", Fields: []morph.Field{{Name: "X", Type: "int"}, {Name: "Y", Type: "int", Tag: `json:"X"`}}},
+        {Name: "C", Fields: []morph.Field{{Name: "Point", Type: "Point"}}},
+    } {
+        if _, err := typescript.Interface(s, typescript.Options{}); err == nil {
+            t.Errorf("expected error for struct %s", s.Name)
+        }
+    }
+}
@@ -0,0 +1,347 @@
+// Package vecmath generates component-wise arithmetic methods for structs of
+// numeric fields, such as vectors and colours.
+//
+// For a struct Vec3, with a scalar type T, [Generate] returns the methods:
+//
+//     func (vec3 Vec3) Add(other Vec3) Vec3
+//     func (vec3 Vec3) Sub(other Vec3) Vec3
+//     func (vec3 Vec3) Mul(other Vec3) Vec3
+//     func (vec3 Vec3) Scale(t T) Vec3
+//     func (vec3 Vec3) Lerp(other Vec3, t T) Vec3
+//     func (vec3 Vec3) Dot(other Vec3) T
+//     func (vec3 Vec3) Min(other Vec3) Vec3
+//     func (vec3 Vec3) Max(other Vec3) Vec3
+//     func (vec3 Vec3) Clamp(lo Vec3, hi Vec3) Vec3
+//     func (vec3 Vec3) Abs() Vec3
+//
+// Generic structs, like Vec[T constraints.Float], are supported.
+//
+// Each operation is described by a [morph.FieldExpressionType] (for example,
+// [Add]), and may be customised for any field by setting a custom
+// [morph.FieldExpression] of that type on the field. For example, to
+// saturate the addition of a colour's uint8 channel:
+//
+//     field.SetCustomExpression(morph.FieldExpression{
+//         Type:    vecmath.Add,
+//         Pattern: "$dest.$ = $a.$ + $b.$\nif $dest.$ < $a.$ { $dest.$ = 255 }",
+//     })
+//
+// A pattern is one or more Go statements that assign to the component of the
+// result, "$dest.$". Patterns may contain the following tokens:
+//
+//   - "$dest.$" is the current field on the result, which starts as a copy of
+//     the receiver.
+//   - "$a.$" is the current field on the receiver.
+//   - "$b.$" is the current field on the other argument, for operations that
+//     have one.
+//   - "$t" is the scalar argument of Scale and Lerp.
+//   - "$type", or "$a.$.$type" etc., is the type of the current field.
+//
+// As for [morph.FieldExpression], tokens are not replaced inside string
+// literals, and "$a" alone, or a named field such as "$a.X", is an error.
+//
+// If a pattern is "skip", the result's field is left unchanged as a copy of
+// the receiver's field.
+//
+// The [Dot] pattern is different: it is an expression of "$a.$" and "$b.$",
+// and the result folds each field's expression, converted to the scalar
+// type, with the Dot FieldExpressionType's Collect expression (a sum). Dot
+// is generated with a [morph.FieldExpressionTypeRegistry], so it supports
+// every token described by [morph.FieldExpression].
+//
+// The other operations use the tokens above, which name the result as well
+// as both operands, so they are generated by this package instead.
+//
+// Clamp has no FieldExpressionType of its own: it calls Max and then Min, so
+// it uses their patterns.
+package vecmath
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/internal/source"
+    "github.com/tawesoft/morph/internal"
+)
+
+// FieldExpressionTypes for each operation, with their default patterns.
+var (
+    Add = &morph.FieldExpressionType{
+        Name:    "VecAdd",
+        Targets: 2,
+        Type:    morph.FieldExpressionTypeValue,
+        Default: "$dest.$ = $a.$ + $b.$",
+        Comment: "Add returns the component-wise sum of two [$a.$type] values.",
+    }
+    Sub = &morph.FieldExpressionType{
+        Name:    "VecSub",
+        Targets: 2,
+        Type:    morph.FieldExpressionTypeValue,
+        Default: "$dest.$ = $a.$ - $b.$",
+        Comment: "Sub returns the component-wise difference of two [$a.$type] values.",
+    }
+    Mul = &morph.FieldExpressionType{
+        Name:    "VecMul",
+        Targets: 2,
+        Type:    morph.FieldExpressionTypeValue,
+        Default: "$dest.$ = $a.$ * $b.$",
+        Comment: "Mul returns the component-wise product of two [$a.$type] values.",
+    }
+    Scale = &morph.FieldExpressionType{
+        Name:    "VecScale",
+        Targets: 1,
+        Type:    morph.FieldExpressionTypeValue,
+        Default: "$dest.$ = $a.$ * $type($t)",
+        Comment: "Scale returns a [$a.$type] value with each component multiplied by t.",
+    }
+    Lerp = &morph.FieldExpressionType{
+        Name:    "VecLerp",
+        Targets: 2,
+        Type:    morph.FieldExpressionTypeValue,
+        Default: "$dest.$ = $a.$ + ($b.$ - $a.$) * $type($t)",
+        Comment: "Lerp returns the component-wise linear interpolation between two [$a.$type] values, where t is\n" +
+            "between 0 (for the receiver) and 1 (for other).",
+    }
+    Dot = &morph.FieldExpressionType{
+        Name:    "VecDot",
+        Targets: 2,
+        Type:    morph.FieldExpressionTypeAggregate,
+        Default: "$a.$ * $b.$",
+        Collect: "$acc + $value",
+        Comment: "Dot returns the dot product of two [$a.$type] values.",
+    }
+    Min = &morph.FieldExpressionType{
+        Name:    "VecMin",
+        Targets: 2,
+        Type:    morph.FieldExpressionTypeValue,
+        Default: "if $b.$ < $a.$ { $dest.$ = $b.$ }",
+        Comment: "Min returns the component-wise minimum of two [$a.$type] values.",
+    }
+    Max = &morph.FieldExpressionType{
+        Name:    "VecMax",
+        Targets: 2,
+        Type:    morph.FieldExpressionTypeValue,
+        Default: "if $b.$ > $a.$ { $dest.$ = $b.$ }",
+        Comment: "Max returns the component-wise maximum of two [$a.$type] values.",
+    }
+    Abs = &morph.FieldExpressionType{
+        Name:    "VecAbs",
+        Targets: 1,
+        Type:    morph.FieldExpressionTypeValue,
+        Default: "if $a.$ < 0 { $dest.$ = -$a.$ }",
+        Comment: "Abs returns a [$a.$type] value with the absolute value of each component.",
+    }
+)
+
+// registry finds the FieldExpressionType for [Dot].
+var registry = func() *morph.FieldExpressionTypeRegistry {
+    r := morph.NewFieldExpressionTypeRegistry(nil)
+    internal.Assert(r.Register(Dot))
+    return r
+}()
+
+// Generate returns the methods described in the package documentation for
+// the given struct.
+//
+// The scalar argument is the type of the t argument of Scale and Lerp, and
+// of the result of Dot. If empty, it defaults to the struct's type parameter
+// (if it has exactly one), or otherwise to the type shared by every field.
+// It is an error if neither exist.
+func Generate(s morph.Struct, scalar string) ([]morph.Function, error) {
+    esc := func(err error) ([]morph.Function, error) {
+        return nil, fmt.Errorf(
+            "error generating vecmath functions for struct %q: %w",
+            s.Name, err,
+        )
+    }
+
+    if scalar == "" {
+        var ok bool
+        scalar, ok = defaultScalar(s)
+        if !ok {
+            return esc(fmt.Errorf("cannot infer scalar type"))
+        }
+    }
+
+    g := generator{
+        s:        s,
+        scalar:   scalar,
+        receiver: source.Receiver(s.Name, "other", "t", "lo", "hi"),
+    }
+    self := morph.Argument{Type: s.Type()}
+    other := morph.Argument{Name: "other", Type: s.Type()}
+    t := morph.Argument{Name: "t", Type: scalar}
+
+    var functions []morph.Function
+    for _, op := range []struct {
+        fet  *morph.FieldExpressionType
+        args []morph.Argument
+    }{
+        {Add,   []morph.Argument{other}},
+        {Sub,   []morph.Argument{other}},
+        {Mul,   []morph.Argument{other}},
+        {Scale, []morph.Argument{t}},
+        {Lerp,  []morph.Argument{other, t}},
+        {Min,   []morph.Argument{other}},
+        {Max,   []morph.Argument{other}},
+        {Abs,   nil},
+    } {
+        fn, err := g.componentwise(op.fet, op.args, self)
+        if err != nil { return esc(err) }
+        functions = append(functions, fn)
+    }
+    functions = append(functions, g.clamp())
+
+    fn, err := g.dot()
+    if err != nil { return esc(err) }
+    functions = append(functions, fn)
+
+    return functions, nil
+}
+
+// defaultScalar returns the default scalar type of a struct, as described
+// by [Generate].
+func defaultScalar(s morph.Struct) (string, bool) {
+    if len(s.TypeParams) == 1 {
+        return s.TypeParams[0].Name, true
+    }
+    if len(s.TypeParams) > 1 { return "", false }
+
+    first, ok := internal.First(s.Fields)
+    if !ok { return "", false }
+    for _, f := range s.Fields {
+        if f.Type != first.Type { return "", false }
+    }
+    return first.Type, true
+}
+
+type generator struct {
+    s        morph.Struct
+    scalar   string
+    receiver string
+}
+
+// name returns the method name for an operation e.g. "Add" for "VecAdd".
+func name(fet *morph.FieldExpressionType) string {
+    return strings.TrimPrefix(fet.Name, "Vec")
+}
+
+// pattern returns the pattern for an operation on a field.
+func pattern(fet *morph.FieldExpressionType, f morph.Field) (string, error) {
+    fe := f.GetCustomExpression(fet.Name)
+    if fe == nil { return fet.Default, nil }
+    if fe.Type != fet {
+        return "", morph.FieldExpressionTypeConflictError{Name: fet.Name}
+    }
+    if fe.Pattern == "" { return fet.Default, nil }
+    return fe.Pattern, nil
+}
+
+// format replaces the tokens described in the package documentation in a
+// pattern for a field, for an operation with the given arguments.
+func (g generator) format(p string, f morph.Field, args []morph.Argument) (string, error) {
+    values := map[string]string{
+        "dest": "_out." + f.Name,
+        "a":    g.receiver + "." + f.Name,
+    }
+    hasT := false
+    for _, arg := range args {
+        switch arg.Name {
+        case "other": values["b"] = "other." + f.Name
+        case "t":     hasT = true
+        }
+    }
+
+    // a target token that must be followed by ".$"
+    const unresolved = "\x00"
+    tr := internal.TokenReplacer{
+        ByName: func(name string) (string, bool) {
+            if name == "type" { return f.Type, true }
+            if (name == "t") && hasT { return "t", true }
+            if _, ok := values[name]; ok { return unresolved + name, true }
+            return "", false
+        },
+        Modifier: func(kw string, target string) (string, bool) {
+            if strings.HasPrefix(target, unresolved) {
+                if kw != "" { return "", false }
+                return values[strings.TrimPrefix(target, unresolved)], true
+            }
+            if kw == "type" {
+                for _, value := range values {
+                    if target == value { return f.Type, true }
+                }
+            }
+            return "", false
+        },
+    }
+    tr.SetDefaults()
+    out, err := tr.Replace(p)
+    if err != nil {
+        return "", fmt.Errorf("unsupported pattern %q for field %q: %w", p, f.Name, err)
+    }
+    if strings.Contains(out, unresolved) {
+        return "", fmt.Errorf("unsupported pattern %q for field %q: only the current field of a value, e.g. %q, is available",
+            p, f.Name, "$a.$")
+    }
+    return out, nil
+}
+
+// comment returns the comment for a method.
+func (g generator) comment(fet *morph.FieldExpressionType) string {
+    return strings.ReplaceAll(fet.Comment, "$a.$type", g.s.Name)
+}
+
+func (g generator) signature(fet *morph.FieldExpressionType, args []morph.Argument, returns morph.Argument) morph.FunctionSignature {
+    return morph.FunctionSignature{
+        Comment:   g.comment(fet),
+        Name:      name(fet),
+        Arguments: args,
+        Returns:   []morph.Argument{returns},
+        Receiver:  morph.Argument{Name: g.receiver, Type: g.s.Type()},
+    }
+}
+
+func (g generator) componentwise(fet *morph.FieldExpressionType, args []morph.Argument, returns morph.Argument) (morph.Function, error) {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "_out := %s\n", g.receiver)
+    for _, f := range g.s.Fields {
+        p, err := pattern(fet, f)
+        if err != nil { return morph.Function{}, err }
+        if p == "skip" { continue }
+        code, err := g.format(p, f, args)
+        if err != nil { return morph.Function{}, err }
+        fmt.Fprintf(&sb, "\n// %s\n%s\n", f.Name, code)
+    }
+    sb.WriteString("\nreturn _out")
+
+    return morph.Function{
+        Signature: g.signature(fet, args, returns),
+        Body:      sb.String(),
+    }, nil
+}
+
+// clamp generates Clamp, with the Max and Min methods.
+func (g generator) clamp() morph.Function {
+    return morph.Function{
+        Signature: morph.FunctionSignature{
+            Comment: fmt.Sprintf("Clamp returns a [%s] value with each component limited to the range given by the\n"+
+                "matching components of lo and hi (inclusive).", g.s.Name),
+            Name: "Clamp",
+            Arguments: []morph.Argument{
+                {Name: "lo", Type: g.s.Type()},
+                {Name: "hi", Type: g.s.Type()},
+            },
+            Returns:  []morph.Argument{{Type: g.s.Type()}},
+            Receiver: morph.Argument{Name: g.receiver, Type: g.s.Type()},
+        },
+        Body: fmt.Sprintf("return %s.%s(lo).%s(hi)", g.receiver, name(Max), name(Min)),
+    }
+}
+
+// dot generates Dot with morph's aggregate FieldExpressionType machinery.
+func (g generator) dot() (morph.Function, error) {
+    signature := fmt.Sprintf("(%s %s) %s(other %s) %s",
+        g.receiver, g.s.Type(), name(Dot), g.s.Type(), g.scalar)
+    return registry.BinaryFunction(g.s, Dot.Name, signature, g.s)
+}
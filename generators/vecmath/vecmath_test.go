@@ -0,0 +1,150 @@
+package vecmath_test
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/vecmath"
+    "github.com/tawesoft/morph/internal"
+)
+
+func TestGenerate(t *testing.T) {
+    source := `
+package example
+
+type Float interface {
+    ~float32 | ~float64
+}
+
+type Vec[T Float] struct {
+    X, Y, Z T
+}
+
+type RGBA struct {
+    R, G, B, A uint8
+}
+`
+    vec := internal.Must(morph.ParseStruct("test.go", source, "Vec"))
+    rgba := internal.Must(morph.ParseStruct("test.go", source, "RGBA"))
+
+    // saturate colour addition, and leave alpha alone
+    for i := range rgba.Fields {
+        f := &rgba.Fields[i]
+        if f.Name == "A" {
+            f.SetCustomExpression(morph.FieldExpression{Type: vecmath.Add, Pattern: "skip"})
+            continue
+        }
+        f.SetCustomExpression(morph.FieldExpression{
+            Type:    vecmath.Add,
+            Pattern: "$dest.$ = $a.$ + $b.$\nif $dest.$ < $a.$ { $dest.$ = 255 }",
+        })
+    }
+
+    vecFunctions, err := vecmath.Generate(vec, "")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    rgbaFunctions, err := vecmath.Generate(rgba, "")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    var sb strings.Builder
+    sb.WriteString("package main\n\nimport \"fmt\"\n\n")
+    sb.WriteString("type Float interface {\n~float32 | ~float64\n}\n\n")
+    sb.WriteString(vec.String() + "\n\n")
+    sb.WriteString(rgba.String() + "\n\n")
+    for _, f := range append(vecFunctions, rgbaFunctions...) {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(`
+func main() {
+    a := Vec[float64]{1, -2, 3}
+    b := Vec[float64]{4, 5, -6}
+    fmt.Println(a.Add(b), a.Sub(b), a.Mul(b))
+    fmt.Println(a.Scale(2), a.Lerp(b, 0.5), a.Dot(b))
+    fmt.Println(a.Min(b), a.Max(b), a.Abs())
+    fmt.Println(a.Clamp(Vec[float64]{0, 0, 0}, Vec[float64]{2, 2, 2}))
+
+    c := RGBA{200, 10, 0, 128}
+    d := RGBA{100, 20, 5, 255}
+    fmt.Println(c.Add(d))
+}
+`)
+
+    internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+        expected := "{5 3 -3} {-3 -7 9} {4 -10 -18}\n" +
+            "{2 -4 6} {2.5 1.5 -1.5} -24\n" +
+            "{1 -2 -6} {4 5 3} {1 2 3}\n" +
+            "{1 0 2}\n" +
+            "{255 30 5 128}\n"
+        if stdout != expected {
+            return fmt.Errorf("got %q, expected %q", stdout, expected)
+        }
+        return nil
+    })
+}
+
+func TestGenerate_errors(t *testing.T) {
+    mixed := morph.Struct{
+        Name: "Mixed",
+        Fields: []morph.Field{
+            {Name: "X", Type: "float32"},
+            {Name: "Y", Type: "float64"},
+        },
+    }
+    if _, err := vecmath.Generate(mixed, ""); err == nil {
+        t.Errorf("expected error inferring scalar type of mixed fields")
+    }
+    if _, err := vecmath.Generate(mixed, "float64"); err != nil {
+        t.Errorf("unexpected error with explicit scalar type: %v", err)
+    }
+
+    for _, tt := range []struct {
+        fet     *morph.FieldExpressionType
+        pattern string
+    }{
+        {vecmath.Add,   "$dest.$ = $unknown"},
+        {vecmath.Add,   "$dest.$ = $a.$ + $b.X"},
+        {vecmath.Add,   "$dest.$ = $a.$ * $type($t)"},
+        {vecmath.Abs,   "$dest.$ = $b.$"},
+        {vecmath.Scale, "$dest.$ = $a"},
+    } {
+        mixed.Fields[0].SetCustomExpression(morph.FieldExpression{Type: tt.fet, Pattern: tt.pattern})
+        if _, err := vecmath.Generate(mixed, "float64"); err == nil {
+            t.Errorf("expected error for pattern %q", tt.pattern)
+        }
+        mixed.Fields[0].Custom = nil
+    }
+}
+
+func TestGenerate_receiverName(t *testing.T) {
+    for _, name := range []string{"T", "Other", "Lo"} {
+        s := morph.Struct{Name: name, Fields: []morph.Field{{Name: "X", Type: "float64"}}}
+        functions, err := vecmath.Generate(s, "")
+        if err != nil { t.Fatalf("unexpected error: %v", err) }
+
+        var sb strings.Builder
+        sb.WriteString("package main\n\nimport \"fmt\"\n\n")
+        sb.WriteString(s.String() + "\n\n")
+        for _, f := range functions {
+            sb.WriteString(f.String() + "\n\n")
+        }
+        fmt.Fprintf(&sb, `
+func main() {
+    a := %s{X: 3}
+    fmt.Println(a.Lerp(%s{X: 5}, 0.5), a.Clamp(%s{X: 0}, %s{X: 1}), a.Dot(a))
+}
+`, name, name, name, name)
+
+        internal.TestCompileAndRun(t, sb.String(), func(stdout string) error {
+            expected := "{4} {1} 9\n"
+            if stdout != expected {
+                return fmt.Errorf("got %q, expected %q", stdout, expected)
+            }
+            return nil
+        })
+    }
+}
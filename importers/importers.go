@@ -0,0 +1,72 @@
+// Package importers creates [morph.Struct] values from schemas in other
+// languages, such as JSON Schema and SQL, or from sample data, so that Go
+// types, and functions that convert between them, can be generated from
+// schemas that are not written in Go.
+package importers
+
+import (
+    "strings"
+    "unicode"
+)
+
+// initialisms are the words that are written in upper case in a Go name.
+var initialisms = map[string]bool{
+    "api": true, "ascii": true, "cpu": true, "css": true, "dns": true,
+    "eof": true, "guid": true, "html": true, "http": true, "https": true,
+    "id": true, "ip": true, "json": true, "sql": true, "tcp": true,
+    "tls": true, "ttl": true, "udp": true, "ui": true, "uid": true,
+    "uri": true, "url": true, "utf8": true, "uuid": true, "xml": true,
+}
+
+// goName converts a name in another language, such as "user_id" or
+// "streetName", to an exported Go name, such as "UserID" or "StreetName".
+func goName(name string) string {
+    words := strings.FieldsFunc(name, func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+
+    var sb strings.Builder
+    for _, word := range words {
+        for _, word := range camelWords(word) {
+            if initialisms[strings.ToLower(word)] {
+                sb.WriteString(strings.ToUpper(word))
+                continue
+            }
+            rs := []rune(word)
+            sb.WriteRune(unicode.ToUpper(rs[0]))
+            sb.WriteString(string(rs[1:]))
+        }
+    }
+
+    result := sb.String()
+    if (result == "") || !unicode.IsLetter([]rune(result)[0]) {
+        result = "X" + result
+    }
+    return result
+}
+
+// camelWords splits a word at each upper case letter that follows a lower
+// case letter or digit e.g. "userId" to "user", "Id".
+func camelWords(word string) []string {
+    rs := []rune(word)
+    var words []string
+    start := 0
+    for i := 1; i < len(rs); i++ {
+        prev := rs[i-1]
+        if unicode.IsUpper(rs[i]) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+            words = append(words, string(rs[start:i]))
+            start = i
+        }
+    }
+    return append(words, string(rs[start:]))
+}
+
+// nilable returns true if a Go type already has a nil value, and so is not
+// made a pointer for a value that may be missing or null.
+func nilable(Type string) bool {
+    return strings.HasPrefix(Type, "*") ||
+        strings.HasPrefix(Type, "[]") ||
+        strings.HasPrefix(Type, "map[") ||
+        (Type == "any") ||
+        (Type == "json.RawMessage")
+}
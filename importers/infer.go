@@ -0,0 +1,217 @@
+package importers
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "time"
+
+    "github.com/tawesoft/morph"
+)
+
+// kinds of JSON values seen in samples, as bits.
+const (
+    kindNull = 1 << iota
+    kindBool
+    kindInt
+    kindFloat
+    kindString
+    kindArray
+    kindObject
+)
+
+// shape is the merged shape of every sample JSON value at the same path.
+type shape struct {
+    kinds   int
+    notTime bool // a string that is not an RFC 3339 time
+
+    // arrays
+    elem *shape
+
+    // objects
+    objects int // number of objects
+    fields  []*fieldShape
+    byKey   map[string]*fieldShape
+}
+
+// fieldShape is the shape of the values of a key in objects.
+type fieldShape struct {
+    key   string
+    count int // number of objects with the key
+    shape shape
+}
+
+// decode reads the next JSON value and merges it into the shape.
+func (s *shape) decode(decoder *json.Decoder) error {
+    t, err := decoder.Token()
+    if err != nil { return err }
+
+    switch v := t.(type) {
+    case nil:
+        s.kinds |= kindNull
+    case bool:
+        s.kinds |= kindBool
+    case json.Number:
+        if _, err := v.Int64(); err == nil {
+            s.kinds |= kindInt
+        } else {
+            s.kinds |= kindFloat
+        }
+    case string:
+        s.kinds |= kindString
+        if _, err := time.Parse(time.RFC3339Nano, v); err != nil { s.notTime = true }
+    case json.Delim:
+        switch v {
+        case '[':
+            s.kinds |= kindArray
+            if s.elem == nil { s.elem = &shape{} }
+            for decoder.More() {
+                if err := s.elem.decode(decoder); err != nil { return err }
+            }
+        case '{':
+            s.kinds |= kindObject
+            s.objects++
+            if s.byKey == nil { s.byKey = make(map[string]*fieldShape) }
+            for decoder.More() {
+                t, err := decoder.Token()
+                if err != nil { return err }
+                key := t.(string)
+                f, ok := s.byKey[key]
+                if !ok {
+                    f = &fieldShape{key: key}
+                    s.fields = append(s.fields, f)
+                    s.byKey[key] = f
+                }
+                f.count++
+                if err := f.shape.decode(decoder); err != nil { return err }
+            }
+        }
+        if _, err := decoder.Token(); err != nil { return err } // closing delimiter
+    }
+    return nil
+}
+
+// FromJSONSamples infers structs from one or more sample JSON documents.
+//
+// Each sample must be an object, or an array of objects, each of which is
+// also a sample. The first struct, given the name, describes every sample.
+// It is followed by a struct for each nested object, given the name of its
+// parent and key, with the suffix "Item" for objects in an array. Names are
+// converted to exported Go names e.g. "street_name" to "StreetName".
+//
+// Each key is a field, with a "json" tag for the key. The types of the
+// values with the same key, in every sample, are merged as follows:
+//
+//   - booleans to bool;
+//   - numbers to int64, or to float64 if any is not an integer;
+//   - strings to time.Time, if every one is an RFC 3339 time, or to string;
+//   - arrays to a slice of the merged type of their elements;
+//   - objects to a struct of their merged keys, or to map[string]any if
+//     they are all empty;
+//   - a mixture of these, or only null or empty arrays, to any.
+//
+// A field for a key that is null in any sample is a pointer, and a field
+// for a key that is missing in any sample is a pointer, with the
+// "omitempty" option, unless its type is already a slice, map, pointer or
+// any.
+func FromJSONSamples(name string, samples ... []byte) ([]morph.Struct, error) {
+    esc := func(err error) ([]morph.Struct, error) {
+        return nil, fmt.Errorf("error inferring struct %q from JSON samples: %w", name, err)
+    }
+
+    var root shape
+    for i, sample := range samples {
+        decoder := json.NewDecoder(bytes.NewReader(sample))
+        decoder.UseNumber()
+
+        sample = bytes.TrimSpace(sample)
+        if bytes.HasPrefix(sample, []byte("[")) {
+            if _, err := decoder.Token(); err != nil { return esc(fmt.Errorf("sample %d: %w", i, err)) }
+            for decoder.More() {
+                if err := root.decode(decoder); err != nil { return esc(fmt.Errorf("sample %d: %w", i, err)) }
+            }
+            if _, err := decoder.Token(); err != nil { return esc(fmt.Errorf("sample %d: %w", i, err)) }
+        } else if err := root.decode(decoder); err != nil {
+            return esc(fmt.Errorf("sample %d: %w", i, err))
+        }
+        if _, err := decoder.Token(); err != io.EOF {
+            return esc(fmt.Errorf("sample %d: unexpected data after JSON value", i))
+        }
+        if root.kinds != kindObject {
+            return esc(fmt.Errorf("sample %d: not an object or an array of objects", i))
+        }
+    }
+    if root.objects == 0 { return esc(fmt.Errorf("no samples")) }
+
+    i := &sampleImporter{names: make(map[string]bool)}
+    if err := i.object(goName(name), &root); err != nil { return esc(err) }
+    return i.structs, nil
+}
+
+type sampleImporter struct {
+    names   map[string]bool
+    structs []morph.Struct
+}
+
+// object adds a struct for the shape of objects.
+func (i *sampleImporter) object(name string, s *shape) error {
+    if i.names[name] { return fmt.Errorf("more than one struct named %q", name) }
+    i.names[name] = true
+    index := len(i.structs)
+    i.structs = append(i.structs, morph.Struct{Name: name})
+
+    var fields []morph.Field
+    seen := make(map[string]string)
+    for _, f := range s.fields {
+        fieldName := goName(f.key)
+        if other, exists := seen[fieldName]; exists {
+            return fmt.Errorf("keys %q and %q of %q have the same Go name %q", other, f.key, name, fieldName)
+        }
+        seen[fieldName] = f.key
+
+        Type, err := i.goType(name + fieldName, &f.shape)
+        if err != nil { return err }
+        missing := f.count < s.objects
+        if ((f.shape.kinds & kindNull) != 0) || missing {
+            if !nilable(Type) { Type = "*" + Type }
+        }
+
+        tag := f.key
+        if missing { tag += ",omitempty" }
+        fields = append(fields, morph.Field{
+            Name: fieldName,
+            Type: Type,
+            Tag:  fmt.Sprintf("json:%q", tag),
+        })
+    }
+    i.structs[index].Fields = fields
+    return nil
+}
+
+// goType returns the Go type for a shape, ignoring null. The name is used for
+// a struct for objects.
+func (i *sampleImporter) goType(name string, s *shape) (string, error) {
+    switch s.kinds &^ kindNull {
+    case kindBool:
+        return "bool", nil
+    case kindInt:
+        return "int64", nil
+    case kindInt | kindFloat, kindFloat:
+        return "float64", nil
+    case kindString:
+        if !s.notTime { return "time.Time", nil }
+        return "string", nil
+    case kindArray:
+        if (s.elem.kinds &^ kindNull) == 0 { return "[]any", nil }
+        elem, err := i.goType(name + "Item", s.elem)
+        if err != nil { return "", err }
+        if ((s.elem.kinds & kindNull) != 0) && !nilable(elem) { elem = "*" + elem }
+        return "[]" + elem, nil
+    case kindObject:
+        if len(s.fields) == 0 { return "map[string]any", nil }
+        if err := i.object(name, s); err != nil { return "", err }
+        return name, nil
+    }
+    return "any", nil
+}
@@ -0,0 +1,100 @@
+package importers_test
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph/importers"
+)
+
+func TestFromJSONSamples(t *testing.T) {
+    samples := []string{
+        `{
+            "id": 1,
+            "user-name": "alice",
+            "score": 10,
+            "created_at": "2020-01-02T03:04:05Z",
+            "tags": ["a", "b"],
+            "address": {"street": "High St", "zip": "AB1"},
+            "orders": [{"sku": "x", "qty": 1}, {"sku": "y", "qty": 2, "gift": true}],
+            "meta": {},
+            "extra": null,
+            "mixed": 1,
+            "empty": []
+        }`,
+        `[{
+            "id": 2,
+            "user-name": "bob",
+            "score": 2.5,
+            "created_at": "yesterday",
+            "tags": [],
+            "address": {"street": "Low St", "zip": null},
+            "orders": [],
+            "meta": {},
+            "extra": null,
+            "mixed": "one",
+            "empty": [],
+            "nickname": "B"
+        }]`,
+    }
+
+    var docs [][]byte
+    for _, s := range samples { docs = append(docs, []byte(s)) }
+    structs, err := importers.FromJSONSamples("user", docs...)
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+
+    var got []string
+    for _, s := range structs {
+        got = append(got, s.String())
+    }
+    expected := strings.TrimSpace("\n" + `
+type User struct {
+	ID        int64            ` + "`json:\"id\"`" + `
+	UserName  string           ` + "`json:\"user-name\"`" + `
+	Score     float64          ` + "`json:\"score\"`" + `
+	CreatedAt string           ` + "`json:\"created_at\"`" + `
+	Tags      []string         ` + "`json:\"tags\"`" + `
+	Address   UserAddress      ` + "`json:\"address\"`" + `
+	Orders    []UserOrdersItem ` + "`json:\"orders\"`" + `
+	Meta      map[string]any   ` + "`json:\"meta\"`" + `
+	Extra     any              ` + "`json:\"extra\"`" + `
+	Mixed     any              ` + "`json:\"mixed\"`" + `
+	Empty     []any            ` + "`json:\"empty\"`" + `
+	Nickname  *string          ` + "`json:\"nickname,omitempty\"`" + `
+}
+type UserAddress struct {
+	Street string  ` + "`json:\"street\"`" + `
+	Zip    *string ` + "`json:\"zip\"`" + `
+}
+type UserOrdersItem struct {
+	Sku  string ` + "`json:\"sku\"`" + `
+	Qty  int64  ` + "`json:\"qty\"`" + `
+	Gift *bool  ` + "`json:\"gift,omitempty\"`" + `
+}`)
+    if strings.Join(got, "\n") != expected {
+        t.Errorf("got:\n%s\nexpected:\n%s", strings.Join(got, "\n"), expected)
+    }
+
+    structs, err = importers.FromJSONSamples("event", []byte(`{"at": "2020-01-02T03:04:05.5+01:00"}`))
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+    if structs[0].Fields[0].Type != "time.Time" {
+        t.Errorf("got type %s, expected time.Time", structs[0].Fields[0].Type)
+    }
+}
+
+func TestFromJSONSamples_errors(t *testing.T) {
+    for _, samples := range [][]string{
+        {},
+        {`[1, 2]`},
+        {`"x"`},
+        {`{"a": 1} {"b": 2}`},
+        {`{"a": 1`},
+        {`{"a-b": 1, "a_b": 2}`},
+    } {
+        var docs [][]byte
+        for _, s := range samples { docs = append(docs, []byte(s)) }
+        if _, err := importers.FromJSONSamples("x", docs...); err == nil {
+            t.Errorf("expected error for %v", samples)
+        }
+    }
+}
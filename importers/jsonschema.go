@@ -0,0 +1,332 @@
+package importers
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "math"
+    "strings"
+
+    "github.com/tawesoft/morph"
+)
+
+// schema is the subset of a JSON Schema that describes a Go type.
+type schema struct {
+    Ref                  string          `json:"$ref"`
+    Type                 json.RawMessage `json:"type"`
+    Title                string          `json:"title"`
+    Description          string          `json:"description"`
+    Format               string          `json:"format"`
+    ContentEncoding      string          `json:"contentEncoding"`
+    Properties           schemas         `json:"properties"`
+    Required             []string        `json:"required"`
+    AdditionalProperties json.RawMessage `json:"additionalProperties"`
+    Items                json.RawMessage `json:"items"`
+    MinItems             *int            `json:"minItems"`
+    MaxItems             *int            `json:"maxItems"`
+    Minimum              *float64        `json:"minimum"`
+    Maximum              *float64        `json:"maximum"`
+    AnyOf                []schema        `json:"anyOf"`
+    OneOf                []schema        `json:"oneOf"`
+    Defs                 schemas         `json:"$defs"`
+    Definitions          schemas         `json:"definitions"`
+}
+
+// namedSchema is a member of a JSON object of schemas.
+type namedSchema struct {
+    name   string
+    schema schema
+}
+
+// schemas is a JSON object of schemas, in order.
+type schemas []namedSchema
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (xs *schemas) UnmarshalJSON(data []byte) error {
+    decoder := json.NewDecoder(bytes.NewReader(data))
+    if t, err := decoder.Token(); err != nil {
+        return err
+    } else if t != json.Delim('{') {
+        return fmt.Errorf("expected an object of schemas")
+    }
+    for decoder.More() {
+        t, err := decoder.Token()
+        if err != nil { return err }
+        var s schema
+        if err := decoder.Decode(&s); err != nil { return err }
+        *xs = append(*xs, namedSchema{t.(string), s})
+    }
+    return nil
+}
+
+// types returns the names in the schema's "type" keyword, without "null",
+// and whether "null" is one of them.
+func (s schema) types() ([]string, bool, error) {
+    if len(s.Type) == 0 { return nil, false, nil }
+    var types []string
+    var one string
+    if err := json.Unmarshal(s.Type, &one); err == nil {
+        types = []string{one}
+    } else if err := json.Unmarshal(s.Type, &types); err != nil {
+        return nil, false, fmt.Errorf("invalid type %s", s.Type)
+    }
+
+    var result []string
+    var null bool
+    for _, t := range types {
+        if t == "null" {
+            null = true
+        } else {
+            result = append(result, t)
+        }
+    }
+    return result, null, nil
+}
+
+// isNull returns true for the schema {"type": "null"}.
+func (s schema) isNull() bool {
+    types, null, _ := s.types()
+    return null && (len(types) == 0)
+}
+
+// isObject returns true for a schema of an object with properties, which is
+// described by a struct.
+func (s schema) isObject() bool {
+    types, _, _ := s.types()
+    if len(types) == 0 { return len(s.Properties) > 0 }
+    return (len(types) == 1) && (types[0] == "object") && (len(s.Properties) > 0)
+}
+
+// FromJSONSchema returns structs described by a JSON Schema document.
+//
+// The first struct is described by the root schema, which must be an
+// object with properties, and is given the name, if not empty, or otherwise
+// the name from the schema's "title". It is followed by a struct for each object
+// under "$defs" (or "definitions"), given a name from its key, and a struct
+// for each object with properties that is nested in another, given the
+// name of its parent and property. Names are converted to exported Go
+// names e.g. "street_name" to "StreetName".
+//
+// Each property is a field, with a "json" tag for the property key, with
+// the "omitempty" option if the property is not required. The
+// "description" of each object and property is its comment.
+//
+// Types are mapped to Go types as follows:
+//
+//   - boolean to bool, and number to float64;
+//   - integer to the smallest of int8, int16, int32 or int64 that fits its
+//     minimum and maximum, or the smallest unsigned type if its minimum is
+//     at least zero, or int64 without bounds;
+//   - string to string, or time.Time with the "date-time" format, or []byte
+//     with the "base64" content encoding;
+//   - array to a slice of its items, or an array if minItems equals
+//     maxItems;
+//   - object to a struct if it has properties, or otherwise a map from
+//     string to its additionalProperties, or any;
+//   - a "$ref" to a definition of an object with properties to the struct
+//     of that name, to any other definition to its type, and "#" to the
+//     first struct. It is an error if a definition that is not an object
+//     with properties refers to itself;
+//   - anything else, or more than one type, to any.
+//
+// A type that is null, as one of the names in "type", or as one of the
+// schemas in "anyOf" or "oneOf", or that is the type of a property that is
+// not required, is a pointer, unless it is already a slice, map, pointer or
+// any.
+func FromJSONSchema(doc []byte, name string) ([]morph.Struct, error) {
+    esc := func(err error) ([]morph.Struct, error) {
+        return nil, fmt.Errorf("error importing JSON Schema: %w", err)
+    }
+
+    var root schema
+    if err := json.Unmarshal(doc, &root); err != nil { return esc(err) }
+    if name == "" { name = root.Title }
+    if name == "" { return esc(fmt.Errorf("no name or title for the root schema")) }
+
+    if !root.isObject() { return esc(fmt.Errorf("root schema is not an object")) }
+
+    i := &jsonSchemaImporter{
+        root:      goName(name),
+        defs:      make(map[string]schema),
+        names:     make(map[string]bool),
+        resolved:  make(map[string]resolvedType),
+        resolving: make(map[string]bool),
+    }
+    defs := append(root.Defs, root.Definitions...)
+    for _, def := range defs {
+        i.defs[def.name] = def.schema
+    }
+
+    if _, err := i.object(i.root, root); err != nil { return esc(err) }
+    for _, def := range defs {
+        if !def.schema.isObject() { continue }
+        if _, err := i.object(goName(def.name), def.schema); err != nil { return esc(err) }
+    }
+    return i.structs, nil
+}
+
+type jsonSchemaImporter struct {
+    root      string
+    defs      map[string]schema
+    names     map[string]bool
+    resolved  map[string]resolvedType // definition key => type
+    resolving map[string]bool         // definition keys being resolved
+    structs   []morph.Struct
+}
+
+// resolvedType is the Go type of a definition, and whether it may be null.
+type resolvedType struct {
+    Type string
+    null bool
+}
+
+// object adds a struct for an object schema.
+func (i *jsonSchemaImporter) object(name string, s schema) (string, error) {
+    if i.names[name] { return "", fmt.Errorf("more than one struct named %q", name) }
+    i.names[name] = true
+    index := len(i.structs)
+    i.structs = append(i.structs, morph.Struct{Name: name, Comment: s.Description})
+
+    required := make(map[string]bool)
+    for _, key := range s.Required { required[key] = true }
+
+    var fields []morph.Field
+    seen := make(map[string]string)
+    for _, p := range s.Properties {
+        fieldName := goName(p.name)
+        if other, exists := seen[fieldName]; exists {
+            return "", fmt.Errorf("properties %q and %q of %q have the same Go name %q", other, p.name, name, fieldName)
+        }
+        seen[fieldName] = p.name
+
+        Type, null, err := i.goType(name + fieldName, p.schema)
+        if err != nil { return "", fmt.Errorf("property %q of %q: %w", p.name, name, err) }
+        if (null || !required[p.name]) && !nilable(Type) { Type = "*" + Type }
+
+        tag := p.name
+        if !required[p.name] { tag += ",omitempty" }
+        fields = append(fields, morph.Field{
+            Name:    fieldName,
+            Type:    Type,
+            Tag:     fmt.Sprintf("json:%q", tag),
+            Comment: p.schema.Description,
+        })
+    }
+    i.structs[index].Fields = fields
+    return name, nil
+}
+
+// goType returns the Go type for a schema, and whether it may be null. The
+// name is used for a struct for an object with properties.
+func (i *jsonSchemaImporter) goType(name string, s schema) (string, bool, error) {
+    if s.Ref != "" {
+        if s.Ref == "#" { return i.root, false, nil }
+        for _, prefix := range []string{"#/$defs/", "#/definitions/"} {
+            if !strings.HasPrefix(s.Ref, prefix) { continue }
+            key := strings.TrimPrefix(s.Ref, prefix)
+            def, ok := i.defs[key]
+            if !ok { return "", false, fmt.Errorf("undefined reference %q", s.Ref) }
+            if def.isObject() { return goName(key), false, nil }
+            return i.definition(key, def)
+        }
+        return "", false, fmt.Errorf("unsupported reference %q", s.Ref)
+    }
+
+    if alternatives := append(s.AnyOf, s.OneOf...); len(alternatives) > 0 {
+        var others []schema
+        var null bool
+        for _, x := range alternatives {
+            if x.isNull() {
+                null = true
+            } else {
+                others = append(others, x)
+            }
+        }
+        if len(others) != 1 { return "any", null, nil }
+        Type, otherNull, err := i.goType(name, others[0])
+        return Type, null || otherNull, err
+    }
+
+    types, null, err := s.types()
+    if err != nil { return "", false, err }
+    if (len(types) == 0) && (len(s.Properties) > 0) { types = []string{"object"} }
+    if len(types) != 1 { return "any", null, nil }
+
+    switch types[0] {
+    case "boolean":
+        return "bool", null, nil
+    case "number":
+        return "float64", null, nil
+    case "integer":
+        return integer(s.Minimum, s.Maximum), null, nil
+    case "string":
+        if s.Format == "date-time" { return "time.Time", null, nil }
+        if s.ContentEncoding == "base64" { return "[]byte", null, nil }
+        return "string", null, nil
+    case "array":
+        if len(s.Items) == 0 { return "[]any", null, nil }
+        var items schema
+        if err := json.Unmarshal(s.Items, &items); err != nil {
+            return "", false, fmt.Errorf("unsupported items %s", s.Items)
+        }
+        elem, elemNull, err := i.goType(name + "Item", items)
+        if err != nil { return "", false, err }
+        if elemNull && !nilable(elem) { elem = "*" + elem }
+        if (s.MinItems != nil) && (s.MaxItems != nil) && (*s.MinItems == *s.MaxItems) && (*s.MinItems > 0) {
+            return fmt.Sprintf("[%d]%s", *s.MinItems, elem), null, nil
+        }
+        return "[]" + elem, null, nil
+    case "object":
+        if len(s.Properties) > 0 {
+            Type, err := i.object(name, s)
+            return Type, null, err
+        }
+        var values schema
+        if err := json.Unmarshal(s.AdditionalProperties, &values); err != nil {
+            return "map[string]any", null, nil
+        }
+        elem, elemNull, err := i.goType(name + "Value", values)
+        if err != nil { return "", false, err }
+        if elemNull && !nilable(elem) { elem = "*" + elem }
+        return "map[string]" + elem, null, nil
+    }
+    return "any", null, nil
+}
+
+// definition returns the Go type for a definition that is not an object
+// with properties, resolving it only once, so that any structs nested in it
+// are only added once.
+func (i *jsonSchemaImporter) definition(key string, def schema) (string, bool, error) {
+    if r, ok := i.resolved[key]; ok { return r.Type, r.null, nil }
+    if i.resolving[key] {
+        return "", false, fmt.Errorf("definition %q refers to itself", key)
+    }
+    i.resolving[key] = true
+    Type, null, err := i.goType(goName(key), def)
+    delete(i.resolving, key)
+    if err != nil { return "", false, err }
+    i.resolved[key] = resolvedType{Type: Type, null: null}
+    return Type, null, nil
+}
+
+// integer returns the smallest Go integer type for the given bounds.
+func integer(min, max *float64) string {
+    if min == nil { return "int64" }
+    upper := math.Inf(1)
+    if max != nil { upper = *max }
+
+    if *min >= 0 {
+        switch {
+        case upper <= math.MaxUint8:  return "uint8"
+        case upper <= math.MaxUint16: return "uint16"
+        case upper <= math.MaxUint32: return "uint32"
+        }
+        return "uint64"
+    }
+    switch {
+    case (*min >= math.MinInt8)  && (upper <= math.MaxInt8):  return "int8"
+    case (*min >= math.MinInt16) && (upper <= math.MaxInt16): return "int16"
+    case (*min >= math.MinInt32) && (upper <= math.MaxInt32): return "int32"
+    }
+    return "int64"
+}
@@ -0,0 +1,168 @@
+package importers_test
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/generators/jsonschema"
+    "github.com/tawesoft/morph/importers"
+    "github.com/tawesoft/morph/internal"
+)
+
+const jsonSource = `
+package example
+
+// Address is a postal address.
+type Address struct {
+    Street string ` + "`json:\"street\"`" + `
+    City   *string ` + "`json:\"city,omitempty\"`" + `
+}
+
+// Person is a person, with fields that round-trip through JSON Schema.
+type Person struct {
+    // Name is the full name of the person.
+    Name      string             ` + "`json:\"name\"`" + `
+    Nick      *string            ` + "`json:\"nick,omitempty\"`" + `
+    Age       int8               ` + "`json:\"age\"`" + `
+    Count     uint32             ` + "`json:\"count\"`" + `
+    Ratio     float64            ` + "`json:\"ratio\"`" + `
+    Tags      [2]string          ` + "`json:\"tags\"`" + `
+    Scores    map[string][]int64 ` + "`json:\"scores,omitempty\"`" + `
+    Data      []byte             ` + "`json:\"data\"`" + `
+    Born      time.Time          ` + "`json:\"born\"`" + `
+    Home      *Address           ` + "`json:\"home\"`" + `
+    Parent    *Person            ` + "`json:\"parent,omitempty\"`" + `
+    Extra     any                ` + "`json:\"extra,omitempty\"`" + `
+}
+`
+
+func TestFromJSONSchema(t *testing.T) {
+    person := internal.Must(morph.ParseStruct("test.go", jsonSource, "Person"))
+    address := internal.Must(morph.ParseStruct("test.go", jsonSource, "Address"))
+    doc := internal.Must(jsonschema.Schema(person, jsonschema.Options{
+        Structs: []morph.Struct{address},
+    }))
+
+    structs, err := importers.FromJSONSchema(doc, "")
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+
+    var got []string
+    for _, s := range structs {
+        got = append(got, s.String())
+    }
+    expected := []string{person.String(), address.String()}
+    if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+        t.Errorf("got:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+    }
+}
+
+func TestFromJSONSchema_nested(t *testing.T) {
+    doc := `{
+        "title": "order",
+        "type": "object",
+        "properties": {
+            "order_id": {"type": "integer"},
+            "shipping": {
+                "description": "Where to send the order.",
+                "type": "object",
+                "properties": {"url": {"type": "string"}},
+                "required": ["url"]
+            },
+            "lines": {"type": "array", "items": {"$ref": "#/$defs/line"}},
+            "status": {"$ref": "#/$defs/status"},
+            "note": {"anyOf": [{"type": "string"}, {"type": "null"}]},
+            "either": {"oneOf": [{"type": "string"}, {"type": "integer"}]}
+        },
+        "required": ["order_id", "lines", "status", "note"],
+        "$defs": {
+            "status": {"type": "string"},
+            "line": {
+                "type": "object",
+                "properties": {"sku": {"type": "string"}, "qty": {"type": "integer", "minimum": 1, "maximum": 1000}},
+                "required": ["sku", "qty"]
+            }
+        }
+    }`
+
+    structs, err := importers.FromJSONSchema([]byte(doc), "")
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+
+    var got []string
+    for _, s := range structs {
+        got = append(got, s.String())
+    }
+    expected := strings.TrimSpace("\n" + `
+type Order struct {
+	OrderID  int64          ` + "`json:\"order_id\"`" + `
+	Shipping *OrderShipping ` + "`json:\"shipping,omitempty\"`" + ` // Where to send the order.
+	Lines    []Line         ` + "`json:\"lines\"`" + `
+	Status   string         ` + "`json:\"status\"`" + `
+	Note     *string        ` + "`json:\"note\"`" + `
+	Either   any            ` + "`json:\"either,omitempty\"`" + `
+}
+// Where to send the order.
+type OrderShipping struct {
+	URL string ` + "`json:\"url\"`" + `
+}
+type Line struct {
+	Sku string ` + "`json:\"sku\"`" + `
+	Qty uint16 ` + "`json:\"qty\"`" + `
+}`)
+    if strings.Join(got, "\n") != expected {
+        t.Errorf("got:\n%s\nexpected:\n%s", strings.Join(got, "\n"), expected)
+    }
+}
+
+func TestFromJSONSchema_sharedDefinition(t *testing.T) {
+    doc := `{
+        "title": "invoice",
+        "properties": {
+            "billed": {"$ref": "#/$defs/lines"},
+            "credited": {"$ref": "#/$defs/lines"}
+        },
+        "required": ["billed", "credited"],
+        "$defs": {
+            "lines": {
+                "type": "array",
+                "items": {"type": "object", "properties": {"sku": {"type": "string"}}, "required": ["sku"]}
+            }
+        }
+    }`
+
+    structs, err := importers.FromJSONSchema([]byte(doc), "")
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+
+    var got []string
+    for _, s := range structs {
+        got = append(got, s.String())
+    }
+    expected := strings.TrimSpace("\n" + `
+type Invoice struct {
+	Billed   []LinesItem ` + "`json:\"billed\"`" + `
+	Credited []LinesItem ` + "`json:\"credited\"`" + `
+}
+type LinesItem struct {
+	Sku string ` + "`json:\"sku\"`" + `
+}`)
+    if strings.Join(got, "\n") != expected {
+        t.Errorf("got:\n%s\nexpected:\n%s", strings.Join(got, "\n"), expected)
+    }
+}
+
+func TestFromJSONSchema_errors(t *testing.T) {
+    for _, doc := range []string{
+        `{"type": "object", "properties": {"a": {"type": "string"}}}`,
+        `{"title": "x", "type": "string"}`,
+        `{"title": "x", "properties": {"a": {"$ref": "#/$defs/missing"}}}`,
+        `{"title": "x", "properties": {"a": {"$ref": "other.json"}}}`,
+        `{"title": "x", "properties": {"a-b": {}, "a_b": {}}}`,
+        `{"title": "x", "properties": {"a": {"type": 1}}}`,
+        `{"title": "x", "properties": {"a": {"$ref": "#/$defs/tree"}}, "$defs": {"tree": {"type": "array", "items": {"$ref": "#/$defs/tree"}}}}`,
+        `not json`,
+    } {
+        if _, err := importers.FromJSONSchema([]byte(doc), ""); err == nil {
+            t.Errorf("expected error for %s", doc)
+        }
+    }
+}
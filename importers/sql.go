@@ -0,0 +1,399 @@
+package importers
+
+import (
+    "fmt"
+    "strings"
+    "unicode"
+
+    "github.com/tawesoft/morph"
+)
+
+// SQLOptions configure [FromCreateTable].
+type SQLOptions struct {
+    // Name, if not empty, is the name of the struct. Otherwise, the table
+    // name is converted to an exported Go name.
+    Name string
+
+    // Types maps an SQL column type, in upper case, such as "NUMERIC(10,2)"
+    // or "NUMERIC", to a Go type, overriding the default Go type for that
+    // column type, if any. A column type is looked up as written, without
+    // spaces, and then without any arguments in parentheses.
+    Types map[string]string
+}
+
+// sqlToken is a token of an SQL statement.
+type sqlToken struct {
+    text   string
+    quoted bool // a quoted identifier, without its quotes
+}
+
+// is returns true if a token is the given keyword, ignoring case.
+func (t sqlToken) is(keyword string) bool {
+    return !t.quoted && strings.EqualFold(t.text, keyword)
+}
+
+// sqlIndexDefinition returns true if a group starting with KEY or INDEX is
+// an index definition, followed by a list of columns, with an optional
+// index name before it, rather than the definition of a column with that
+// name. A list of columns is told apart from the arguments of a column type,
+// such as "VARCHAR(10)", because it does not start with a number.
+func sqlIndexDefinition(group []sqlToken) bool {
+    isColumns := func(i int) bool {
+        if (i + 1 >= len(group)) || group[i].quoted || (group[i].text != "(") { return false }
+        next := group[i+1]
+        return next.quoted || (next.text == "") || !unicode.IsDigit(rune(next.text[0]))
+    }
+    return isColumns(1) || isColumns(2)
+}
+
+// sqlTokens splits an SQL statement into tokens, skipping comments.
+func sqlTokens(stmt string) ([]sqlToken, error) {
+    var tokens []sqlToken
+    rs := []rune(stmt)
+    for i := 0; i < len(rs); {
+        c := rs[i]
+        switch {
+        case unicode.IsSpace(c):
+            i++
+        case (c == '-') && (i + 1 < len(rs)) && (rs[i+1] == '-'):
+            for (i < len(rs)) && (rs[i] != '\n') { i++ }
+        case (c == '/') && (i + 1 < len(rs)) && (rs[i+1] == '*'):
+            end := strings.Index(string(rs[i+2:]), "*/")
+            if end < 0 { return nil, fmt.Errorf("unterminated comment") }
+            i += 2 + len([]rune(string(rs[i+2:])[:end])) + 2
+        case (c == '"') || (c == '`') || (c == '['):
+            closing := c
+            if c == '[' { closing = ']' }
+            var sb strings.Builder
+            j := i + 1
+            for ; j < len(rs); j++ {
+                if rs[j] != closing {
+                    sb.WriteRune(rs[j])
+                } else if (j + 1 < len(rs)) && (rs[j+1] == closing) && (c != '[') {
+                    sb.WriteRune(closing)
+                    j++
+                } else {
+                    break
+                }
+            }
+            if j >= len(rs) { return nil, fmt.Errorf("unterminated identifier") }
+            tokens = append(tokens, sqlToken{text: sb.String(), quoted: true})
+            i = j + 1
+        case c == '\'':
+            j := i + 1
+            for ; j < len(rs); j++ {
+                if rs[j] != '\'' { continue }
+                if (j + 1 < len(rs)) && (rs[j+1] == '\'') { j++; continue }
+                break
+            }
+            if j >= len(rs) { return nil, fmt.Errorf("unterminated string") }
+            tokens = append(tokens, sqlToken{text: string(rs[i:j+1])})
+            i = j + 1
+        case unicode.IsLetter(c) || unicode.IsDigit(c) || (c == '_') || (c == '.'):
+            j := i
+            for (j < len(rs)) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || (rs[j] == '_') || (rs[j] == '.') || (rs[j] == '$')) { j++ }
+            tokens = append(tokens, sqlToken{text: string(rs[i:j])})
+            i = j
+        default:
+            tokens = append(tokens, sqlToken{text: string(c)})
+            i++
+        }
+    }
+    return tokens, nil
+}
+
+// sqlGroup returns the tokens from a token at index i up to the next comma
+// or closing parenthesis that is not in parentheses, and the index after
+// them.
+func sqlGroup(tokens []sqlToken, i int) ([]sqlToken, int) {
+    depth := 0
+    start := i
+    for ; i < len(tokens); i++ {
+        t := tokens[i]
+        if t.quoted { continue }
+        switch t.text {
+        case "(":
+            depth++
+        case ")":
+            if depth == 0 { return tokens[start:i], i }
+            depth--
+        case ",":
+            if depth == 0 { return tokens[start:i], i }
+        }
+    }
+    return tokens[start:i], i
+}
+
+// sqlText joins tokens as SQL source, with a space between words.
+func sqlText(tokens []sqlToken) string {
+    var sb strings.Builder
+    for i, t := range tokens {
+        text := t.text
+        if t.quoted { text = `"` + strings.ReplaceAll(text, `"`, `""`) + `"` }
+        if (i > 0) && !strings.Contains("(),", t.text) && (tokens[i-1].text != "(") {
+            sb.WriteString(" ")
+        }
+        sb.WriteString(text)
+    }
+    return sb.String()
+}
+
+// sqlConstraints are the keywords that start a column constraint.
+var sqlConstraints = []string{
+    "CONSTRAINT", "NOT", "NULL", "PRIMARY", "UNIQUE", "DEFAULT", "CHECK",
+    "REFERENCES", "COLLATE", "GENERATED", "AUTOINCREMENT", "AUTO_INCREMENT",
+    "AS", "ON", "IDENTITY",
+}
+
+// sqlGoType returns the default Go type for an SQL column type, in upper
+// case and without arguments, or the empty string if there is none.
+func sqlGoType(Type string) string {
+    switch Type {
+    case "BOOL", "BOOLEAN":
+        return "bool"
+    case "TINYINT":
+        return "int8"
+    case "SMALLINT", "INT2", "SMALLSERIAL":
+        return "int16"
+    case "INT4", "MEDIUMINT", "SERIAL":
+        return "int32"
+    case "INT", "INTEGER", "BIGINT", "INT8", "BIGSERIAL":
+        return "int64"
+    case "REAL", "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "DOUBLE PRECISION",
+        "NUMERIC", "DECIMAL":
+        return "float64"
+    case "CHAR", "CHARACTER", "VARCHAR", "CHARACTER VARYING", "NCHAR",
+        "NVARCHAR", "TEXT", "CLOB", "UUID", "CITEXT":
+        return "string"
+    case "BLOB", "BYTEA", "BINARY", "VARBINARY":
+        return "[]byte"
+    case "DATE", "TIME", "DATETIME", "TIMESTAMP", "TIMESTAMPTZ",
+        "TIMESTAMP WITH TIME ZONE", "TIMESTAMP WITHOUT TIME ZONE",
+        "TIME WITH TIME ZONE", "TIME WITHOUT TIME ZONE":
+        return "time.Time"
+    case "JSON", "JSONB":
+        return "json.RawMessage"
+    }
+    return ""
+}
+
+// FromCreateTable returns a struct described by an SQL CREATE TABLE
+// statement.
+//
+// Each column is a field, named by converting the column name to an
+// exported Go name e.g. "user_id" to "UserID", with a "db" tag for the
+// column name, with the "key" option for a column of the primary key. The
+// "sql" tag keeps any "unique" or "default" constraint on a column, as
+// documented by the sqlcodec generator's CreateTable. Comments are ignored.
+//
+// Column types are mapped to Go types, in order, by [SQLOptions].Types, or
+// as follows, or it is an error:
+//
+//   - BOOL and BOOLEAN to bool;
+//   - TINYINT to int8, SMALLINT to int16, SERIAL to int32, and INT,
+//     INTEGER, and BIGINT to int64;
+//   - REAL, FLOAT, DOUBLE, NUMERIC, and DECIMAL to float64;
+//   - CHAR, VARCHAR, TEXT, and UUID to string;
+//   - BLOB, BYTEA, and BINARY to []byte;
+//   - DATE, TIME, DATETIME, and TIMESTAMP to time.Time;
+//   - JSON and JSONB to json.RawMessage.
+//
+// An UNSIGNED integer type is mapped to the unsigned Go type of the same
+// size e.g. INT UNSIGNED to uint64.
+//
+// A column that is not NOT NULL, and is not part of the primary key, is a
+// pointer, unless its type is already a slice.
+func FromCreateTable(stmt string, options SQLOptions) (morph.Struct, error) {
+    esc := func(err error) (morph.Struct, error) {
+        return morph.Struct{}, fmt.Errorf("error importing CREATE TABLE statement: %w", err)
+    }
+
+    tokens, err := sqlTokens(stmt)
+    if err != nil { return esc(err) }
+
+    // CREATE [TEMP | TEMPORARY] TABLE [IF NOT EXISTS] name (
+    i := 0
+    expect := func(keywords ... string) bool {
+        for _, k := range keywords {
+            if (i >= len(tokens)) || !tokens[i].is(k) { return false }
+            i++
+        }
+        return true
+    }
+    if !expect("CREATE") { return esc(fmt.Errorf("expected CREATE")) }
+    _ = expect("TEMP") || expect("TEMPORARY")
+    if !expect("TABLE") { return esc(fmt.Errorf("expected TABLE")) }
+    _ = expect("IF", "NOT", "EXISTS")
+    if i >= len(tokens) { return esc(fmt.Errorf("expected a table name")) }
+    table := tokens[i].text
+    if !tokens[i].quoted {
+        if dot := strings.LastIndex(table, "."); dot >= 0 { table = table[dot+1:] }
+    }
+    i++
+    for (i + 1 < len(tokens)) && (tokens[i].text == ".") {
+        table = tokens[i+1].text
+        i += 2
+    }
+    if !expect("(") { return esc(fmt.Errorf("expected ( after table name")) }
+
+    name := options.Name
+    if name == "" { name = goName(table) }
+    result := morph.Struct{Name: name}
+
+    type column struct {
+        name     string
+        Type     string
+        notNull  bool
+        key      bool
+        unique   bool
+        value    string // default
+    }
+    var columns []*column
+    byName := make(map[string]*column)
+
+    for (i < len(tokens)) && (tokens[i].text != ")") {
+        if tokens[i].text == "," { i++; continue }
+        var group []sqlToken
+        group, i = sqlGroup(tokens, i)
+        if len(group) == 0 { return esc(fmt.Errorf("expected a column definition")) }
+
+        // table constraints
+        first := group[0]
+        if first.is("CONSTRAINT") && (len(group) > 2) { first = group[2] }
+        switch {
+        case first.is("PRIMARY"), first.is("UNIQUE"):
+            // the first word of each item in the first list of columns
+            var names []string
+            depth, expectName := 0, false
+            for _, t := range group {
+                switch {
+                case !t.quoted && (t.text == "("):
+                    depth++
+                    expectName = (depth == 1)
+                case !t.quoted && (t.text == ")"):
+                    depth--
+                case (depth == 1) && !t.quoted && (t.text == ","):
+                    expectName = true
+                case (depth == 1) && expectName:
+                    names = append(names, t.text)
+                    expectName = false
+                }
+                if (depth == 0) && (len(names) > 0) { break }
+            }
+            if len(names) == 0 { return esc(fmt.Errorf("expected columns in %s", sqlText(group))) }
+            for _, n := range names {
+                c, ok := byName[n]
+                if !ok { return esc(fmt.Errorf("unknown column %q in %s", n, sqlText(group))) }
+                if first.is("PRIMARY") {
+                    c.key = true
+                } else if len(names) == 1 {
+                    c.unique = true
+                }
+            }
+            continue
+        case first.is("CHECK"), first.is("FOREIGN"), first.is("EXCLUDE"):
+            continue
+        case (first.is("KEY") || first.is("INDEX")) && sqlIndexDefinition(group):
+            // MySQL index definitions; otherwise, a column named key or index
+            continue
+        }
+
+        // column definitions: name type [constraints]
+        c := &column{name: group[0].text}
+        if _, exists := byName[c.name]; exists {
+            return esc(fmt.Errorf("more than one column named %q", c.name))
+        }
+        j := 1
+        var typeTokens []sqlToken
+        for ; j < len(group); j++ {
+            t := group[j]
+            isConstraint := false
+            for _, k := range sqlConstraints {
+                if t.is(k) { isConstraint = true; break }
+            }
+            if isConstraint { break }
+            typeTokens = append(typeTokens, t)
+        }
+        for ; j < len(group); j++ {
+            t := group[j]
+            switch {
+            case t.is("NOT") && (j + 1 < len(group)) && group[j+1].is("NULL"):
+                c.notNull = true
+                j++
+            case t.is("PRIMARY"):
+                c.key = true
+            case t.is("UNIQUE"):
+                c.unique = true
+            case t.is("DEFAULT") && (j + 1 < len(group)):
+                end := j + 2
+                if group[j+1].text == "(" {
+                    depth := 0
+                    for end = j + 1; end < len(group); end++ {
+                        if group[end].text == "(" { depth++ }
+                        if group[end].text == ")" { depth--; if depth == 0 { end++; break } }
+                    }
+                } else if ((group[j+1].text == "-") || (group[j+1].text == "+")) && (j + 2 < len(group)) {
+                    c.value = group[j+1].text + group[j+2].text
+                    j += 2
+                    continue
+                }
+                if end > len(group) { end = len(group) }
+                c.value = sqlText(group[j+1:end])
+                j = end - 1
+            }
+        }
+
+        if len(typeTokens) == 0 { return esc(fmt.Errorf("no type for column %q", c.name)) }
+        sqlType := strings.ToUpper(sqlText(typeTokens))
+        base := sqlType
+        if open := strings.Index(base, "("); open >= 0 {
+            closing := strings.LastIndex(base, ")")
+            base = strings.TrimSpace(base[:open] + base[closing+1:])
+        }
+        unsigned := strings.HasSuffix(base, " UNSIGNED")
+        base = strings.TrimSpace(strings.TrimSuffix(base, " UNSIGNED"))
+
+        var ok bool
+        if c.Type, ok = options.Types[strings.ReplaceAll(sqlType, " ", "")]; !ok {
+            if c.Type, ok = options.Types[base]; !ok {
+                c.Type = sqlGoType(base)
+                if unsigned && strings.HasPrefix(c.Type, "int") { c.Type = "u" + c.Type }
+            }
+        }
+        if c.Type == "" { return esc(fmt.Errorf("unsupported type %q for column %q", sqlType, c.name)) }
+
+        columns = append(columns, c)
+        byName[c.name] = c
+    }
+    if !expect(")") { return esc(fmt.Errorf("expected ) after column definitions")) }
+    if len(columns) == 0 { return esc(fmt.Errorf("no columns")) }
+
+    seen := make(map[string]string)
+    for _, c := range columns {
+        fieldName := goName(c.name)
+        if other, exists := seen[fieldName]; exists {
+            return esc(fmt.Errorf("columns %q and %q have the same Go name %q", other, c.name, fieldName))
+        }
+        seen[fieldName] = c.name
+
+        Type := c.Type
+        if !c.notNull && !c.key && !nilable(Type) { Type = "*" + Type }
+
+        db := c.name
+        if c.key { db += ",key" }
+        tag := fmt.Sprintf("db:%q", db)
+        var annotations []string
+        if c.unique { annotations = append(annotations, "unique") }
+        if c.value != "" { annotations = append(annotations, "default:" + c.value) }
+        if len(annotations) > 0 {
+            tag += fmt.Sprintf(" sql:%q", strings.Join(annotations, ";"))
+        }
+
+        result.Fields = append(result.Fields, morph.Field{
+            Name: fieldName,
+            Type: Type,
+            Tag:  tag,
+        })
+    }
+    return result, nil
+}
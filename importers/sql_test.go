@@ -0,0 +1,122 @@
+package importers_test
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph/generators/sqlcodec"
+    "github.com/tawesoft/morph/importers"
+)
+
+func TestFromCreateTable(t *testing.T) {
+    stmt := `
+-- accounts of users
+CREATE TABLE IF NOT EXISTS public.user_accounts (
+    id            BIGSERIAL,
+    email         VARCHAR(255) NOT NULL UNIQUE,
+    "display name" TEXT DEFAULT 'anon''s',
+    score         NUMERIC(10, 2) DEFAULT -1,
+    level         SMALLINT NOT NULL CHECK (level > 0),
+    active        BOOLEAN NOT NULL DEFAULT TRUE,
+    created       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT (now()),
+    avatar        BYTEA, /* an image */
+    settings      JSONB,
+    region        TEXT NOT NULL REFERENCES regions (code),
+    CONSTRAINT user_accounts_pk PRIMARY KEY (id),
+    UNIQUE (region, email)
+);`
+
+    s, err := importers.FromCreateTable(stmt, importers.SQLOptions{
+        Types: map[string]string{"NUMERIC(10,2)": "Decimal"},
+    })
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+
+    expected := strings.TrimSpace("\n" + `
+type UserAccounts struct {
+	ID          int64           ` + "`db:\"id,key\"`" + `
+	Email       string          ` + "`db:\"email\" sql:\"unique\"`" + `
+	DisplayName *string         ` + "`db:\"display name\" sql:\"default:'anon''s'\"`" + `
+	Score       *Decimal        ` + "`db:\"score\" sql:\"default:-1\"`" + `
+	Level       int16           ` + "`db:\"level\"`" + `
+	Active      bool            ` + "`db:\"active\" sql:\"default:TRUE\"`" + `
+	Created     time.Time       ` + "`db:\"created\" sql:\"default:(now())\"`" + `
+	Avatar      []byte          ` + "`db:\"avatar\"`" + `
+	Settings    json.RawMessage ` + "`db:\"settings\"`" + `
+	Region      string          ` + "`db:\"region\"`" + `
+}`)
+    if s.String() != expected {
+        t.Errorf("got:\n%s\nexpected:\n%s", s.String(), expected)
+    }
+
+    s, err = importers.FromCreateTable("create table `t` (`a` int primary key, b int)", importers.SQLOptions{Name: "Thing"})
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+    if (s.Name != "Thing") || (len(s.Fields) != 2) || (s.Fields[0].Tag != `db:"a,key"`) || (s.Fields[1].Type != "*int64") {
+        t.Errorf("got %+v", s)
+    }
+}
+
+func TestFromCreateTable_keyColumns(t *testing.T) {
+    stmt := `CREATE TABLE settings (
+        key   TEXT NOT NULL,
+        index INT NOT NULL,
+        value VARCHAR(64),
+        KEY (value),
+        INDEX settings_by_index (index, key)
+    )`
+    s, err := importers.FromCreateTable(stmt, importers.SQLOptions{})
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+
+    expected := strings.TrimSpace("\n" + `
+type Settings struct {
+	Key   string  ` + "`db:\"key\"`" + `
+	Index int64   ` + "`db:\"index\"`" + `
+	Value *string ` + "`db:\"value\"`" + `
+}`)
+    if s.String() != expected {
+        t.Errorf("got:\n%s\nexpected:\n%s", s.String(), expected)
+    }
+}
+
+func TestFromCreateTable_types(t *testing.T) {
+    stmt := `CREATE TABLE counters (
+        counterId INT UNSIGNED NOT NULL PRIMARY KEY,
+        small     TINYINT(3) UNSIGNED,
+        note      TEXT NOT NULL DEFAULT 'a;b'
+    )`
+    s, err := importers.FromCreateTable(stmt, importers.SQLOptions{})
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+
+    expected := strings.TrimSpace("\n" + `
+type Counters struct {
+	CounterID uint64 ` + "`db:\"counterId,key\"`" + `
+	Small     *uint8 ` + "`db:\"small\"`" + `
+	Note      string ` + "`db:\"note\" sql:\"default:'a;b'\"`" + `
+}`)
+    if s.String() != expected {
+        t.Errorf("got:\n%s\nexpected:\n%s", s.String(), expected)
+    }
+
+    // the default value survives generating the table again
+    got, err := sqlcodec.CreateTable(s, sqlcodec.Options{Table: "counters"})
+    if err != nil { t.Fatalf("unexpected error: %v", err) }
+    if !strings.Contains(got, `"note" TEXT NOT NULL DEFAULT 'a;b'`) {
+        t.Errorf("got:\n%s", got)
+    }
+}
+
+func TestFromCreateTable_errors(t *testing.T) {
+    for _, stmt := range []string{
+        `CREATE INDEX x ON t (a)`,
+        `CREATE TABLE t ()`,
+        `CREATE TABLE t (a GEOMETRY)`,
+        `CREATE TABLE t (a INT, a INT)`,
+        `CREATE TABLE t (a INT, PRIMARY KEY (b))`,
+        `CREATE TABLE t (a_b INT, "a b" INT)`,
+        `CREATE TABLE t (a INT`,
+        `CREATE TABLE t (a TEXT DEFAULT 'x)`,
+    } {
+        if _, err := importers.FromCreateTable(stmt, importers.SQLOptions{}); err == nil {
+            t.Errorf("expected error for %s", stmt)
+        }
+    }
+}
@@ -1,10 +1,13 @@
 package internal
 
 import (
+    "fmt"
     "go/format"
     "os"
     "os/exec"
     "path"
+    "reflect"
+    "runtime"
     "strings"
     "testing"
     "unicode"
@@ -56,6 +59,30 @@ func TestCompileAndRun(t *testing.T, source string, expected func(stdout string)
     }
 }
 
+// TestProgram returns the source code of a main package, for
+// [TestCompileAndRun], that imports each of the given import paths (ignoring
+// duplicates), then declares each struct and function, in order, followed by
+// the rest of the program, main, which may also declare other types.
+func TestProgram[F fmt.Stringer](imports []string, structs []fmt.Stringer, functions []F, main string) string {
+    var sb strings.Builder
+    sb.WriteString("package main\n\nimport (\n")
+    seen := make(map[string]bool)
+    for _, i := range imports {
+        if seen[i] { continue }
+        seen[i] = true
+        fmt.Fprintf(&sb, "%q\n", i)
+    }
+    sb.WriteString(")\n\n")
+    for _, s := range structs {
+        sb.WriteString(s.String() + "\n\n")
+    }
+    for _, f := range functions {
+        sb.WriteString(f.String() + "\n\n")
+    }
+    sb.WriteString(main)
+    return sb.String()
+}
+
 // FirstOrDefault returns the first value in the slice, or, if empty, the default value
 func FirstOrDefault[X comparable](xs []X, defaultIfMissing X) X {
     if len(xs) >= 1 {
@@ -229,3 +256,33 @@ func RemoveElementByIndex[X any](idx int, xs []X) []X {
 func runeIsHSpace(c rune) bool {
     return (c == '\t') || (c == ' ')
 }
+
+// FuncName returns a short name for a function value, such as
+// "fieldmappers.TimeToInt64", for use in error messages. A closure is named
+// after the function that contains it, e.g. "fieldmappers.DeleteNamed".
+// Returns the empty string for a nil function or for any other value.
+func FuncName(fn any) string {
+    v := reflect.ValueOf(fn)
+    if (v.Kind() != reflect.Func) || v.IsNil() { return "" }
+    f := runtime.FuncForPC(v.Pointer())
+    if f == nil { return "" }
+    name := f.Name()
+    if idx := strings.LastIndexByte(name, '/'); idx >= 0 {
+        name = name[idx+1:]
+    }
+    for {
+        idx := strings.LastIndexByte(name, '.')
+        if (idx < 0) || !strings.HasPrefix(name[idx+1:], "func") { break }
+        if strings.TrimLeftFunc(name[idx+5:], IsAsciiNumber) != "" { break }
+        name = name[:idx]
+    }
+    return name
+}
+
+// Untitle returns s with its first character forced to lowercase, for example
+// to derive a variable name from a type name.
+func Untitle(s string) string {
+    if s == "" { return s }
+    // TODO unicode
+    return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,164 @@
+package morph
+
+import (
+    "fmt"
+    "strings"
+)
+
+// Lineage records the provenance of a mapped [Field] or [Struct]: its name
+// (and, for a field, its type) before any mapping, and the ordered list of
+// mappers that changed it.
+//
+// Mappers are identified by the name of their Go function, for example
+// "fieldmappers.TimeToInt64". A mapper returned by a constructor, like
+// fieldmappers.DeleteNamed, is identified by the name of that constructor.
+//
+// A Field or Struct that has never been mapped has an empty Lineage. Use
+// [Field.Origin] or [Struct.Origin] to get a complete Lineage in either case.
+type Lineage struct {
+    Name    string   // Name before any mapping
+    Type    string   // Type before any mapping (for a Field only)
+    Mappers []string // Mappers that changed it, in the order they were applied
+}
+
+// Copy returns a (deep) copy of a Lineage, ensuring that slices aren't
+// aliased.
+func (l Lineage) Copy() Lineage {
+    out := l
+    out.Mappers = append([]string(nil), l.Mappers...)
+    return out
+}
+
+// String returns a human-readable description of a Lineage, for example
+// "Picked time.Time via fieldmappers.TimeToInt64".
+func (l Lineage) String() string {
+    s := strings.TrimSpace(l.Name + " " + l.Type)
+    if len(l.Mappers) > 0 {
+        s += " via " + strings.Join(l.Mappers, ", ")
+    }
+    return s
+}
+
+// Origin returns the lineage of a field. If the field has never been mapped,
+// this is a Lineage with the field's current Name and Type.
+func (f Field) Origin() Lineage {
+    if f.From.Name == "" {
+        return Lineage{Name: f.Name, Type: f.Type}
+    }
+    return f.From.Copy()
+}
+
+// Derive sets the lineage of a field, f, that has been produced by the named
+// mapper from the input field. The mapper is recorded only if the field
+// differs from its input.
+//
+// If the field's lineage already extends the input's lineage, for example
+// because it was produced by a composition of mappers that each recorded
+// themselves, then it is left unchanged.
+//
+// [FieldMapper.StructMapper] calls this automatically. It is only needed by
+// code, like fieldmappers.Compose, that applies FieldMappers directly.
+//
+// Note that this modifies the field in-place, so should be done on a copy
+// where appropriate.
+func (f *Field) Derive(input Field, mapper string) {
+    from := input.Origin()
+    if (f.From.Name == from.Name) && (len(f.From.Mappers) > len(from.Mappers)) {
+        return
+    }
+    if fieldChanged(*f, input) && (mapper != "") {
+        from.Mappers = append(from.Mappers, mapper)
+    }
+    f.From = from
+}
+
+// Origin returns the lineage of a struct. If the struct has never been
+// mapped, this is a Lineage with the struct's current Name.
+func (s Struct) Origin() Lineage {
+    if s.From.Name == "" {
+        return Lineage{Name: s.Name}
+    }
+    return s.From.Copy()
+}
+
+// Derive sets the lineage of a struct, s, that has been produced by the named
+// mapper from the input struct, in the same way as [Field.Derive].
+//
+// [Struct.Map] calls this automatically.
+//
+// Note that this modifies the struct in-place, so should be done on a copy
+// where appropriate.
+func (s *Struct) Derive(input Struct, mapper string) {
+    from := input.Origin()
+    if (s.From.Name == from.Name) && (len(s.From.Mappers) > len(from.Mappers)) {
+        return
+    }
+    if structChanged(*s, input) && (mapper != "") {
+        from.Mappers = append(from.Mappers, mapper)
+    }
+    s.From = from
+}
+
+// FieldsFrom returns every field on the struct that was derived from an
+// input field with the given name, before any mapping.
+func (s Struct) FieldsFrom(name string) []Field {
+    return filterFields(s.Fields, func(f Field) bool {
+        return f.Origin().Name == name
+    })
+}
+
+// Explain returns a human-readable description of the lineage of each field
+// on a struct, one per line, for example:
+//
+//     PickedAt int64 from Picked time.Time via fieldmappers.TimeToInt64
+func (s Struct) Explain() string {
+    var sb strings.Builder
+    for _, f := range s.Fields {
+        fmt.Fprintf(&sb, "%s %s from %s\n", f.Name, f.Type, f.Origin())
+    }
+    return sb.String()
+}
+
+// matchingField returns the field on s that corresponds to the given field
+// (which may be from another struct), by name if possible, or otherwise by
+// a unique shared origin. For example, this finds the source field of a
+// converter even after the field was renamed by a mapper.
+func (s Struct) matchingField(f Field) (Field, bool) {
+    if result, ok := s.namedField(f.Name); ok {
+        return result, true
+    }
+    matches := s.FieldsFrom(f.Origin().Name)
+    if len(matches) == 1 {
+        return matches[0], true
+    }
+    return Field{}, false
+}
+
+func fieldChanged(a Field, b Field) bool {
+    if (a.Name != b.Name) || (a.Type != b.Type) || (a.Tag != b.Tag) ||
+        (a.Comment != b.Comment) || (a.Converter != b.Converter) ||
+        (a.Comparer != b.Comparer) || (a.Copier != b.Copier) ||
+        (a.Orderer != b.Orderer) || (a.Zeroer != b.Zeroer) ||
+        (a.Truther != b.Truther) || (len(a.Custom) != len(b.Custom)) {
+        return true
+    }
+    for i := range a.Custom {
+        if a.Custom[i] != b.Custom[i] { return true }
+    }
+    return false
+}
+
+func structChanged(a Struct, b Struct) bool {
+    if (a.Name != b.Name) || (a.Comment != b.Comment) ||
+        (len(a.TypeParams) != len(b.TypeParams)) ||
+        (len(a.Fields) != len(b.Fields)) {
+        return true
+    }
+    for i := range a.TypeParams {
+        if fieldChanged(a.TypeParams[i], b.TypeParams[i]) { return true }
+    }
+    for i := range a.Fields {
+        if fieldChanged(a.Fields[i], b.Fields[i]) { return true }
+    }
+    return false
+}
@@ -0,0 +1,108 @@
+package morph_test
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/fieldmappers"
+    "github.com/tawesoft/morph/internal"
+    "github.com/tawesoft/morph/structmappers"
+)
+
+// suffixAt is a FieldMapper that renames time fields e.g. "Picked" to
+// "PickedAt".
+func suffixAt(input morph.Field, emit func(output morph.Field)) {
+    if input.Type == "time.Time" {
+        input.Name = "$At"
+    }
+    emit(input)
+}
+
+func TestStruct_Lineage(t *testing.T) {
+    apple := morph.Struct{
+        Name: "Apple",
+        Fields: []morph.Field{
+            {Name: "Picked", Type: "time.Time"},
+            {Name: "Weight", Type: "int64"},
+        },
+    }
+
+    if got := apple.Origin(); !reflect.DeepEqual(got, morph.Lineage{Name: "Apple"}) {
+        t.Errorf("unexpected origin of unmapped struct: %+v", got)
+    }
+
+    orange := apple.Map(
+        structmappers.Rename("Orange"),
+    ).MapFields(
+        fieldmappers.StripComments,
+        fieldmappers.Compose(suffixAt, fieldmappers.TimeToInt64),
+    )
+
+    expectedStruct := morph.Lineage{
+        Name: "Apple",
+        Mappers: []string{
+            "structmappers.Rename",
+            "fieldmappers.Compose",
+        },
+    }
+    if got := orange.Origin(); !reflect.DeepEqual(got, expectedStruct) {
+        t.Errorf("got struct lineage %+v, expected %+v", got, expectedStruct)
+    }
+
+    picked := orange.FieldsFrom("Picked")
+    if len(picked) != 1 {
+        t.Fatalf("expected exactly one field from Picked, got %d", len(picked))
+    }
+    expectedField := morph.Lineage{
+        Name: "Picked",
+        Type: "time.Time",
+        Mappers: []string{
+            "morph_test.suffixAt",
+            "fieldmappers.TimeToInt64",
+        },
+    }
+    if got := picked[0].Origin(); !reflect.DeepEqual(got, expectedField) {
+        t.Errorf("got field lineage %+v, expected %+v", got, expectedField)
+    }
+
+    expectedExplain := strings.Join([]string{
+        "PickedAt int64 from Picked time.Time via morph_test.suffixAt, fieldmappers.TimeToInt64",
+        "Weight int64 from Weight int64",
+        "",
+    }, "\n")
+    if got := orange.Explain(); got != expectedExplain {
+        t.Errorf("got explanation %q, expected %q", got, expectedExplain)
+    }
+
+    // mapping must not modify the lineage of the input
+    if got := apple.Fields[0].Origin(); len(got.Mappers) != 0 {
+        t.Errorf("input field lineage was modified: %+v", got)
+    }
+
+    // converters find the source field, even though it was renamed
+    f, err := morph.StructConverter("($src.$type.$untitle $src.$type) To$dest.$type() $dest.$type", apple, orange)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    expected := internal.Must(internal.FormatSource(`
+// ToOrange converts a value of type [Apple] to a value of type [Orange].
+func (apple Apple) ToOrange() Orange {
+    _out := Orange{}
+
+    // convert time.Time to int64
+    _out.PickedAt = apple.Picked.UTC().Unix()
+
+    // convert int64 to int64
+    _out.Weight = apple.Weight
+
+    return _out
+}
+`))
+    if f.String() != expected {
+        t.Logf("got: %s", f.String())
+        t.Logf("expected: %s", expected)
+        t.Errorf("unexpected output")
+    }
+}
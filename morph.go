@@ -162,6 +162,7 @@ type Field struct {
 
     // For fields appearing in structs that have been mapped only...
     Reverse   FieldMapper
+    From      Lineage // See [Field.Origin].
 
     Converter BuiltinFieldExpression // x=y;  See [StructConverter].
     Comparer  BuiltinFieldExpression // x==y; See [Struct.Comparer].
@@ -179,6 +180,7 @@ type Field struct {
 func (f Field) Copy() Field {
     out := f
     out.Custom = append([]FieldExpression(nil), f.Custom...)
+    out.From = f.From.Copy()
     return out
 }
 
@@ -295,9 +297,10 @@ func (f Field) GetCustomExpression(name string) *FieldExpression {
 // These are either boolean comparison expressions (like [Field.Comparer] and
 // [Field.Orderer]) that return true or false, value assignment expressions
 // (like [Field.Converter] and [Field.Copier]) which assign values to a
-// destination value, or void inspection expressions that don't return
-// anything (but may panic). Fields are inspected in the order they appear in
-// a source struct.
+// destination value, aggregating expressions that each produce a value that
+// is folded into a single result (for example, a sum), or void inspection
+// expressions that don't return anything (but may panic). Fields are
+// inspected in the order they appear in a source struct.
 //
 // In any expression, if the pattern is the special value "skip", then
 // it means explicitly ignore that field for expressions of that type
@@ -351,9 +354,10 @@ type FieldExpression struct {
 }
 
 const (
-    FieldExpressionTypeVoid  = "void"
-    FieldExpressionTypeBool  = "bool"
-    FieldExpressionTypeValue = "value"
+    FieldExpressionTypeVoid      = "void"
+    FieldExpressionTypeBool      = "bool"
+    FieldExpressionTypeValue     = "value"
+    FieldExpressionTypeAggregate = "aggregate"
 )
 
 // FieldExpressionType describes some operation (e.g. copier, comparer,
@@ -364,6 +368,11 @@ const (
 // Most users will just call [Struct.Converter], [Struct.Comparer], etc.
 // and do not need to use this unless using Morph to generate their own custom
 // types of functions.
+//
+// A FieldExpressionType can be registered globally with
+// [RegisterFieldExpressionType], or in a [FieldExpressionTypeRegistry], so that
+// a function can be generated for it even for a struct where no field has a
+// custom FieldExpression of that type.
 type FieldExpressionType struct {
     // Targets specifies that this is an operation over this many input and/or
     // output struct values.
@@ -391,11 +400,17 @@ type FieldExpressionType struct {
     Default string
 
     // Returns specifies if the function is a boolean comparison expression,
-    // value assignment expression, or a void inspection expression
-    // (see [FieldExpression]).
+    // value assignment expression, aggregating expression, or a void
+    // inspection expression (see [FieldExpression]).
     //
     // Allowed values are FieldExpressionTypeVoid, FieldExpressionTypeBool,
-    // and FieldExpressionTypeValue.
+    // FieldExpressionTypeValue, and FieldExpressionTypeAggregate.
+    //
+    // If the type is FieldExpressionTypeAggregate, then the function
+    // signature must have exactly one return value. Its type is the type of
+    // the result. Each field's expression produces a value, converted to the
+    // result type, that is combined with the result so far using the Collect
+    // expression.
     //
     // If the type is FieldExpressionTypeVoid, then Targets must be less than
     // 2.
@@ -428,8 +443,18 @@ type FieldExpressionType struct {
     // generated function returns true immediately on the first true value. If
     // set to "&&", the generated function returns false immediately on the
     // first false value.
+    //
+    // When Returns is set to "aggregate", Collect is instead an expression
+    // that combines the result so far, "$acc", with the value of the current
+    // field's expression, "$value", e.g. "$acc + $value" to compute a sum,
+    // or "$acc | $value" to compute a bitwise OR.
     Collect string
 
+    // Initial is an optional expression giving the initial value of the
+    // result when Returns is set to "aggregate" e.g. "0" or "1". If empty,
+    // the result starts as the zero value of its type.
+    Initial string
+
     // Accessor returns the pattern of a FieldExpression of this type on a
     // given field, if one exists. If nil, calls [Field.GetCustomExpression]
     // with the FieldExpressionType.Name as an argument. It's likely that you
@@ -507,37 +532,18 @@ type FieldMapper func(input Field, emit func(output Field))
 // StructMapper returns a new StructMapper that applies the given FieldMapper
 // to every field on the input struct.
 //
+// Each output field, and the output struct, records the FieldMapper in its
+// lineage if it was changed by it (see [Field.Origin]).
+//
 // If the FieldMapper is reversible, then so is the returned StructMapper.
 func (mapper FieldMapper) StructMapper() StructMapper {
     if mapper == nil { return nil }
+    name := internal.FuncName(mapper)
     return func(in Struct) Struct {
-        var results []Field
-        out := in.Copy()
-
-        emit := collector(&results)
-        for _, input := range out.Fields {
-            emit2 := func(output Field) {
-                output.Rewrite(input)
-                emit(output.Copy())
-            }
-            mapper(input, emit2)
-        }
-
-        out.Fields = results
-        oldReverse := out.Reverse
-        out.Reverse = func(in2 Struct) Struct {
-            out2 := in2.MapFields(func (input2 Field, emit2 func(output Field)) {
-                if input2.Reverse != nil {
-                    input2.Reverse(input2.Copy(), emit2)
-                } else {
-                    emit2(input2.Copy())
-                }
-            })
-            if oldReverse != nil {
-                out2 = oldReverse(out2.Copy())
-            }
-            return out2
-        }
+        out, _ := mapFields(in, name, func(input Field, emit func(output Field)) error {
+            mapper(input, emit)
+            return nil
+        })
         return out
     }
 }
@@ -545,14 +551,15 @@ func (mapper FieldMapper) StructMapper() StructMapper {
 // Struct represents a Go struct - it's type name, type constraints (if using
 // generics), doc comment, and fields.
 //
-// If the struct has been renamed, From is its previous name. Otherwise, it is
-// the empty string.
+// If the struct has been produced by mappers, From records its lineage,
+// including its name before any mapping. See [Struct.Origin].
 type Struct struct {
     Comment    string
     Name       string
     TypeParams []Field
     Fields     []Field
     Reverse StructMapper
+    From       Lineage
 }
 
 func (s Struct) namedField(name string) (Field, bool) {
@@ -572,20 +579,23 @@ func (s Struct) Copy() Struct {
         TypeParams: internal.RecursiveCopySlice(s.TypeParams),
         Fields:     internal.RecursiveCopySlice(s.Fields),
         Reverse:    s.Reverse,
+        From:       s.From.Copy(),
     }
     return ss
 }
 
 // Map applies each given [StructMapper] (in order of the arguments provided)
 // to a struct and returns the result.
+//
+// Each mapper that changes the struct is recorded in the result's lineage
+// (see [Struct.Origin]).
 func (s Struct) Map(mappers ... StructMapper) Struct {
     ss := s
-    if len(mappers) > 0 {
-        ss = ss.Copy()
-    }
     for _, t := range mappers {
         if t == nil { continue }
-        ss = t(ss)
+        in := ss.Copy()
+        ss = t(ss.Copy())
+        ss.Derive(in, internal.FuncName(t))
     }
     return ss
 }
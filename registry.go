@@ -0,0 +1,238 @@
+package morph
+
+import (
+    "fmt"
+    "sort"
+    "sync"
+
+    "github.com/tawesoft/morph/internal"
+)
+
+// FieldExpressionTypeRegistry is a collection of [FieldExpressionType] values,
+// indexed by their Name.
+//
+// A registry lets a function generator find the FieldExpressionType for a
+// named operation even when no field on a struct has a custom
+// [FieldExpression] for that operation. In that case, the
+// FieldExpressionType's Default pattern is applied to every field, in the same
+// way as the builtin operations like [Struct.Comparer] and [Struct.Copier].
+//
+// A registry may have a parent registry. Lookups that fail in a registry are
+// retried in its parent. This allows a generator to keep its own
+// FieldExpressionTypes private, while still seeing those registered globally
+// in [DefaultFieldExpressionTypes].
+//
+// A registry is safe for concurrent use.
+type FieldExpressionTypeRegistry struct {
+    mutex  sync.RWMutex
+    types  map[string]*FieldExpressionType
+    parent *FieldExpressionTypeRegistry
+}
+
+// DefaultFieldExpressionTypes is the global registry of FieldExpressionTypes.
+//
+// It always contains the builtin FieldExpressionTypes "Converter", "Comparer",
+// "Copier", "Orderer", "Zeroer", and "Truther".
+//
+// It is used by [Struct.CustomUnaryFunction] and
+// [Struct.CustomBinaryFunction].
+var DefaultFieldExpressionTypes = func() *FieldExpressionTypeRegistry {
+    r := NewFieldExpressionTypeRegistry(nil)
+    internal.Assert(r.Register(
+        converterFieldExpressionType,
+        comparerFieldExpressionType,
+        copierFieldExpressionType,
+        ordererFieldExpressionType,
+        zeroerFieldExpressionType,
+        trutherFieldExpressionType,
+    ))
+    return r
+}()
+
+// NewFieldExpressionTypeRegistry returns a new, empty, registry. The parent
+// argument may be nil, or may be another registry (such as
+// [DefaultFieldExpressionTypes]) which is searched when a lookup fails.
+func NewFieldExpressionTypeRegistry(parent *FieldExpressionTypeRegistry) *FieldExpressionTypeRegistry {
+    return &FieldExpressionTypeRegistry{
+        types:  make(map[string]*FieldExpressionType),
+        parent: parent,
+    }
+}
+
+// RegisterFieldExpressionType registers each FieldExpressionType in the global
+// registry, [DefaultFieldExpressionTypes]. See
+// [FieldExpressionTypeRegistry.Register].
+func RegisterFieldExpressionType(fets ... *FieldExpressionType) error {
+    return DefaultFieldExpressionTypes.Register(fets...)
+}
+
+// LookupFieldExpressionType returns the FieldExpressionType with the given
+// name from the global registry, [DefaultFieldExpressionTypes], or nil if not
+// found.
+func LookupFieldExpressionType(name string) *FieldExpressionType {
+    return DefaultFieldExpressionTypes.Lookup(name)
+}
+
+// FieldExpressionTypeConflictError is the error returned when two different
+// FieldExpressionTypes share the same Name, for example when registering a
+// FieldExpressionType or when generating a function for a struct whose fields
+// refer to different FieldExpressionTypes with the same Name.
+type FieldExpressionTypeConflictError struct {
+    Name string
+}
+
+func (e FieldExpressionTypeConflictError) Error() string {
+    return fmt.Sprintf("conflicting FieldExpressionTypes with the same name %q", e.Name)
+}
+
+// Register adds each FieldExpressionType to the registry.
+//
+// Registering the same FieldExpressionType more than once is allowed and has
+// no effect. However, it is an error, of type
+// [FieldExpressionTypeConflictError], to register a different
+// FieldExpressionType with the same Name as one already in the registry or any
+// of its parents. On error, none of the FieldExpressionTypes are registered.
+func (r *FieldExpressionTypeRegistry) Register(fets ... *FieldExpressionType) error {
+    r.mutex.Lock()
+    defer r.mutex.Unlock()
+
+    pending := make(map[string]*FieldExpressionType)
+    for _, fet := range fets {
+        if err := fet.validate(); err != nil {
+            return fmt.Errorf("cannot register FieldExpressionType: %w", err)
+        }
+        existing := r.lookup(fet.Name)
+        if existing == nil { existing = pending[fet.Name] }
+        if (existing != nil) && (existing != fet) {
+            return FieldExpressionTypeConflictError{Name: fet.Name}
+        }
+        pending[fet.Name] = fet
+    }
+
+    for name, fet := range pending {
+        r.types[name] = fet
+    }
+    return nil
+}
+
+// Lookup returns the FieldExpressionType with the given name, searching the
+// parent registry (if any) if it is not found, or nil if not found at all.
+func (r *FieldExpressionTypeRegistry) Lookup(name string) *FieldExpressionType {
+    r.mutex.RLock()
+    defer r.mutex.RUnlock()
+    return r.lookup(name)
+}
+
+// lookup implements Lookup, and must be called with a read lock held.
+func (r *FieldExpressionTypeRegistry) lookup(name string) *FieldExpressionType {
+    if fet, ok := r.types[name]; ok {
+        return fet
+    }
+    if r.parent != nil {
+        return r.parent.Lookup(name)
+    }
+    return nil
+}
+
+// Names returns the sorted names of every FieldExpressionType in the registry,
+// including those in any parent registry.
+func (r *FieldExpressionTypeRegistry) Names() []string {
+    names := internal.NewSet[string]()
+    var result []string
+    for current := r; current != nil; current = current.parent {
+        current.mutex.RLock()
+        for name := range current.types {
+            if names.Contains(name) { continue }
+            names.Add(name)
+            result = append(result, name)
+        }
+        current.mutex.RUnlock()
+    }
+    sort.Strings(result)
+    return result
+}
+
+// UnaryFunction generates a function that performs the named operation on a
+// single struct value, as described by [Struct.CustomUnaryFunction], but
+// looking up the FieldExpressionType in this registry.
+func (r *FieldExpressionTypeRegistry) UnaryFunction(s Struct, operation string, signature string) (Function, error) {
+    fet, err := r.match(s, 1, operation)
+    if err != nil {
+        return Function{}, fmt.Errorf(
+            "error generating morph.Struct function for struct %q: %w",
+            s.Name, err,
+        )
+    }
+    return fet.formatStructUnaryFunction(operation, signature, s)
+}
+
+// BinaryFunction generates a function that performs the named operation on
+// two struct values, as described by [Struct.CustomBinaryFunction], but
+// looking up the FieldExpressionType in this registry.
+func (r *FieldExpressionTypeRegistry) BinaryFunction(s Struct, operation string, signature string, other Struct) (Function, error) {
+    fet, err := r.match(s, 2, operation)
+    if err != nil {
+        return Function{}, fmt.Errorf(
+            "error generating morph.Struct function for structs %q and %q: %w",
+            s.Name, other.Name, err,
+        )
+    }
+    return fet.formatStructBinaryFunction(operation, signature, s, other)
+}
+
+// match finds the FieldExpressionType for the named operation. This is taken
+// from the custom field expressions on the struct's fields, if any, otherwise
+// from the registry.
+//
+// It is an error if the fields, or the fields and the registry, disagree on
+// which FieldExpressionType has that name, or if the FieldExpressionType has
+// the wrong number of targets.
+func (r *FieldExpressionTypeRegistry) match(s Struct, targets int, operation string) (*FieldExpressionType, error) {
+    var fet *FieldExpressionType
+    for _, f := range s.Fields {
+        fe := f.GetCustomExpression(operation)
+        if (fe == nil) || (fe.Type == nil) { continue }
+        if (fet != nil) && (fet != fe.Type) {
+            return nil, FieldExpressionTypeConflictError{Name: operation}
+        }
+        fet = fe.Type
+    }
+
+    registered := r.Lookup(operation)
+    if fet == nil {
+        fet = registered
+    } else if (registered != nil) && (registered != fet) {
+        return nil, FieldExpressionTypeConflictError{Name: operation}
+    }
+
+    if fet == nil {
+        return nil, fmt.Errorf("no FieldExpressionType for operation %q", operation)
+    }
+    if fet.Targets != targets {
+        return nil, fmt.Errorf(
+            "FieldExpressionType for operation %q has %d target(s) (expected %d)",
+            operation, fet.Targets, targets,
+        )
+    }
+    return fet, nil
+}
+
+// validate returns a non-nil error if a FieldExpressionType is not suitable
+// for registration.
+func (fet *FieldExpressionType) validate() error {
+    if fet == nil {
+        return fmt.Errorf("nil FieldExpressionType")
+    }
+    if fet.Name == "" {
+        return fmt.Errorf("FieldExpressionType has an empty Name")
+    }
+    if (fet.Targets != 1) && (fet.Targets != 2) {
+        return fmt.Errorf("FieldExpressionType %q has invalid Targets %d", fet.Name, fet.Targets)
+    }
+    switch fet.Type {
+    case FieldExpressionTypeVoid, FieldExpressionTypeBool, FieldExpressionTypeValue, FieldExpressionTypeAggregate:
+    default:
+        return fmt.Errorf("FieldExpressionType %q has invalid Type %q", fet.Name, fet.Type)
+    }
+    return nil
+}
@@ -0,0 +1,165 @@
+package morph_test
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/internal"
+)
+
+func TestFieldExpressionTypeRegistry(t *testing.T) {
+    fetIsZero := &morph.FieldExpressionType{
+        Name:    "IsZero",
+        Targets: 1,
+        Type:    morph.FieldExpressionTypeBool,
+        Default: "$this == (func() (_zero $this.$type) { return })()",
+        Comment: "$ returns true if every field on $self is zero.",
+        FieldComment: "is $this zero?",
+        Collect: "&&",
+    }
+    fetIsZero2 := &morph.FieldExpressionType{
+        Name:    "IsZero",
+        Targets: 1,
+        Type:    morph.FieldExpressionTypeBool,
+        Default: "$this.IsZero()",
+        Collect: "&&",
+    }
+
+    parent := morph.NewFieldExpressionTypeRegistry(morph.DefaultFieldExpressionTypes)
+    child := morph.NewFieldExpressionTypeRegistry(parent)
+
+    if err := parent.Register(fetIsZero); err != nil {
+        t.Fatalf("unexpected error registering: %v", err)
+    }
+    if err := parent.Register(fetIsZero); err != nil {
+        t.Errorf("unexpected error registering the same type twice: %v", err)
+    }
+
+    var conflict morph.FieldExpressionTypeConflictError
+    if err := parent.Register(fetIsZero2); !errors.As(err, &conflict) {
+        t.Errorf("expected conflict error registering a different type with the same name, got %v", err)
+    }
+    if err := child.Register(fetIsZero2); !errors.As(err, &conflict) {
+        t.Errorf("expected conflict error registering a type with the same name as the parent, got %v", err)
+    }
+    if err := child.Register(&morph.FieldExpressionType{Name: "Bad"}); err == nil {
+        t.Errorf("expected error registering an invalid type")
+    }
+
+    if got := child.Lookup("IsZero"); got != fetIsZero {
+        t.Errorf("child lookup did not find type registered on parent")
+    }
+    if got := child.Lookup("Missing"); got != nil {
+        t.Errorf("lookup of a missing type should be nil")
+    }
+    if got := morph.LookupFieldExpressionType("IsZero"); got != nil {
+        t.Errorf("type registered on a private registry leaked into the global registry")
+    }
+
+    expectedNames := []string{"Comparer", "Converter", "Copier", "IsZero", "Orderer", "Truther", "Zeroer"}
+    if got := child.Names(); !reflect.DeepEqual(got, expectedNames) {
+        t.Errorf("got names %v, expected %v", got, expectedNames)
+    }
+
+    apple := morph.Struct{
+        Name: "Apple",
+        Fields: []morph.Field{
+            {Name: "Picked", Type: "time.Time"},
+            {Name: "Weight", Type: "int64"},
+        },
+    }
+
+    // no field has a custom expression, so the default is applied to every
+    // field.
+    f, err := child.UnaryFunction(apple, "IsZero", "($self.$type.$untitle $self.$type) $()() bool")
+    if err != nil {
+        t.Fatalf("unexpected error generating function: %v", err)
+    }
+    expected := internal.Must(internal.FormatSource(`
+// IsZero returns true if every field on apple is zero.
+func (apple Apple) IsZero() bool {
+    // is apple.Picked zero?
+    _cmp0 := bool(apple.Picked == (func() (_zero time.Time) { return })())
+    if !_cmp0 {
+        return false
+    }
+
+    // is apple.Weight zero?
+    _cmp1 := bool(apple.Weight == (func() (_zero int64) { return })())
+    if !_cmp1 {
+        return false
+    }
+
+    return true
+}
+`))
+    if f.String() != expected {
+        t.Logf("got: %s", f.String())
+        t.Logf("expected: %s", expected)
+        t.Errorf("unexpected output")
+    }
+
+    // not registered globally
+    if _, err := apple.CustomUnaryFunction("IsZero", "IsZero(apple Apple) bool"); err == nil {
+        t.Errorf("expected error generating function for an unregistered operation")
+    }
+
+    // builtin types are registered globally
+    if _, err := apple.CustomBinaryFunction("Comparer", "Equal(a Apple, b Apple) bool", apple); err != nil {
+        t.Errorf("unexpected error generating function for a builtin operation: %v", err)
+    }
+
+    // a field disagrees with the registry
+    conflicting := apple.Copy()
+    conflicting.Fields[0].SetCustomExpression(morph.FieldExpression{
+        Type:    fetIsZero2,
+        Pattern: "$this.IsZero()",
+    })
+    if _, err := child.UnaryFunction(conflicting, "IsZero", "IsZero(apple Apple) bool"); !errors.As(err, &conflict) {
+        t.Errorf("expected conflict error generating function, got %v", err)
+    }
+
+    // fields disagree with each other
+    conflicting.Fields[1].SetCustomExpression(morph.FieldExpression{
+        Type:    fetIsZero,
+        Pattern: "$this == 0",
+    })
+    if _, err := conflicting.CustomUnaryFunction("IsZero", "IsZero(apple Apple) bool"); !errors.As(err, &conflict) {
+        t.Errorf("expected conflict error generating function, got %v", err)
+    }
+}
+
+func TestFieldExpressionType_FormatField(t *testing.T) {
+    f := morph.Field{Name: "Price", Type: "float64"}
+    type row struct {
+        name    string
+        pattern string
+        values  []string
+        expected string // or "" for an error
+    }
+    for _, r := range []row{
+        {"Orderer",   "$a.$ < $b.$",                  []string{"x[i]", "x[j]"}, "x[i] < x[j]"},
+        {"Orderer",   "$a.$.$type($a.$) < $b.$",      []string{"x[i]", "x[j]"}, "float64(x[i]) < x[j]"},
+        {"Converter", "$dest.$ = $src.$",             []string{"out", "in"},    "out = in"},
+        {"Truther",   "$this != (func() (_zero $this.$type) { return })()", []string{"v"}, "v != (func() (_zero float64) { return })()"},
+        {"Truther",   "$self.$ > 0",                  []string{"v"},            "v > 0"},
+        {"Orderer",   "$a.Cost < $b.Cost",            []string{"x[i]", "x[j]"}, ""},
+        {"Orderer",   "$a < $b",                      []string{"x[i]", "x[j]"}, ""},
+        {"Orderer",   "$a.$type",                     []string{"x[i]", "x[j]"}, ""},
+        {"Orderer",   "$a.$ < $b.$",                  []string{"x[i]"},         ""},
+    } {
+        fet := morph.LookupFieldExpressionType(r.name)
+        got, err := fet.FormatField(r.pattern, f, r.values...)
+        if (r.expected == "") && (err == nil) {
+            t.Errorf("%s %q: expected error, got %q", r.name, r.pattern, got)
+        } else if (r.expected != "") && (got != r.expected) {
+            t.Errorf("%s %q: got %q, %v, expected %q", r.name, r.pattern, got, err, r.expected)
+        }
+    }
+
+    if got := morph.LookupFieldExpressionType("Orderer").Pattern(f); got != "$a.$ < $b.$" {
+        t.Errorf("unexpected default pattern %q", got)
+    }
+}
@@ -0,0 +1,319 @@
+// Package report generates human-readable reports, in Markdown or HTML, that
+// describe how one [morph.Struct] was mapped to another.
+//
+// A report is intended to be checked in to a repository next to generated
+// code, so that reviewers can see what a morph pipeline did without reading
+// the pipeline itself.
+package report
+
+import (
+    "fmt"
+    "html"
+    "strings"
+    "unicode"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/internal"
+)
+
+// Status describes what happened to a field during mapping.
+type Status string
+
+const (
+    StatusUnchanged Status = "unchanged" // same name and type
+    StatusRenamed   Status = "renamed"   // same type, different name
+    StatusRetyped   Status = "retyped"   // same name, different type
+    StatusChanged   Status = "changed"   // different name and type
+    StatusAdded     Status = "added"     // no source field
+    StatusDropped   Status = "dropped"   // no destination field
+    StatusSkipped   Status = "skipped"   // not converted (Converter is "skip")
+)
+
+// Report describes a mapping from a source struct to a destination struct.
+type Report struct {
+    From morph.Struct
+    To   morph.Struct
+
+    // Converter and Reverse are optional generated functions that convert
+    // from the source struct to the destination struct, and back again. A
+    // zero value means that the function is not listed in the report.
+    Converter morph.Function
+    Reverse   morph.Function
+}
+
+// Row describes a single source field, destination field, or both, in a
+// [Report].
+//
+// The Converter, Comparer, Copier and Orderer patterns are those that apply
+// to the destination field (or, for a dropped field, the source field), with
+// any defaults applied. An added field has no source field to convert from,
+// so its Converter is empty unless one is set on the field.
+//
+// Conversion is the statement that [Report.Converter] generated to set the
+// destination field, and ReverseConversion is the statement that
+// [Report.Reverse] generated to set the source field. Each is empty if there
+// is no such function, or if it skipped the field.
+type Row struct {
+    From      *morph.Field // nil if added
+    To        *morph.Field // nil if dropped
+    Status    Status
+    Converter string
+    Comparer  string
+    Copier    string
+    Orderer   string
+    Mappers   []string // mappers that changed the destination field
+
+    Conversion        string
+    ReverseConversion string
+}
+
+// Rows returns a row for each field on the destination struct, in order,
+// followed by a row for each source field that was dropped.
+//
+// A destination field is matched with the source field it was derived from
+// (see [morph.Field.Origin]). Only if no destination field has a lineage,
+// because the fields were never mapped, is it matched with a source field of
+// the same name instead.
+func (r Report) Rows() []Row {
+    var rows []Row
+    used := make(map[string]bool)
+    lineage := false
+    for _, f := range r.To.Fields {
+        if f.From.Name != "" { lineage = true }
+    }
+    conversions := statements(r.Converter)
+    reverse := statements(r.Reverse)
+
+    for _, to := range r.To.Fields {
+        to := to
+        row := Row{To: &to, Mappers: to.Origin().Mappers, Conversion: conversions[to.Name]}
+        if from, ok := matchingField(r.From, to, lineage); ok {
+            used[from.Name] = true
+            row.From = &from
+            row.Status = status(from, to)
+            row.ReverseConversion = reverse[from.Name]
+        } else {
+            row.Status = StatusAdded
+        }
+        row.setPatterns(to)
+        if (row.From == nil) && (to.Converter == "") {
+            row.Converter = ""
+        }
+        if (row.From != nil) && (row.Converter == "skip") {
+            row.Status = StatusSkipped
+        }
+        rows = append(rows, row)
+    }
+
+    for _, from := range r.From.Fields {
+        if used[from.Name] { continue }
+        from := from
+        row := Row{From: &from, Status: StatusDropped, ReverseConversion: reverse[from.Name]}
+        row.setPatterns(from)
+        rows = append(rows, row)
+    }
+
+    return rows
+}
+
+// columns are the headings of each column in a formatted report.
+var columns = []string{"Source field", "Source type", "Destination field",
+    "Destination type", "Status", "Converter", "Comparer", "Copier",
+    "Orderer", "Mappers", "Conversion", "Reverse conversion"}
+
+// Markdown returns the report formatted as Markdown.
+func (r Report) Markdown() string {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "# Mapping report: %s to %s\n\n",
+        markdownText(r.From.Name), markdownText(r.To.Name))
+    for _, item := range r.summary(markdownCode) {
+        fmt.Fprintf(&sb, "* %s: %s\n", item[0], item[1])
+    }
+    sb.WriteString("\n")
+
+    sb.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+    sb.WriteString(strings.Repeat("| --- ", len(columns)) + "|\n")
+
+    for _, row := range r.Rows() {
+        cells := row.cells(markdownCode, markdownText)
+        sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+    }
+    return sb.String()
+}
+
+// HTML returns the report formatted as an HTML fragment.
+func (r Report) HTML() string {
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "<h1>Mapping report: %s to %s</h1>\n",
+        html.EscapeString(r.From.Name), html.EscapeString(r.To.Name))
+    sb.WriteString("<ul>\n")
+    for _, item := range r.summary(htmlCode) {
+        fmt.Fprintf(&sb, "<li>%s: %s</li>\n", item[0], item[1])
+    }
+    sb.WriteString("</ul>\n")
+
+    sb.WriteString("<table>\n<thead>\n<tr>")
+    for _, h := range columns {
+        fmt.Fprintf(&sb, "<th>%s</th>", h)
+    }
+    sb.WriteString("</tr>\n</thead>\n<tbody>\n")
+    for _, row := range r.Rows() {
+        sb.WriteString("<tr>")
+        for _, cell := range row.cells(htmlCode, html.EscapeString) {
+            fmt.Fprintf(&sb, "<td>%s</td>", cell)
+        }
+        sb.WriteString("</tr>\n")
+    }
+    sb.WriteString("</tbody>\n</table>\n")
+    return sb.String()
+}
+
+// summary returns a list of labels and values describing the generated
+// functions and field counts, where each value is formatted with code.
+func (r Report) summary(code func(string) string) [][2]string {
+    var items [][2]string
+    if r.Converter.Signature.Name != "" {
+        items = append(items, [2]string{"Converter", code(signature(r.Converter.Signature))})
+    }
+    if r.Reverse.Signature.Name != "" {
+        items = append(items, [2]string{"Reverse", code(signature(r.Reverse.Signature))})
+    }
+    items = append(items,
+        [2]string{"Source fields", fmt.Sprintf("%d", len(r.From.Fields))},
+        [2]string{"Destination fields", fmt.Sprintf("%d", len(r.To.Fields))},
+    )
+    return items
+}
+
+// cells returns the formatted cells of a row, using code to format each code
+// value and text to escape any other text.
+func (row Row) cells(code func(string) string, text func(string) string) []string {
+    var fromName, fromType, toName, toType string
+    if row.From != nil {
+        fromName, fromType = code(row.From.Name), code(row.From.Type)
+    }
+    if row.To != nil {
+        toName, toType = code(row.To.Name), code(row.To.Type)
+    }
+    return []string{
+        fromName, fromType, toName, toType,
+        text(string(row.Status)),
+        code(row.Converter),
+        code(row.Comparer),
+        code(row.Copier),
+        code(row.Orderer),
+        text(strings.Join(row.Mappers, ", ")),
+        code(row.Conversion),
+        code(row.ReverseConversion),
+    }
+}
+
+// setPatterns sets the patterns on a row from a field.
+func (row *Row) setPatterns(f morph.Field) {
+    row.Converter = pattern("Converter", f)
+    row.Comparer  = pattern("Comparer", f)
+    row.Copier    = pattern("Copier", f)
+    row.Orderer   = pattern("Orderer", f)
+}
+
+// pattern returns the pattern of the named builtin field expression on a
+// field, or its default if not set.
+func pattern(name string, f morph.Field) string {
+    fet := morph.LookupFieldExpressionType(name)
+    if fet == nil { return "" }
+    var p string
+    if fet.Accessor != nil {
+        p = fet.Accessor(f)
+    } else if fe := f.GetCustomExpression(name); fe != nil {
+        p = fe.Pattern
+    }
+    if p == "" { p = fet.Default }
+    if p == "" { p = "skip" }
+    return p
+}
+
+// signature formats a function signature as a single line of Go source
+// code, including the leading "func" keyword.
+func signature(fs morph.FunctionSignature) string {
+    source := "func " + fs.String()
+    formatted, err := internal.FormatSource(source + " {}")
+    if err != nil { return source }
+    return strings.TrimSuffix(formatted, " {}")
+}
+
+func status(from morph.Field, to morph.Field) Status {
+    switch {
+    case (from.Name == to.Name) && (from.Type == to.Type): return StatusUnchanged
+    case (from.Type == to.Type): return StatusRenamed
+    case (from.Name == to.Name): return StatusRetyped
+    default: return StatusChanged
+    }
+}
+
+// matchingField returns the field on s that the field f was derived from,
+// by its lineage or, if lineage is false, by name. A field without a lineage
+// on a struct whose fields have lineage was added, so matches nothing.
+func matchingField(s morph.Struct, f morph.Field, lineage bool) (morph.Field, bool) {
+    name := f.Name
+    if f.From.Name != "" {
+        name = f.From.Name
+    } else if lineage {
+        return morph.Field{}, false
+    }
+    for _, x := range s.Fields {
+        if x.Name == name { return x, true }
+    }
+    return morph.Field{}, false
+}
+
+// statements returns, by field name, the statement that a generated
+// converter emitted to set each field of the value it returns, without the
+// field comments. A skipped field has no statement.
+func statements(fn morph.Function) map[string]string {
+    result := make(map[string]string)
+    for _, block := range strings.Split(fn.Body, "\n\n") {
+        var lines []string
+        for _, line := range strings.Split(block, "\n") {
+            line = strings.TrimSpace(line)
+            if strings.HasPrefix(line, "//") { continue }
+            lines = append(lines, line)
+        }
+        statement := strings.Join(lines, "\n")
+        _, name, ok := strings.Cut(statement, "_out.")
+        if !ok { continue }
+        if i := strings.IndexFunc(name, func(r rune) bool {
+            return !unicode.IsLetter(r) && !unicode.IsDigit(r) && (r != '_')
+        }); i >= 0 {
+            name = name[:i]
+        }
+        if _, exists := result[name]; !exists { result[name] = statement }
+    }
+    return result
+}
+
+// markdownCode formats a code value in a Markdown table cell.
+func markdownCode(s string) string {
+    if s == "" { return "" }
+    s = strings.ReplaceAll(s, "|", "\\|")
+    s = strings.ReplaceAll(s, "\n", " ")
+    if strings.Contains(s, "`") {
+        return "`` " + s + " ``"
+    }
+    return "`" + s + "`"
+}
+
+// markdownText escapes text in a Markdown heading or table cell.
+func markdownText(s string) string {
+    return markdownEscaper.Replace(s)
+}
+
+var markdownEscaper = strings.NewReplacer(
+    "\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "[", "\\[", "]", "\\]",
+    "<", "\\<", ">", "\\>", "#", "\\#", "|", "\\|", "\n", " ",
+)
+
+// htmlCode formats a code value in an HTML table cell.
+func htmlCode(s string) string {
+    if s == "" { return "" }
+    return "<code>" + html.EscapeString(s) + "</code>"
+}
@@ -0,0 +1,134 @@
+package report_test
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/fieldmappers"
+    "github.com/tawesoft/morph/internal"
+    "github.com/tawesoft/morph/report"
+    "github.com/tawesoft/morph/structmappers"
+)
+
+func TestReport(t *testing.T) {
+    apple := morph.Struct{
+        Name: "Apple",
+        Fields: []morph.Field{
+            {Name: "Picked", Type: "time.Time"},
+            {Name: "Weight", Type: "int64"},
+            {Name: "Secret", Type: "string"},
+            {Name: "Cache",  Type: "[]byte"},
+        },
+    }
+
+    orange := apple.Map(
+        structmappers.Rename("Orange"),
+    ).MapFields(
+        fieldmappers.TimeToInt64,
+        fieldmappers.DeleteNamed("Secret"),
+        func(input morph.Field, emit func(output morph.Field)) {
+            if input.Name == "Cache" { input.Converter = "skip" }
+            emit(input)
+        },
+    )
+
+    converter := internal.Must(morph.StructConverter(
+        "($src.$type.$untitle $src.$type) To$dest.$type() $dest.$type",
+        apple, orange,
+    ))
+
+    // a field without a source
+    orange = orange.Map(
+        structmappers.AppendFields([]morph.Field{{Name: "Count", Type: "int"}}),
+    )
+
+    r := report.Report{
+        From:      apple,
+        To:        orange,
+        Converter: converter,
+    }
+
+    expected := strings.TrimLeft(`
+# Mapping report: Apple to Orange
+
+* Converter: `+"`func (apple Apple) ToOrange() Orange`"+`
+* Source fields: 4
+* Destination fields: 4
+
+| Source field | Source type | Destination field | Destination type | Status | Converter | Comparer | Copier | Orderer | Mappers | Conversion | Reverse conversion |
+| --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- |
+| `+"`Picked` | `time.Time` | `Picked` | `int64` | retyped | `$dest.$ = $src.$.UTC().Unix()` | `$a.$ == $b.$` | `$dest.$ == $src.$` | `$a.$ < $b.$` | fieldmappers.TimeToInt64 | `_out.Picked = apple.Picked.UTC().Unix()` |  |"+`
+| `+"`Weight` | `int64` | `Weight` | `int64` | unchanged | `$dest.$ = $src.$` | `$a.$ == $b.$` | `$dest.$ == $src.$` | `$a.$ < $b.$` |  | `_out.Weight = apple.Weight` |  |"+`
+| `+"`Cache` | `[]byte` | `Cache` | `[]byte` | skipped | `skip` | `$a.$ == $b.$` | `$dest.$ == $src.$` | `$a.$ < $b.$` | report\\_test.TestReport |  |  |"+`
+|  |  | `+"`Count` | `int` | added |  | `$a.$ == $b.$` | `$dest.$ == $src.$` | `$a.$ < $b.$` |  |  |  |"+`
+| `+"`Secret` | `string` |  |  | dropped | `$dest.$ = $src.$` | `$a.$ == $b.$` | `$dest.$ == $src.$` | `$a.$ < $b.$` |  |  |  |"+`
+`, "\n")
+
+    if got := r.Markdown(); got != expected {
+        t.Logf("got:\n%s", got)
+        t.Logf("expected:\n%s", expected)
+        t.Errorf("unexpected Markdown output")
+    }
+
+    html := r.HTML()
+    for _, s := range []string{
+        "<h1>Mapping report: Apple to Orange</h1>",
+        "<li>Converter: <code>func (apple Apple) ToOrange() Orange</code></li>",
+        "<td><code>Secret</code></td><td><code>string</code></td><td></td><td></td><td>dropped</td>",
+        "<td><code>$a.$ &lt; $b.$</code></td>",
+        "<td>fieldmappers.TimeToInt64</td><td><code>_out.Picked = apple.Picked.UTC().Unix()</code></td>",
+    } {
+        if !strings.Contains(html, s) {
+            t.Errorf("HTML output missing %q:\n%s", s, html)
+        }
+    }
+}
+
+func TestReport_rows(t *testing.T) {
+    a := morph.Struct{
+        Name: "A",
+        Fields: []morph.Field{
+            {Name: "Name",   Type: "string"},
+            {Name: "Secret", Type: "string"},
+        },
+    }
+
+    // a new field that reuses the name of a dropped field
+    b := a.Map(
+        structmappers.Rename("B"),
+    ).MapFields(
+        fieldmappers.DeleteNamed("Secret"),
+    ).Map(
+        structmappers.AppendFields([]morph.Field{{Name: "Secret", Type: "int"}}),
+    )
+
+    reverse := internal.Must(morph.StructConverter(
+        "($src.$type.$untitle $src.$type) To$dest.$type() $dest.$type",
+        b, a,
+    ))
+
+    rows := report.Report{From: a, To: b, Reverse: reverse}.Rows()
+    type result struct {
+        from, to string
+        status report.Status
+        reverse string
+    }
+    var got []result
+    for _, row := range rows {
+        var x result
+        if row.From != nil { x.from = row.From.Name }
+        if row.To != nil { x.to = row.To.Name }
+        x.status, x.reverse = row.Status, row.ReverseConversion
+        got = append(got, x)
+    }
+    expected := []result{
+        {"Name", "Name", report.StatusUnchanged, "_out.Name = b.Name"},
+        {"", "Secret", report.StatusAdded, ""},
+        {"Secret", "", report.StatusDropped, "_out.Secret = b.Secret"},
+    }
+    if !reflect.DeepEqual(got, expected) {
+        t.Errorf("got %v, expected %v", got, expected)
+    }
+}
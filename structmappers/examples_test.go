@@ -45,3 +45,63 @@ type Apple struct {
     //	Price     price.Price
     // }
 }
+
+func ExampleColumns() {
+    source := `
+package example
+
+type Point struct {
+    X     int
+    Y     int
+    Label string
+}
+`
+
+    point := must(morph.ParseStruct("test.go", source, "Point"))
+    pointColumns := point.Map(structmappers.Columns("$Columns"))
+    fmt.Println(pointColumns)
+
+    pointAgain := pointColumns.Map(structmappers.Reverse)
+    fmt.Println(pointAgain)
+
+    // output:
+    // // PointColumns stores [Point] values in column-orientated form, with a slice for each field.
+    // type PointColumns struct {
+    //	X     []int
+    //	Y     []int
+    //	Label []string
+    // }
+    // type Point struct {
+    //	X     int
+    //	Y     int
+    //	Label string
+    // }
+}
+
+func ExamplePacked() {
+    source := `
+package example
+
+type Flags struct {
+    Enabled bool
+    Level   uint8
+}
+`
+
+    flags := must(morph.ParseStruct("test.go", source, "Flags"))
+    flagsPacked := flags.Map(structmappers.Packed("$Packed", "uint16"))
+    fmt.Println(flagsPacked)
+
+    flagsAgain := flagsPacked.Map(structmappers.Reverse)
+    fmt.Println(flagsAgain)
+
+    // output:
+    // // FlagsPacked stores [Flags] values in bit-packed form.
+    // type FlagsPacked struct {
+    //	Bits uint16 // packed fields
+    // }
+    // type Flags struct {
+    //	Enabled bool
+    //	Level   uint8
+    // }
+}
@@ -0,0 +1,162 @@
+package structmappers
+
+import (
+    "fmt"
+    "go/token"
+    "go/types"
+    "sort"
+
+    "github.com/tawesoft/morph"
+)
+
+// Size returns the size, in bytes, of a struct for the given GOARCH (e.g.
+// "amd64") with the gc compiler, including any padding.
+//
+// Field types are evaluated in the universe scope, so it is an error if a
+// field has a type, such as time.Time, that is not built from predeclared
+// types.
+func Size(s morph.Struct, goarch string) (int64, error) {
+    sizes := types.SizesFor("gc", goarch)
+    if sizes == nil {
+        return 0, fmt.Errorf("unknown GOARCH %q", goarch)
+    }
+    fieldTypes, err := layoutTypes(s)
+    if err != nil { return 0, err }
+
+    vars := make([]*types.Var, 0, len(fieldTypes))
+    for i, t := range fieldTypes {
+        vars = append(vars, types.NewField(token.NoPos, nil, fmt.Sprintf("f%d", i), t, false))
+    }
+    return sizes.Sizeof(types.NewStruct(vars, nil)), nil
+}
+
+// layoutTypes returns the type of each field on a struct, or an error if
+// any field type cannot be evaluated.
+func layoutTypes(s morph.Struct) ([]types.Type, error) {
+    fset := token.NewFileSet()
+    result := make([]types.Type, 0, len(s.Fields))
+    for _, f := range s.Fields {
+        t, err := layoutType(fset, f)
+        if err != nil { return nil, err }
+        result = append(result, t)
+    }
+    return result, nil
+}
+
+// layoutType returns the type of a field, or an error if the field type
+// cannot be evaluated.
+func layoutType(fset *token.FileSet, f morph.Field) (types.Type, error) {
+    tv, err := types.Eval(fset, nil, token.NoPos, f.Type)
+    if err != nil {
+        return nil, fmt.Errorf("cannot evaluate type %q of field %q: %w", f.Type, f.Name, err)
+    }
+    if !tv.IsType() {
+        return nil, fmt.Errorf("field %q has invalid type %q", f.Name, f.Type)
+    }
+    return tv.Type, nil
+}
+
+// TypeLayout is the size and alignment, in bytes, of a field type that is
+// not built from predeclared types, such as time.Time, for
+// [OptimiseLayout].
+type TypeLayout struct {
+    Size  int64
+    Align int64
+}
+
+// OptimiseLayout returns a new [morph.FallibleStructMapper] that reorders a
+// struct's fields to minimise padding, using the size and alignment of each
+// field type for the given GOARCH (e.g. "amd64") with the gc compiler. It
+// returns an error if the GOARCH is not known.
+//
+// Fields are sorted by decreasing alignment, and then by decreasing size,
+// otherwise keeping their existing order. A field's expressions, tags, and
+// comments move with it.
+//
+// The size and alignment of a field type is looked up in layouts, by the
+// field's type e.g. "time.Time", or, if it is not there, the type is
+// evaluated as for [Size]. It is an error if a field type is in neither.
+//
+// If report is not nil, it is called with the size of the struct, in bytes,
+// before and after reordering.
+//
+// OptimiseLayout is reversible with [Reverse], so that converters between
+// the two layouts can be generated. The reverse mapper panics if the struct
+// it is given does not have the same number of fields: use
+// [morph.StructMapper.Fallible] to recover this as an error.
+func OptimiseLayout(
+    goarch string,
+    layouts map[string]TypeLayout,
+    report func(before int64, after int64),
+) morph.FallibleStructMapper {
+    return func(s morph.Struct) (morph.Struct, error) {
+        sizes := types.SizesFor("gc", goarch)
+        if sizes == nil {
+            return morph.Struct{}, fmt.Errorf("unknown GOARCH %q", goarch)
+        }
+
+        type entry struct {
+            index int
+            TypeLayout
+        }
+        entries := make([]entry, len(s.Fields))
+        fset := token.NewFileSet()
+        for i, f := range s.Fields {
+            e := entry{index: i}
+            if l, ok := layouts[f.Type]; ok {
+                if l.Align < 1 {
+                    return morph.Struct{}, fmt.Errorf("invalid alignment %d for type %q of field %q", l.Align, f.Type, f.Name)
+                }
+                e.TypeLayout = l
+            } else if t, err := layoutType(fset, f); err == nil {
+                e.Align = sizes.Alignof(t)
+                e.Size  = sizes.Sizeof(t)
+            } else {
+                return morph.Struct{}, fmt.Errorf("unknown size and alignment: %w", err)
+            }
+            entries[i] = e
+        }
+
+        // size returns the size of a struct with fields in the given order.
+        size := func(entries []entry) int64 {
+            var offset, align int64 = 0, 1
+            for _, e := range entries {
+                offset = (offset + e.Align - 1) / e.Align * e.Align + e.Size
+                if e.Align > align { align = e.Align }
+            }
+            // gc pads a final zero-size field, so its address is in bounds
+            if n := len(entries); (n > 0) && (entries[n-1].Size == 0) && (offset > 0) { offset++ }
+            return (offset + align - 1) / align * align
+        }
+        before := size(entries)
+
+        sort.SliceStable(entries, func(i, j int) bool {
+            a, b := entries[i], entries[j]
+            if a.Align != b.Align { return a.Align > b.Align }
+            return a.Size > b.Size
+        })
+        if report != nil { report(before, size(entries)) }
+
+        order := make([]int, len(entries)) // order[i] is the input index of output field i
+        fields := make([]morph.Field, len(entries))
+        for i, e := range entries {
+            order[i] = e.index
+            fields[i] = s.Fields[e.index]
+        }
+        s.Fields = fields
+
+        s.Reverse = Compose(func (in morph.Struct) morph.Struct {
+            if len(in.Fields) != len(order) {
+                panic(fmt.Errorf("cannot reverse the field order of struct %q: it has %d fields, but expected %d",
+                    in.Name, len(in.Fields), len(order)))
+            }
+            out := in
+            out.Fields = make([]morph.Field, len(order))
+            for i, j := range order {
+                out.Fields[j] = in.Fields[i]
+            }
+            return out
+        }, s.Reverse)
+        return s, nil
+    }
+}
@@ -0,0 +1,124 @@
+package structmappers_test
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/internal"
+    "github.com/tawesoft/morph/structmappers"
+)
+
+func TestOptimiseLayout(t *testing.T) {
+    source := `
+package example
+
+type Particle struct {
+    Alive  bool
+    X      float64
+    Kind   uint8
+    ID     int32
+    Name   string
+    Hidden bool
+}
+`
+    particle := internal.Must(morph.ParseStruct("test.go", source, "Particle"))
+    particle.Fields[0].SetCustomExpression(morph.FieldExpression{
+        Type:    morph.LookupFieldExpressionType("Comparer"),
+        Pattern: "skip",
+    })
+
+    var before, after int64
+    optimised := internal.Must(particle.Map(
+        structmappers.Rename("$Optimised"),
+    ).TryMap(
+        structmappers.OptimiseLayout("amd64", nil, func(b int64, a int64) {
+            before, after = b, a
+        }),
+    ))
+
+    if (before != 48) || (after != 32) {
+        t.Errorf("got sizes %d => %d, expected 48 => 32", before, after)
+    }
+    if size := internal.Must(structmappers.Size(optimised, "amd64")); size != after {
+        t.Errorf("got size %d, expected %d", size, after)
+    }
+    if size := internal.Must(structmappers.Size(particle, "386")); size != 32 {
+        t.Errorf("got size %d on 386, expected 32", size)
+    }
+
+    names := internal.Map(func(f morph.Field) string { return f.Name }, optimised.Fields)
+    if got := strings.Join(names, " "); got != "Name X ID Alive Kind Hidden" {
+        t.Errorf("unexpected field order %q", got)
+    }
+    if optimised.Fields[3].GetCustomExpression("Comparer") == nil {
+        t.Errorf("expected field expression to move with its field")
+    }
+
+    reversed := optimised.Map(structmappers.Reverse)
+    if reversed.String() != particle.String() {
+        t.Errorf("got reversed struct %s, expected %s", reversed, particle)
+    }
+
+    if _, err := morph.StructConverter(
+        "($src.$type.$untitle $src.$type) To$dest.$type() $dest.$type",
+        particle, optimised,
+    ); err != nil {
+        t.Errorf("unexpected error generating converter: %v", err)
+    }
+
+    // the reverse mapper fails for a struct with different fields
+    changed := optimised
+    changed.Fields = append([]morph.Field{{Name: "Extra", Type: "int"}}, optimised.Fields...)
+    if _, err := changed.TryMap(morph.StructMapper(structmappers.Reverse).Fallible()); err == nil {
+        t.Errorf("expected error reversing a struct with an extra field")
+    }
+
+    // a type that cannot be evaluated is looked up in layouts
+    picked := morph.Struct{
+        Name: "Picked",
+        Fields: []morph.Field{
+            {Name: "Alive",  Type: "bool"},
+            {Name: "Kind",   Type: "uint16"},
+            {Name: "Picked", Type: "time.Time"},
+            {Name: "Weight", Type: "[2]int64"},
+        },
+    }
+    picked = internal.Must(picked.TryMap(
+        structmappers.OptimiseLayout("amd64", map[string]structmappers.TypeLayout{
+            "time.Time": {Size: 24, Align: 8},
+        }, func(b int64, a int64) {
+            before, after = b, a
+        }),
+    ))
+    names = internal.Map(func(f morph.Field) string { return f.Name }, picked.Fields)
+    if got := strings.Join(names, " "); got != "Picked Weight Kind Alive" {
+        t.Errorf("unexpected field order %q", got)
+    }
+    if (before != 48) || (after != 48) {
+        t.Errorf("got sizes %d => %d, expected 48 => 48", before, after)
+    }
+
+    // errors
+    unknown := morph.Struct{
+        Name:   "Unknown",
+        Fields: []morph.Field{{Name: "Picked", Type: "time.Time"}},
+    }
+    if _, err := unknown.TryMap(structmappers.OptimiseLayout("nope", nil, nil)); err == nil {
+        t.Errorf("expected error for unknown GOARCH")
+    }
+    if _, err := unknown.TryMap(structmappers.OptimiseLayout("amd64", nil, nil)); err == nil {
+        t.Errorf("expected error for unknown type")
+    }
+    if _, err := unknown.TryMap(structmappers.OptimiseLayout("amd64", map[string]structmappers.TypeLayout{
+        "time.Time": {Size: 24},
+    }, nil)); err == nil {
+        t.Errorf("expected error for invalid alignment")
+    }
+    if _, err := structmappers.Size(unknown, "amd64"); err == nil {
+        t.Errorf("expected error for unknown type")
+    }
+    if _, err := structmappers.Size(particle, "nope"); err == nil {
+        t.Errorf("expected error for unknown GOARCH")
+    }
+}
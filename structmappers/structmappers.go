@@ -9,6 +9,7 @@ import (
     "strings"
 
     "github.com/tawesoft/morph"
+    "github.com/tawesoft/morph/internal"
 )
 
 // Compose returns a new [morph.StructMapper] that applies each of the given
@@ -24,6 +25,24 @@ func Compose(mappers ... morph.StructMapper) morph.StructMapper {
     }
 }
 
+// TryCompose returns a new [morph.FallibleStructMapper] that applies each of
+// the given non-nil mappers, from left to right. Nil mappers are skipped. It
+// stops at, and returns, the first error.
+func TryCompose(mappers ... morph.FallibleStructMapper) morph.FallibleStructMapper {
+    mappers = append([]morph.FallibleStructMapper{}, mappers...)
+    return func(in morph.Struct) (morph.Struct, error) {
+        for _, mapper := range mappers {
+            if mapper == nil { continue }
+            out, err := mapper(in)
+            if err != nil {
+                return morph.Struct{}, morph.NewMapperError(err, in.Name, "", internal.FuncName(mapper))
+            }
+            in = out
+        }
+        return in, nil
+    }
+}
+
 // StripComment is a [morph.StructMapper] that sets the struct's comment to the
 // empty string.
 func StripComment(s morph.Struct) morph.Struct {
@@ -61,6 +80,91 @@ func Rename(name string) morph.StructMapper {
     }
 }
 
+// Columns returns a new [morph.StructMapper] that maps a struct to a
+// column-orientated ("struct of arrays") form, where each field of type T is
+// replaced by a field of the same name with type []T, and each tag is
+// removed.
+//
+// The struct is renamed to the provided name, where the token "$" is
+// rewritten to the existing name, as in [Rename]. For example, Columns
+// ("$Columns") on a struct named "Foo" maps to a struct named "FooColumns".
+//
+// The struct's comment is replaced with one describing the column-orientated
+// form.
+//
+// Columns is reversible with [Reverse].
+//
+// The package morph/generators/columns can generate methods on the result.
+func Columns(name string) morph.StructMapper {
+    toSlice := morph.FieldMapper(func(input morph.Field, emit func(output morph.Field)) {
+        tag := input.Tag
+        input.Type = "[]$"
+        input.Tag = ""
+        input.Reverse = func(input2 morph.Field, emit2 func(output morph.Field)) {
+            input2.Type = strings.TrimPrefix(input2.Type, "[]")
+            input2.Tag = tag
+            emit2(input2)
+        }
+        emit(input)
+    })
+
+    return Compose(
+        func(s morph.Struct) morph.Struct {
+            oldComment := s.Comment
+            oldName := s.Name
+            s.Name = strings.ReplaceAll(name, "$", s.Name)
+            s.Comment = s.Name + " stores [" + oldName + "] values in " +
+                "column-orientated form, with a slice for each field."
+            s.Reverse = Compose(func (in morph.Struct) morph.Struct {
+                out := in
+                out.Name = oldName
+                out.Comment = oldComment
+                return out
+            }, s.Reverse)
+            return s
+        },
+        toSlice.StructMapper(),
+    )
+}
+
+// Packed returns a new [morph.StructMapper] that maps a struct to a
+// bit-packed form, where every field is replaced by a single field, Bits,
+// with the provided storage type (e.g. "uint32").
+//
+// The struct is renamed to the provided name, where the token "$" is
+// rewritten to the existing name, as in [Rename]. For example, Packed
+// ("$Packed", "uint32") on a struct named "Foo" maps to a struct named
+// "FooPacked".
+//
+// The struct's comment is replaced with one describing the bit-packed form.
+//
+// Packed is reversible with [Reverse].
+//
+// The package morph/generators/bitpack can generate methods on the result.
+func Packed(name string, storage string) morph.StructMapper {
+    return func(s morph.Struct) morph.Struct {
+        oldComment := s.Comment
+        oldName := s.Name
+        oldFields := s.Fields
+        s.Name = strings.ReplaceAll(name, "$", s.Name)
+        s.Comment = s.Name + " stores [" + oldName + "] values in " +
+            "bit-packed form."
+        s.Fields = []morph.Field{{
+            Name:    "Bits",
+            Type:    storage,
+            Comment: "packed fields",
+        }}
+        s.Reverse = Compose(func (in morph.Struct) morph.Struct {
+            out := in
+            out.Name = oldName
+            out.Comment = oldComment
+            out.Fields = append([]morph.Field(nil), oldFields...)
+            return out
+        }, s.Reverse)
+        return s
+    }
+}
+
 // AppendFields returns a new [morph.StructMapper] that adds the given fields
 // to the end of a struct's list of fields.
 //